@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"time"
+
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Types of the sink groups, every topic is part of one of them at a time.
+const (
+	MainSinkGroup       = "main"
+	ReloadSinkGroup     = "reload"
+	ReloadDupeSinkGroup = "reload-dupe"
+)
+
+var (
+	// DefaultBatcherMaxProcessingTime is the batcher MaxProcessingTime in ms
+	DefaultBatcherMaxProcessingTime int32 = 180000
+	// DefaultLoaderMaxProcessingTime is the loader MaxProcessingTime in ms
+	DefaultLoaderMaxProcessingTime int32 = 600000
+	// DefaultMaxReloadingUnits is the MaxReloadingUnits of all sink groups
+	DefaultMaxReloadingUnits int32 = 10
+	// DefaultAutoscaleMinUnits is the MinUnits of the autoscaling
+	DefaultAutoscaleMinUnits int32 = 1
+	// DefaultAutoscaleScaleDownDelay is the ScaleDownDelay of the autoscaling
+	DefaultAutoscaleScaleDownDelay = 10 * time.Minute
+)
+
+// batcherSinkGroupDefaults are the batcher defaults of the sink group type
+func batcherSinkGroupDefaults(sgType string) SinkGroupSpec {
+	switch sgType {
+	case MainSinkGroup:
+		return SinkGroupSpec{
+			MaxSizePerBatch: quantityPtr("0.8Mi"),
+			MaxWaitSeconds:  intPtr(450),
+			MaxConcurrency:  intPtr(2),
+		}
+	case ReloadSinkGroup:
+		return SinkGroupSpec{
+			MaxSizePerBatch: quantityPtr("0.5Mi"),
+			MaxWaitSeconds:  intPtr(60),
+			MaxConcurrency:  intPtr(10),
+		}
+	case ReloadDupeSinkGroup:
+		return SinkGroupSpec{
+			MaxSizePerBatch: quantityPtr("0.5Mi"),
+			MaxWaitSeconds:  intPtr(450),
+			MaxConcurrency:  intPtr(10),
+		}
+	}
+
+	return SinkGroupSpec{}
+}
+
+// loaderSinkGroupDefaults are the loader defaults of the sink group type
+func loaderSinkGroupDefaults(sgType string) SinkGroupSpec {
+	switch sgType {
+	case MainSinkGroup:
+		return SinkGroupSpec{
+			MaxSizePerBatch: quantityPtr("1Gi"),
+			MaxWaitSeconds:  intPtr(900),
+		}
+	case ReloadSinkGroup:
+		return SinkGroupSpec{
+			MaxSizePerBatch: quantityPtr("1Gi"),
+			MaxWaitSeconds:  intPtr(60),
+		}
+	case ReloadDupeSinkGroup:
+		return SinkGroupSpec{
+			MaxSizePerBatch: quantityPtr("1Gi"),
+			MaxWaitSeconds:  intPtr(900),
+		}
+	}
+
+	return SinkGroupSpec{}
+}
+
+// specifiedSpec returns the specified spec of the sink group type
+// following the precedence of the SinkGroup.
+func (s *SinkGroup) specifiedSpec(sgType string) *SinkGroupSpec {
+	if s == nil {
+		return nil
+	}
+
+	var spec *SinkGroupSpec
+	switch sgType {
+	case MainSinkGroup:
+		spec = s.Main
+	case ReloadSinkGroup:
+		spec = s.Reload
+	case ReloadDupeSinkGroup:
+		spec = s.ReloadDupe
+	}
+	if spec == nil {
+		spec = s.All
+	}
+
+	return spec
+}
+
+// withDefaults overwrites the defaults with the specified values.
+// Image is left nil when neither it is specified nor a default is given.
+func withDefaults(
+	specified *SinkGroupSpec,
+	defaults SinkGroupSpec,
+	defaultImage *string,
+) *SinkGroupSpec {
+	spec := defaults
+	spec.MaxReloadingUnits = int32Ptr(DefaultMaxReloadingUnits)
+	podTemplate := &RedshiftPodTemplateSpec{}
+	if defaultImage != nil {
+		podTemplate.Image = stringPtr(*defaultImage)
+	}
+
+	if specified != nil {
+		if specified.MaxSizePerBatch != nil {
+			spec.MaxSizePerBatch = specified.MaxSizePerBatch
+		}
+		if specified.MaxWaitSeconds != nil {
+			spec.MaxWaitSeconds = specified.MaxWaitSeconds
+		}
+		if specified.MaxConcurrency != nil {
+			spec.MaxConcurrency = specified.MaxConcurrency
+		}
+		if specified.MaxProcessingTime != nil {
+			spec.MaxProcessingTime = specified.MaxProcessingTime
+		}
+		if specified.MaxReloadingUnits != nil {
+			spec.MaxReloadingUnits = specified.MaxReloadingUnits
+		}
+		if specified.DeploymentUnit != nil &&
+			specified.DeploymentUnit.PodTemplate != nil {
+			image := podTemplate.Image
+			podTemplate = specified.DeploymentUnit.PodTemplate.DeepCopy()
+			if podTemplate.Image == nil {
+				podTemplate.Image = image
+			}
+		}
+	}
+	spec.DeploymentUnit = &DeploymentUnit{PodTemplate: podTemplate}
+
+	return &spec
+}
+
+// autoscaleWithDefaults returns the specified autoscaling with the defaults
+// applied, nil when the autoscaling is not specified.
+func autoscaleWithDefaults(specified *SinkGroupSpec) *SinkGroupAutoscale {
+	if specified == nil || specified.Autoscale == nil {
+		return nil
+	}
+
+	autoscale := specified.Autoscale.DeepCopy()
+	if autoscale.MinUnits == nil {
+		autoscale.MinUnits = int32Ptr(DefaultAutoscaleMinUnits)
+	}
+	if autoscale.ScaleDownDelay == nil {
+		autoscale.ScaleDownDelay = &metav1.Duration{
+			Duration: DefaultAutoscaleScaleDownDelay,
+		}
+	}
+
+	return autoscale
+}
+
+// BatcherSinkGroupSpec returns the batcher specification of the sink group
+// type with the defaults applied. User does not need to specify big lengthy
+// configurations everytime. Defaults are optimized for maximum performance
+// and are recommended to use. The image is not defaulted if defaultImage
+// is nil.
+func (r *RedshiftSink) BatcherSinkGroupSpec(
+	sgType string,
+	defaultImage *string,
+) *SinkGroupSpec {
+	defaults := batcherSinkGroupDefaults(sgType)
+	defaults.MaxProcessingTime = int32Ptr(DefaultBatcherMaxProcessingTime)
+
+	specified := r.Spec.Batcher.SinkGroup.specifiedSpec(sgType)
+	spec := withDefaults(specified, defaults, defaultImage)
+	if sgType == MainSinkGroup {
+		spec.Autoscale = autoscaleWithDefaults(specified)
+	}
+
+	return spec
+}
+
+// LoaderSinkGroupSpec returns the loader specification of the sink group
+// type with the defaults applied. Loaders are synchronous and do not use
+// MaxConcurrency. The image is not defaulted if defaultImage is nil.
+func (r *RedshiftSink) LoaderSinkGroupSpec(
+	sgType string,
+	defaultImage *string,
+) *SinkGroupSpec {
+	defaults := loaderSinkGroupDefaults(sgType)
+	defaults.MaxProcessingTime = int32Ptr(DefaultLoaderMaxProcessingTime)
+
+	specified := r.Spec.Loader.SinkGroup.specifiedSpec(sgType)
+	spec := withDefaults(specified, defaults, defaultImage)
+	spec.MaxConcurrency = nil
+	if sgType == MainSinkGroup {
+		spec.Autoscale = autoscaleWithDefaults(specified)
+	}
+
+	return spec
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func quantityPtr(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
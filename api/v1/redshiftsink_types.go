@@ -29,6 +29,9 @@ import (
 // RedshiftPodTemplateSpec supports a subset of `v1/PodTemplateSpec`
 // that the operator explicitly permits. We don't
 // want to allow a user to set arbitrary features on our underlying pods.
+// The schema of the larger core types is not generated, it would make
+// the CRD too large for a client side apply. They are validated when
+// the pods are created.
 type RedshiftPodTemplateSpec struct {
 	// Image for the underlying pod
 	// +optional
@@ -41,6 +44,87 @@ type RedshiftPodTemplateSpec struct {
 	// Toleartions the underlying pods should have
 	// +optional
 	Tolerations *[]corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Annotations added to the underlying pods, for example to let
+	// Prometheus scrape the pods.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Labels added to the underlying pods. The labels set by the
+	// operator take precedence as they are used to select the pods.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// ImagePullPolicy of the image. Defaults to Always.
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// ImagePullSecrets used to pull the image from private registries
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Env is the extra environment variables set in the container
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// EnvFrom is the extra sources to populate the environment
+	// variables of the container
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// NodeSelector the underlying pods should be scheduled with
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Affinity the underlying pods should be scheduled with
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints describes how the underlying pods
+	// should be spread across the topology domains.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName of the underlying pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ServiceAccountName the underlying pods run as, for example to
+	// use IAM roles for service accounts.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// SecurityContext of the underlying pods
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// ContainerSecurityContext of the container in the underlying pods
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+
+	// LivenessProbe of the container in the underlying pods
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// ReadinessProbe of the container in the underlying pods
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
 }
 
 // DeploymentUnit is used to specify how many topics will run together in a unit
@@ -85,6 +169,43 @@ type SinkGroupSpec struct {
 	// solves the scaling issues described in #167.
 	// +optional
 	DeploymentUnit *DeploymentUnit `json:"deploymentUnit,omitempty"`
+
+	// Autoscale scales the deployment units based on the lag and the
+	// load duration of the topics. Only valid for the Main SinkGroup.
+	// +optional
+	Autoscale *SinkGroupAutoscale `json:"autoscale,omitempty"`
+}
+
+// SinkGroupAutoscale specifies how the topics of the main sink group are
+// spread across the deployment units. The topics lagging beyond the
+// thresholds are moved to their own deployment units and are merged back
+// when they stay within the thresholds for the ScaleDownDelay.
+type SinkGroupAutoscale struct {
+	// MinUnits is the minimum number of units the topics are spread
+	// across. Defaults to 1.
+	// +optional
+	MinUnits *int32 `json:"minUnits,omitempty"`
+
+	// MaxUnits is the maximum number of units including the units
+	// of the topics moved to their own units.
+	MaxUnits int32 `json:"maxUnits"`
+
+	// MaxLag is the lag of the topic beyond which it is moved to its own
+	// unit. For the batcher it is the lag of the topic and for the loader
+	// it is the lag of the loader topic.
+	// +optional
+	MaxLag *int64 `json:"maxLag,omitempty"`
+
+	// MaxLoadSeconds is the average time taken to load a batch of the topic
+	// beyond which it is moved to its own unit. Only valid for the loader,
+	// it requires the operator to be configured with Prometheus.
+	// +optional
+	MaxLoadSeconds *int32 `json:"maxLoadSeconds,omitempty"`
+
+	// ScaleDownDelay is the time the topic needs to stay within the
+	// thresholds before it is merged back. Defaults to 10m.
+	// +optional
+	ScaleDownDelay *metav1.Duration `json:"scaleDownDelay,omitempty"`
 }
 
 // SinkGroup is the group of batcher and loader pods based on the
@@ -125,6 +246,13 @@ type RedshiftBatcherSpec struct {
 	// MaskFile to use to apply mask configurations
 	// +optional
 	MaskFile string `json:"maskFile,omitempty"`
+	// MaskSaltVersion is the version of the salt used for masking. The salt
+	// is read from the secret key maskSalt-<version>, the secret key maskSalt
+	// is used when it is not specified. Changing it reloads all the tables
+	// with the new salt and releases them.
+	// +kubebuilder:validation:Pattern=^[a-zA-Z0-9]+$
+	// +optional
+	MaskSaltVersion string `json:"maskSaltVersion,omitempty"`
 	// +optional
 
 	// SinkGroup contains the specification for main, reload and reloadDupe
@@ -235,6 +363,61 @@ type RedshiftSinkSpec struct {
 	// does not work well with central ReleaseCondition for all topics
 	// +optional
 	TopicReleaseCondition map[string]ReleaseCondition `json:"topicReleaseCondition,omitempty"`
+
+	// ReloadTopics forces the topics to be reloaded without a mask change,
+	// example: to backfill a table after an incident. The key is the topic
+	// and the value is the id of the reload, changing the id reloads the
+	// topic again. The topic is reloaded in a new reload table using fresh
+	// consumer groups and is released as per the ReleaseCondition when it
+	// becomes realtime. This is relevant only if masking is turned on.
+	// +optional
+	ReloadTopics map[string]string `json:"reloadTopics,omitempty"`
+
+	// PreviousTableRetention keeps the live table replaced by a release
+	// as <table>_previous_<version> for the duration, so that the release
+	// can be rolled back using the RollbackReleaseAnnotation. The replaced
+	// table is dropped right away when not specified.
+	// +optional
+	PreviousTableRetention *metav1.Duration `json:"previousTableRetention,omitempty"`
+
+	// Timezone specifies the timezone of the source database, it is used
+	// to convert the DATETIME values into UTC before loading them in Redshift.
+	// It is passed to both the batcher and the loader as both of them
+	// need to agree on the Redshift column types.
+	// +optional
+	Timezone *Timezone `json:"timezone,omitempty"`
+}
+
+// Timezone specifies the source timezone of the temporal values.
+// Precedence: Tables > Databases > Source. Defaults to UTC.
+type Timezone struct {
+	// Source is the IANA timezone of the source, example: Asia/Kolkata
+	// +optional
+	Source string `json:"source,omitempty"`
+	// Databases overrides the Source timezone per database.
+	// +optional
+	Databases map[string]string `json:"databases,omitempty"`
+	// Tables overrides the Source and Databases timezone per table.
+	// The key is in the format database.table
+	// +optional
+	Tables map[string]string `json:"tables,omitempty"`
+	// TimestampWithTimezone when turned on maps the temporal timestamp
+	// columns to Redshift TIMESTAMPTZ instead of TIMESTAMP.
+	// Changing this requires the tables to be reloaded. Defaults to false.
+	// +optional
+	TimestampWithTimezone bool `json:"timestampWithTimezone,omitempty"`
+	// TimeType is the Redshift type to store the MySQL TIME columns in.
+	// varchar: formatted as [-]HHH:MM:SS[.ffffff], supports full MySQL range
+	// time: Redshift TIME (TIMETZ if TimestampWithTimezone is set)
+	// seconds: signed seconds as numeric(13,6), supports full MySQL range
+	// Defaults to the raw Debezium value in a varchar.
+	// +kubebuilder:validation:Enum=varchar;time;seconds
+	// +optional
+	TimeType string `json:"timeType,omitempty"`
+	// TimeColumns overrides the TimeType for a column. The key is in the
+	// format database.table.column
+	// +optional
+	TimeColumns map[string]string `json:"timeColumns,omitempty"`
 }
 
 type ReleaseCondition struct {
@@ -247,8 +430,101 @@ type ReleaseCondition struct {
 	// shoud have to be be considered to be operating in realtime and
 	// to be considered for release.
 	MaxLoaderLag *int64 `json:"maxLoaderLag,omitempty"`
+
+	// MaxBatcherLagSeconds is the maximum age in seconds of the oldest
+	// message not yet processed by the batcher consumer group for it to be
+	// considered to be operating in realtime. The age is found using the
+	// message timestamps. When specified without MaxBatcherLag, the lag in
+	// messages is not checked, else both the conditions need to be met.
+	// +optional
+	MaxBatcherLagSeconds *int64 `json:"maxBatcherLagSeconds,omitempty"`
+
+	// MaxLoaderLagSeconds is the maximum age in seconds of the oldest
+	// message not yet processed by the loader consumer group for it to be
+	// considered to be operating in realtime. The age is found using the
+	// message timestamps. When specified without MaxLoaderLag, the lag in
+	// messages is not checked, else both the conditions need to be met.
+	// +optional
+	MaxLoaderLagSeconds *int64 `json:"maxLoaderLagSeconds,omitempty"`
+
+	// ReleaseWindows are the time windows in which the realtime topics
+	// are allowed to be released. Realtime topics wait for the next window
+	// to open. Topics are released anytime when not specified.
+	// +optional
+	ReleaseWindows []ReleaseWindow `json:"releaseWindows,omitempty"`
+
+	// RequireApproval when turned on keeps the realtime topics in the
+	// AwaitingApproval phase till their release is approved using the
+	// annotation ApproveReleaseAnnotation. Defaults to false.
+	// +optional
+	RequireApproval *bool `json:"requireApproval,omitempty"`
+
+	// Validation specifies the checks to run on the reloaded table before
+	// it replaces the live table. Failing checks block the release.
+	// +optional
+	Validation *ReleaseValidation `json:"validation,omitempty"`
 }
 
+// ReleaseValidation specifies the pre-release checks of the reloaded table.
+// The release of the topic is blocked till all of the checks pass.
+type ReleaseValidation struct {
+	// MaxRowCountDeviationPercent is the allowed deviation in percent of the
+	// row count of the reloaded table from the row count of the live table.
+	// Not checked when the live table does not exist.
+	// +optional
+	MaxRowCountDeviationPercent *int32 `json:"maxRowCountDeviationPercent,omitempty"`
+
+	// UniquePrimaryKeys checks that no primary key is present in more
+	// than one row of the reloaded table.
+	// +optional
+	UniquePrimaryKeys bool `json:"uniquePrimaryKeys,omitempty"`
+
+	// NotNullPrimaryKeys checks that the primary key columns of the
+	// reloaded table do not have nulls.
+	// +optional
+	NotNullPrimaryKeys bool `json:"notNullPrimaryKeys,omitempty"`
+
+	// Assertions are the user supplied SQL checks of the reloaded table.
+	// +optional
+	Assertions []ReleaseAssertion `json:"assertions,omitempty"`
+}
+
+// ReleaseAssertion is a SQL query returning a single number, the assertion
+// passes when the number is 0, example: the count of the invalid rows.
+// {table} and {liveTable} in the query are replaced with the quoted names
+// of the reloaded and the live table. It is run in a read only transaction.
+type ReleaseAssertion struct {
+	// Name of the assertion used in the failure messages
+	Name string `json:"name"`
+	// SQL query of the assertion
+	SQL string `json:"sql"`
+}
+
+// ReleaseWindow is a time window which opens at the runs of the cron
+// schedule and stays open for the duration.
+type ReleaseWindow struct {
+	// Schedule is the cron schedule of the opening of the window in the
+	// format: minute hour day-of-month month day-of-week.
+	// Example: "0 22 * * 1-5" opens the window at 22:00 on weekdays.
+	Schedule string `json:"schedule"`
+	// Duration is how long the window stays open, example: 6h, 90m
+	Duration metav1.Duration `json:"duration"`
+	// Timezone is the IANA timezone of the schedule. Defaults to UTC.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// ApproveReleaseAnnotation approves the release of the topics awaiting
+// approval. The value is the comma separated list of topics or * to approve
+// all of them. Approved topics are removed from it by the operator.
+const ApproveReleaseAnnotation = "tipoca.k8s.practo.dev/approve-release"
+
+// RollbackReleaseAnnotation rolls back the last release of the topics,
+// the value is the comma separated list of topics. The live table is
+// swapped with the table retained by the release (PreviousTableRetention).
+// Topics are removed from it by the operator once processed.
+const RollbackReleaseAnnotation = "tipoca.k8s.practo.dev/rollback-release"
+
 // MaskPhase is a label for the condition of a masking at the current time.
 type MaskPhase string
 
@@ -266,6 +542,15 @@ const (
 	// MaskRealtime tells the SinkGroup has been reloaded with new mask
 	// version and is realtime and it is waiting to be released
 	MaskRealtime MaskPhase = "Realtime"
+
+	// MaskAwaitingApproval tells the SinkGroup is realtime and its release
+	// is waiting to be approved as the ReleaseCondition requires approval
+	MaskAwaitingApproval MaskPhase = "AwaitingApproval"
+
+	// MaskRolledBack tells the last release of the topic was rolled back,
+	// the topic is sinking into the table of its ReleasedVersion. It is
+	// reloaded again only when it has a mask diff or a ReloadTopics reload.
+	MaskRolledBack MaskPhase = "RolledBack"
 )
 
 // TopicMaskStatus store the mask status of a single topic
@@ -280,6 +565,11 @@ type TopicMaskStatus struct {
 	// ReleasedVersion is the last released version for the topic
 	// +optional
 	ReleasedVersion *string `json:"releasedVersion,omitempty"`
+
+	// ReloadID is the id of the ReloadTopics reload the topic was
+	// last reloaded for or is being reloaded for.
+	// +optional
+	ReloadID string `json:"reloadID,omitempty"`
 }
 
 type MaskStatus struct {
@@ -300,6 +590,16 @@ type MaskStatus struct {
 	// completely rolled out in all the topics.
 	// +optional
 	DesiredMaskVersion *string `json:"desiredMaskedVersion,omitempty"`
+
+	// CurrentMaskSaltVersion stores the mask salt version which was
+	// completely rolled out in all the topics.
+	// +optional
+	CurrentMaskSaltVersion *string `json:"currentMaskSaltVersion,omitempty"`
+
+	// DesiredMaskSaltVersion stores the latest mask salt version which
+	// should be completely rolled out in all the topics.
+	// +optional
+	DesiredMaskSaltVersion *string `json:"desiredMaskSaltVersion,omitempty"`
 }
 
 type Group struct {
@@ -323,11 +623,96 @@ type Group struct {
 	LoaderCurrentOffset *int64 `json:"currentOffset,omitempty"`
 }
 
+// These are the condition types of the RedshiftSink.
+const (
+	// ConditionReady is true when the last reconcile was successful and
+	// the sink groups are running as desired.
+	ConditionReady = "Ready"
+
+	// ConditionReconciling is true when the sink groups are being
+	// updated or the topics are reloading or waiting to be released.
+	ConditionReconciling = "Reconciling"
+
+	// ConditionReloading is true when the topics are being reloaded with
+	// the new mask version.
+	ConditionReloading = "Reloading"
+
+	// ConditionReleasing is true when the reloaded topics have reached
+	// realtime and are being released.
+	ConditionReleasing = "Releasing"
+
+	// ConditionDegraded is true when the last reconcile failed, the reason
+	// tells the failure.
+	ConditionDegraded = "Degraded"
+)
+
+// These are the reasons of the conditions of the RedshiftSink.
+const (
+	ReasonReconciled            = "Reconciled"
+	ReasonProgressing           = "Progressing"
+	ReasonTopicsNotFound        = "TopicsNotFound"
+	ReasonTopicsReloading       = "TopicsReloading"
+	ReasonNoTopicsReloading     = "NoTopicsReloading"
+	ReasonTopicsRealtime        = "TopicsRealtime"
+	ReasonNoTopicsRealtime      = "NoTopicsRealtime"
+	ReasonAwaitingApproval      = "AwaitingApproval"
+	ReasonSecretUnavailable     = "SecretUnavailable"
+	ReasonKafkaUnreachable      = "KafkaUnreachable"
+	ReasonGitUnreachable        = "GitUnreachable"
+	ReasonMaskSourceUnreachable = "MaskSourceUnreachable"
+	ReasonMaskInvalid           = "MaskInvalid"
+	ReasonRedshiftUnreachable   = "RedshiftUnreachable"
+	ReasonSinkGroupFailed       = "SinkGroupFailed"
+	ReasonReleaseFailed         = "ReleaseFailed"
+	ReasonValidationFailed      = "ReleaseValidationFailed"
+	ReasonRollbackFailed        = "RollbackFailed"
+	ReasonReconcileFailed       = "ReconcileFailed"
+)
+
+// TopicPhaseCounts stores the number of topics in each mask phase
+type TopicPhaseCounts struct {
+	// Total is the number of topics sinked by the RedshiftSink
+	Total int `json:"total"`
+
+	// Active is the number of topics running with the released mask version
+	Active int `json:"active"`
+
+	// Reloading is the number of topics reloading with the desired mask version
+	Reloading int `json:"reloading"`
+
+	// Realtime is the number of reloaded topics waiting to be released
+	Realtime int `json:"realtime"`
+
+	// AwaitingApproval is the number of realtime topics waiting for
+	// their release to be approved
+	// +optional
+	AwaitingApproval int `json:"awaitingApproval,omitempty"`
+
+	// RolledBack is the number of topics whose last release was rolled back
+	// +optional
+	RolledBack int `json:"rolledBack,omitempty"`
+}
+
 // RedshiftSinkStatus defines the observed state of RedshiftSink
 type RedshiftSinkStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
+	// Conditions are the latest observations of the RedshiftSink's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the spec last reconciled
+	// successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Topics stores the number of topics in each mask phase
+	// +optional
+	Topics *TopicPhaseCounts `json:"topics,omitempty"`
+
 	// MaskStatus stores the status of masking for topics if masking is enabled
 	// +optional
 	MaskStatus *MaskStatus `json:"maskStatus,omitempty"`
@@ -348,16 +733,66 @@ type RedshiftSinkStatus struct {
 	// +optional
 	LoaderReloadingTopics []string `json:"loaderReloadingTopics,omitempty"`
 
+	// BatcherDedicatedTopics stores the topics of the main sink group which
+	// have been moved to their own batcher deployment units by the
+	// autoscaling, along with the last time they breached the thresholds.
+	// +optional
+	BatcherDedicatedTopics map[string]metav1.Time `json:"batcherDedicatedTopics,omitempty"`
+
+	// LoaderDedicatedTopics stores the topics of the main sink group which
+	// have been moved to their own loader deployment units by the
+	// autoscaling, along with the last time they breached the thresholds.
+	// +optional
+	LoaderDedicatedTopics map[string]metav1.Time `json:"loaderDedicatedTopics,omitempty"`
+
 	// LoaderTopicGroupCurrentOffset stores the topic + group ID = currentOffset
 	// this is used for realtime calculation, after release it is not useful and is cleaned up
 	LoaderTopicGroupCurrentOffset map[string]int64 `json:"loaderTopicGroupCurrentOffset,omitempty"`
 
 	// DeadConsumerGroups stores the list of consumer groups that should be cleaned after release is done
 	DeadConsumerGroups []string `json:"deadConsumerGroups,omitempty"`
+
+	// ReleaseValidationFailures has the failed pre-release validation
+	// checks of the realtime topics, topic = failed checks. They are not
+	// released till the validations pass.
+	// +optional
+	ReleaseValidationFailures map[string]string `json:"releaseValidationFailures,omitempty"`
+
+	// PreviousReleases stores the tables retained by the releases of the
+	// topics, topic = previous release. They are used to rollback the
+	// releases and are dropped when the retention expires.
+	// +optional
+	PreviousReleases map[string]PreviousRelease `json:"previousReleases,omitempty"`
+}
+
+// PreviousRelease is the live table replaced by the last release of a
+// topic, it is retained till the retention expires to allow a rollback.
+type PreviousRelease struct {
+	// Table is the name of the retained table
+	Table string `json:"table"`
+
+	// ReleasedVersion is the mask version of the retained table
+	// +optional
+	ReleasedVersion *string `json:"releasedVersion,omitempty"`
+
+	// Group is the consumer group which was sinking into the retained table,
+	// it is kept to resume the sink on rollback.
+	Group Group `json:"group"`
+
+	// ExpiresAt is the time after which the retained table is dropped
+	ExpiresAt metav1.Time `json:"expiresAt"`
 }
 
 // +kubebuilder:resource:path=redshiftsinks,shortName=rsk;rsks
 // +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Topics",type="integer",JSONPath=".status.topics.total"
+// +kubebuilder:printcolumn:name="Active",type="integer",JSONPath=".status.topics.active"
+// +kubebuilder:printcolumn:name="Reloading",type="integer",JSONPath=".status.topics.reloading"
+// +kubebuilder:printcolumn:name="Realtime",type="integer",JSONPath=".status.topics.realtime"
+// +kubebuilder:printcolumn:name="Mask Version",type="string",JSONPath=".status.maskStatus.currentMaskVersion",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // +kubebuilder:subresource:status
 // RedshiftSink is the Schema for the redshiftsinks API
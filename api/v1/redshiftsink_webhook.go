@@ -0,0 +1,481 @@
+package v1
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/practo/tipoca-stream/pkg/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the defaulting and the validating
+// webhooks of the RedshiftSink with the webhook server of the manager.
+func (r *RedshiftSink) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-tipoca-k8s-practo-dev-v1-redshiftsink,mutating=true,failurePolicy=fail,groups=tipoca.k8s.practo.dev,resources=redshiftsinks,verbs=create;update,versions=v1,name=mredshiftsink.kb.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+var _ webhook.Defaulter = &RedshiftSink{}
+
+// Default applies the sink group defaults into the specs given in the
+// object so that the stored object shows the configuration being used.
+// The sink group types not specified are not filled from all, they keep
+// following all and are defaulted when read. all is defaulted only for the
+// fields not depending on the sink group type. The defaults are applied
+// only when the sinkGroup is specified, the deprecated configuration is
+// left as it is. The image is not defaulted, the default image of the
+// operator is used for the sink groups not specifying one.
+func (r *RedshiftSink) Default() {
+	batcher := r.Spec.Batcher.SinkGroup
+	if batcher != nil {
+		if batcher.Main != nil {
+			batcher.Main = r.BatcherSinkGroupSpec(MainSinkGroup, nil)
+		}
+		if batcher.Reload != nil {
+			batcher.Reload = r.BatcherSinkGroupSpec(ReloadSinkGroup, nil)
+		}
+		if batcher.ReloadDupe != nil {
+			batcher.ReloadDupe = r.BatcherSinkGroupSpec(ReloadDupeSinkGroup, nil)
+		}
+		defaultAllSpec(batcher.All, DefaultBatcherMaxProcessingTime)
+	}
+	loader := r.Spec.Loader.SinkGroup
+	if loader != nil {
+		if loader.Main != nil {
+			loader.Main = r.LoaderSinkGroupSpec(MainSinkGroup, nil)
+		}
+		if loader.Reload != nil {
+			loader.Reload = r.LoaderSinkGroupSpec(ReloadSinkGroup, nil)
+		}
+		if loader.ReloadDupe != nil {
+			loader.ReloadDupe = r.LoaderSinkGroupSpec(ReloadDupeSinkGroup, nil)
+		}
+		defaultAllSpec(loader.All, DefaultLoaderMaxProcessingTime)
+	}
+}
+
+// defaultAllSpec defaults the fields of the all spec which have the same
+// default for every sink group type
+func defaultAllSpec(all *SinkGroupSpec, maxProcessingTime int32) {
+	if all == nil {
+		return
+	}
+	if all.MaxProcessingTime == nil {
+		all.MaxProcessingTime = int32Ptr(maxProcessingTime)
+	}
+	if all.MaxReloadingUnits == nil {
+		all.MaxReloadingUnits = int32Ptr(DefaultMaxReloadingUnits)
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-tipoca-k8s-practo-dev-v1-redshiftsink,mutating=false,failurePolicy=fail,groups=tipoca.k8s.practo.dev,resources=redshiftsinks,versions=v1,name=vredshiftsink.kb.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+var _ webhook.Validator = &RedshiftSink{}
+
+// ValidateCreate validates the RedshiftSink on create
+func (r *RedshiftSink) ValidateCreate() error {
+	return r.validate(nil)
+}
+
+// ValidateUpdate validates the RedshiftSink on update
+func (r *RedshiftSink) ValidateUpdate(old runtime.Object) error {
+	oldRsk, _ := old.(*RedshiftSink)
+	return r.validate(oldRsk)
+}
+
+// ValidateDelete allows all deletes
+func (r *RedshiftSink) ValidateDelete() error {
+	return nil
+}
+
+// validate validates the spec which would otherwise fail the reconcile.
+// Quantities which can not be parsed are rejected while decoding the object.
+// The changes from the old object are validated on update.
+func (r *RedshiftSink) validate(old *RedshiftSink) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	allErrs = append(allErrs, validateTopicRegexes(
+		r.Spec.KafkaTopicRegexes, specPath.Child("kafkaTopicRegexes"))...)
+
+	if strings.Count(r.Spec.KafkaLoaderTopicPrefix, "-") > 1 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("kafkaLoaderTopicPrefix"),
+			r.Spec.KafkaLoaderTopicPrefix,
+			"can contain at max 1 hyphen"))
+	}
+
+	if len(r.Spec.ReloadTopics) > 0 && !r.Spec.Batcher.Mask {
+		allErrs = append(allErrs, field.Forbidden(
+			specPath.Child("reloadTopics"),
+			"supported only when batcher.mask is turned on"))
+	}
+
+	if strings.TrimSpace(r.Spec.Loader.RedshiftSchema) == "" {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("loader", "redshiftSchema"), ""))
+	}
+
+	if old != nil {
+		allErrs = append(allErrs, validateTimeTypeChange(
+			old.Spec.Timezone, r.Spec.Timezone, specPath.Child("timezone"))...)
+	}
+
+	if r.Spec.PreviousTableRetention != nil &&
+		r.Spec.PreviousTableRetention.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("previousTableRetention"),
+			r.Spec.PreviousTableRetention.Duration.String(),
+			"must be greater than 0"))
+	}
+
+	if r.Spec.ReleaseCondition != nil {
+		allErrs = append(allErrs, validateReleaseCondition(
+			*r.Spec.ReleaseCondition, specPath.Child("releaseCondition"))...)
+	}
+	topics := []string{}
+	for topic := range r.Spec.TopicReleaseCondition {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		allErrs = append(allErrs, validateReleaseCondition(
+			r.Spec.TopicReleaseCondition[topic],
+			specPath.Child("topicReleaseCondition").Key(topic))...)
+	}
+
+	batcherPath := specPath.Child("batcher")
+	if r.Spec.Batcher.SinkGroup != nil {
+		batcher := r.Spec.Batcher
+		allErrs = append(allErrs, validateDeprecated(batcherPath, map[string]bool{
+			"maxSize":           batcher.MaxSize != 0,
+			"maxWaitSeconds":    batcher.MaxWaitSeconds != 0,
+			"maxConcurrency":    batcher.MaxConcurrency != nil,
+			"maxProcessingTime": batcher.MaxProcessingTime != nil,
+			"podTemplate":       batcher.PodTemplate != nil,
+		})...)
+	}
+	allErrs = append(allErrs, validateSinkGroup(
+		r.Spec.Batcher.SinkGroup, batcherPath.Child("sinkGroup"), false)...)
+
+	loaderPath := specPath.Child("loader")
+	if r.Spec.Loader.SinkGroup != nil {
+		loader := r.Spec.Loader
+		allErrs = append(allErrs, validateDeprecated(loaderPath, map[string]bool{
+			"maxSize":           loader.MaxSize != 0,
+			"maxWaitSeconds":    loader.MaxWaitSeconds != 0,
+			"maxProcessingTime": loader.MaxProcessingTime != nil,
+			"podTemplate":       loader.PodTemplate != nil,
+		})...)
+	}
+	allErrs = append(allErrs, validateSinkGroup(
+		r.Spec.Loader.SinkGroup, loaderPath.Child("sinkGroup"), true)...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: GroupVersion.Group, Kind: "RedshiftSink"},
+		r.Name,
+		allErrs,
+	)
+}
+
+// validateTopicRegexes validates the comma separated regexes compile
+func validateTopicRegexes(regexes string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if strings.TrimSpace(regexes) == "" {
+		return append(allErrs, field.Required(path, ""))
+	}
+
+	for _, expression := range strings.Split(regexes, ",") {
+		_, err := regexp.Compile(strings.TrimSpace(expression))
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				path, expression, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+// legacyTimeTypeSwitch tells if the time type changes between the legacy
+// raw value and varchar. Both use the same varchar column, so the table
+// migration can not tell the values apart and the existing rows would
+// be mixed with the new ones in a different format.
+func legacyTimeTypeSwitch(from, to string) bool {
+	return (from == "" && to == "varchar") || (from == "varchar" && to == "")
+}
+
+// validateTimeTypeChange rejects the time type changes between the legacy
+// raw value and varchar, for the TimeType and for the columns.
+func validateTimeTypeChange(old, new *Timezone, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if old == nil {
+		old = &Timezone{}
+	}
+	if new == nil {
+		new = &Timezone{}
+	}
+	message := "can not be changed between the raw value and varchar, " +
+		"use a new column or reload the table"
+
+	if legacyTimeTypeSwitch(old.TimeType, new.TimeType) {
+		allErrs = append(allErrs, field.Forbidden(
+			path.Child("timeType"), message))
+	}
+
+	columns := []string{}
+	for column := range old.TimeColumns {
+		columns = append(columns, column)
+	}
+	for column := range new.TimeColumns {
+		if _, ok := old.TimeColumns[column]; !ok {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		from, ok := old.TimeColumns[column]
+		if !ok {
+			from = old.TimeType
+		}
+		to, ok := new.TimeColumns[column]
+		if !ok {
+			to = new.TimeType
+		}
+		if legacyTimeTypeSwitch(from, to) {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("timeColumns").Key(column), message))
+		}
+	}
+
+	return allErrs
+}
+
+// validateDeprecated validates the deprecated fields are not used with
+// the sinkGroup, the deprecated fields are ignored when it is specified.
+func validateDeprecated(path *field.Path, set map[string]bool) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, name := range []string{
+		"maxSize",
+		"maxWaitSeconds",
+		"maxConcurrency",
+		"maxProcessingTime",
+		"podTemplate",
+	} {
+		if set[name] {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child(name),
+				"deprecated, can not be used with sinkGroup"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateSinkGroup validates the specs of all the sink group types
+func validateSinkGroup(
+	sinkGroup *SinkGroup,
+	path *field.Path,
+	loader bool,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if sinkGroup == nil {
+		return allErrs
+	}
+
+	specs := []struct {
+		name string
+		spec *SinkGroupSpec
+	}{
+		{"all", sinkGroup.All},
+		{"main", sinkGroup.Main},
+		{"reload", sinkGroup.Reload},
+		{"reloadDupe", sinkGroup.ReloadDupe},
+	}
+	for _, s := range specs {
+		if s.spec == nil {
+			continue
+		}
+		specPath := path.Child(s.name)
+		if s.spec.MaxSizePerBatch != nil && s.spec.MaxSizePerBatch.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("maxSizePerBatch"),
+				s.spec.MaxSizePerBatch.String(),
+				"should be greater than 0"))
+		}
+		if s.spec.MaxWaitSeconds != nil && *s.spec.MaxWaitSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("maxWaitSeconds"),
+				*s.spec.MaxWaitSeconds,
+				"should be greater than 0"))
+		}
+		if s.spec.MaxConcurrency != nil && *s.spec.MaxConcurrency <= 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("maxConcurrency"),
+				*s.spec.MaxConcurrency,
+				"should be greater than 0"))
+		}
+		if s.spec.MaxProcessingTime != nil && *s.spec.MaxProcessingTime <= 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("maxProcessingTime"),
+				*s.spec.MaxProcessingTime,
+				"should be greater than 0"))
+		}
+		if s.spec.MaxReloadingUnits != nil && *s.spec.MaxReloadingUnits <= 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("maxReloadingUnits"),
+				*s.spec.MaxReloadingUnits,
+				"should be greater than 0"))
+		}
+		if s.spec.Autoscale != nil {
+			autoscalePath := specPath.Child("autoscale")
+			if s.name == "reload" || s.name == "reloadDupe" {
+				allErrs = append(allErrs, field.Forbidden(
+					autoscalePath, "only valid for the main sinkGroup"))
+				continue
+			}
+			allErrs = append(allErrs, validateAutoscale(
+				*s.spec.Autoscale, autoscalePath, loader)...)
+		}
+	}
+
+	return allErrs
+}
+
+// validateAutoscale validates the autoscaling of the main sink group
+func validateAutoscale(
+	autoscale SinkGroupAutoscale,
+	path *field.Path,
+	loader bool,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	minUnits := DefaultAutoscaleMinUnits
+	if autoscale.MinUnits != nil {
+		minUnits = *autoscale.MinUnits
+		if minUnits <= 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("minUnits"), minUnits, "should be greater than 0"))
+		}
+	}
+	if autoscale.MaxUnits < minUnits {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("maxUnits"),
+			autoscale.MaxUnits,
+			"should be greater than or equal to minUnits"))
+	}
+	if autoscale.MaxLag != nil && *autoscale.MaxLag < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("maxLag"), *autoscale.MaxLag, "should not be negative"))
+	}
+	if autoscale.MaxLoadSeconds != nil {
+		if !loader {
+			allErrs = append(allErrs, field.Forbidden(
+				path.Child("maxLoadSeconds"), "only valid for the loader"))
+		} else if *autoscale.MaxLoadSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child("maxLoadSeconds"),
+				*autoscale.MaxLoadSeconds,
+				"should be greater than 0"))
+		}
+	}
+	if autoscale.ScaleDownDelay != nil && autoscale.ScaleDownDelay.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("scaleDownDelay"),
+			autoscale.ScaleDownDelay.Duration.String(),
+			"should not be negative"))
+	}
+
+	return allErrs
+}
+
+func validateReleaseCondition(condition ReleaseCondition, path *field.Path) field.ErrorList {
+	allErrs := validateReleaseWindows(
+		condition.ReleaseWindows, path.Child("releaseWindows"))
+	lagSeconds := []struct {
+		name    string
+		seconds *int64
+	}{
+		{"maxBatcherLagSeconds", condition.MaxBatcherLagSeconds},
+		{"maxLoaderLagSeconds", condition.MaxLoaderLagSeconds},
+	}
+	for _, l := range lagSeconds {
+		if l.seconds != nil && *l.seconds < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				path.Child(l.name), *l.seconds, "should not be negative"))
+		}
+	}
+	if condition.Validation == nil {
+		return allErrs
+	}
+
+	validationPath := path.Child("validation")
+	deviation := condition.Validation.MaxRowCountDeviationPercent
+	if deviation != nil && *deviation < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			validationPath.Child("maxRowCountDeviationPercent"),
+			*deviation,
+			"should not be negative"))
+	}
+	names := make(map[string]bool)
+	for i, assertion := range condition.Validation.Assertions {
+		assertionPath := validationPath.Child("assertions").Index(i)
+		if strings.TrimSpace(assertion.Name) == "" {
+			allErrs = append(allErrs, field.Required(
+				assertionPath.Child("name"), ""))
+		} else if names[assertion.Name] {
+			allErrs = append(allErrs, field.Duplicate(
+				assertionPath.Child("name"), assertion.Name))
+		}
+		names[assertion.Name] = true
+		if strings.TrimSpace(assertion.SQL) == "" {
+			allErrs = append(allErrs, field.Required(
+				assertionPath.Child("sql"), ""))
+		}
+	}
+
+	return allErrs
+}
+
+// maxReleaseWindowDuration is one week, the longest cron period for a window
+const maxReleaseWindowDuration = 7 * 24 * time.Hour
+
+func validateReleaseWindows(windows []ReleaseWindow, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, window := range windows {
+		windowPath := path.Index(i)
+		_, err := util.ParseCronSchedule(window.Schedule)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				windowPath.Child("schedule"), window.Schedule, err.Error()))
+		}
+		if window.Duration.Duration <= 0 ||
+			window.Duration.Duration > maxReleaseWindowDuration {
+			allErrs = append(allErrs, field.Invalid(
+				windowPath.Child("duration"),
+				window.Duration.Duration.String(),
+				"should be greater than 0 and at max 168h"))
+		}
+		if window.Timezone != "" {
+			_, err := time.LoadLocation(window.Timezone)
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(
+					windowPath.Child("timezone"), window.Timezone, err.Error()))
+			}
+		}
+	}
+
+	return allErrs
+}
@@ -0,0 +1,518 @@
+package v1
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validRedshiftSink() *RedshiftSink {
+	return &RedshiftSink{
+		Spec: RedshiftSinkSpec{
+			KafkaTopicRegexes:      "^db.inventory*, ^db.orders.*",
+			KafkaLoaderTopicPrefix: "loader-",
+			Batcher: RedshiftBatcherSpec{
+				SinkGroup: &SinkGroup{
+					All: &SinkGroupSpec{
+						MaxSizePerBatch: quantityPtr("10Mi"),
+					},
+				},
+			},
+			Loader: RedshiftLoaderSpec{
+				RedshiftSchema: "inventory",
+				SinkGroup: &SinkGroup{
+					Main: &SinkGroupSpec{
+						MaxWaitSeconds: intPtr(1800),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRedshiftSinkValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mutate func(rsk *RedshiftSink)
+		fields []string
+	}{
+		{
+			name:   "test1: valid",
+			mutate: func(rsk *RedshiftSink) {},
+		},
+		{
+			name: "test2: valid deprecated spec",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Batcher.SinkGroup = nil
+				rsk.Spec.Batcher.MaxSize = 10
+				rsk.Spec.Batcher.MaxWaitSeconds = 30
+			},
+		},
+		{
+			name: "test3: bad topic regex",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.KafkaTopicRegexes = "^db.inventory*,^db.(orders"
+			},
+			fields: []string{"spec.kafkaTopicRegexes"},
+		},
+		{
+			name: "test4: missing topic regexes",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.KafkaTopicRegexes = ""
+			},
+			fields: []string{"spec.kafkaTopicRegexes"},
+		},
+		{
+			name: "test5: loader prefix with more than one hyphen",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.KafkaLoaderTopicPrefix = "loader-ts-"
+			},
+			fields: []string{"spec.kafkaLoaderTopicPrefix"},
+		},
+		{
+			name: "test6: missing redshift schema",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Loader.RedshiftSchema = ""
+			},
+			fields: []string{"spec.loader.redshiftSchema"},
+		},
+		{
+			name: "test7: deprecated spec with sink group",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Batcher.MaxSize = 10
+				rsk.Spec.Loader.MaxWaitSeconds = 30
+			},
+			fields: []string{
+				"spec.batcher.maxSize",
+				"spec.loader.maxWaitSeconds",
+			},
+		},
+		{
+			name: "test8: zero quantity",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Batcher.SinkGroup.All.MaxSizePerBatch = quantityPtr("0")
+			},
+			fields: []string{"spec.batcher.sinkGroup.all.maxSizePerBatch"},
+		},
+		{
+			name: "test9: reload topics without mask",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.ReloadTopics = map[string]string{
+					"db.inventory.orders": "incident-1",
+				}
+			},
+			fields: []string{"spec.reloadTopics"},
+		},
+		{
+			name: "test10: negative wait",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Loader.SinkGroup.Main.MaxWaitSeconds = intPtr(-1)
+			},
+			fields: []string{"spec.loader.sinkGroup.main.maxWaitSeconds"},
+		},
+		{
+			name: "test11: valid release windows",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.ReleaseCondition = &ReleaseCondition{
+					ReleaseWindows: []ReleaseWindow{{
+						Schedule: "0 22 * * 1-5",
+						Duration: metav1.Duration{Duration: 6 * time.Hour},
+						Timezone: "Asia/Kolkata",
+					}},
+				}
+			},
+		},
+		{
+			name: "test12: invalid release windows",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.ReleaseCondition = &ReleaseCondition{
+					ReleaseWindows: []ReleaseWindow{{
+						Schedule: "0 22 * *",
+						Duration: metav1.Duration{Duration: 6 * time.Hour},
+					}},
+				}
+				rsk.Spec.TopicReleaseCondition = map[string]ReleaseCondition{
+					"db.inventory.orders": {
+						ReleaseWindows: []ReleaseWindow{{
+							Schedule: "0 0 * * 6",
+							Timezone: "Mars/Olympus",
+						}},
+					},
+				}
+			},
+			fields: []string{
+				"spec.releaseCondition.releaseWindows[0].schedule",
+				"spec.topicReleaseCondition[db.inventory.orders].releaseWindows[0].duration",
+				"spec.topicReleaseCondition[db.inventory.orders].releaseWindows[0].timezone",
+			},
+		},
+		{
+			name: "test13: invalid release validation",
+			mutate: func(rsk *RedshiftSink) {
+				deviation := int32(-1)
+				rsk.Spec.ReleaseCondition = &ReleaseCondition{
+					Validation: &ReleaseValidation{
+						MaxRowCountDeviationPercent: &deviation,
+						Assertions: []ReleaseAssertion{
+							{Name: "no future orders", SQL: "SELECT 0"},
+							{Name: "no future orders"},
+						},
+					},
+				}
+			},
+			fields: []string{
+				"spec.releaseCondition.validation.maxRowCountDeviationPercent",
+				"spec.releaseCondition.validation.assertions[1].name",
+				"spec.releaseCondition.validation.assertions[1].sql",
+			},
+		},
+		{
+			name: "test14: valid previous table retention",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.PreviousTableRetention = &metav1.Duration{
+					Duration: 24 * time.Hour,
+				}
+			},
+		},
+		{
+			name: "test15: invalid previous table retention",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.PreviousTableRetention = &metav1.Duration{}
+			},
+			fields: []string{"spec.previousTableRetention"},
+		},
+		{
+			name: "test16: valid autoscale",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Batcher.SinkGroup.All.Autoscale = &SinkGroupAutoscale{
+					MaxUnits: 5,
+					MaxLag:   int64Ptr(1000),
+				}
+				rsk.Spec.Loader.SinkGroup.Main.Autoscale = &SinkGroupAutoscale{
+					MinUnits:       int32Ptr(2),
+					MaxUnits:       5,
+					MaxLoadSeconds: int32Ptr(300),
+				}
+			},
+		},
+		{
+			name: "test17: autoscale in reload sink group",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Batcher.SinkGroup.Reload = &SinkGroupSpec{
+					Autoscale: &SinkGroupAutoscale{MaxUnits: 5},
+				}
+			},
+			fields: []string{"spec.batcher.sinkGroup.reload.autoscale"},
+		},
+		{
+			name: "test18: invalid autoscale units",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Loader.SinkGroup.Main.Autoscale = &SinkGroupAutoscale{
+					MinUnits: int32Ptr(3),
+					MaxUnits: 2,
+					MaxLag:   int64Ptr(-1),
+					ScaleDownDelay: &metav1.Duration{
+						Duration: -time.Minute,
+					},
+				}
+			},
+			fields: []string{
+				"spec.loader.sinkGroup.main.autoscale.maxUnits",
+				"spec.loader.sinkGroup.main.autoscale.maxLag",
+				"spec.loader.sinkGroup.main.autoscale.scaleDownDelay",
+			},
+		},
+		{
+			name: "test19: batcher autoscale on load seconds",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.Batcher.SinkGroup.All.Autoscale = &SinkGroupAutoscale{
+					MaxUnits:       5,
+					MaxLoadSeconds: int32Ptr(300),
+				}
+			},
+			fields: []string{
+				"spec.batcher.sinkGroup.all.autoscale.maxLoadSeconds",
+			},
+		},
+		{
+			name: "test20: valid lag seconds",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.ReleaseCondition = &ReleaseCondition{
+					MaxBatcherLagSeconds: int64Ptr(60),
+					MaxLoaderLagSeconds:  int64Ptr(0),
+				}
+			},
+		},
+		{
+			name: "test21: negative lag seconds",
+			mutate: func(rsk *RedshiftSink) {
+				rsk.Spec.TopicReleaseCondition = map[string]ReleaseCondition{
+					"db.inventory.orders": {
+						MaxLoaderLagSeconds: int64Ptr(-1),
+					},
+				}
+			},
+			fields: []string{
+				"spec.topicReleaseCondition[db.inventory.orders].maxLoaderLagSeconds",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rsk := validRedshiftSink()
+			tc.mutate(rsk)
+
+			err := rsk.ValidateCreate()
+			if len(tc.fields) == 0 {
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error for fields: %v", tc.fields)
+			}
+			for _, field := range tc.fields {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("expected error for: %s, got: %v", field, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRedshiftSinkValidateUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		old    *Timezone
+		new    *Timezone
+		fields []string
+	}{
+		{
+			name: "test1: raw to time",
+			new:  &Timezone{TimeType: "time"},
+		},
+		{
+			name:   "test2: raw to varchar",
+			new:    &Timezone{TimeType: "varchar"},
+			fields: []string{"spec.timezone.timeType"},
+		},
+		{
+			name:   "test3: varchar to raw",
+			old:    &Timezone{TimeType: "varchar"},
+			new:    &Timezone{},
+			fields: []string{"spec.timezone.timeType"},
+		},
+		{
+			name: "test4: column inheriting raw to varchar",
+			old:  &Timezone{TimeType: "time"},
+			new: &Timezone{
+				TimeType: "time",
+				TimeColumns: map[string]string{
+					"inventory.shifts.overtime": "varchar",
+				},
+			},
+		},
+		{
+			name: "test5: column raw to varchar",
+			old: &Timezone{
+				TimeColumns: map[string]string{
+					"inventory.shifts.overtime": "seconds",
+				},
+			},
+			new: &Timezone{
+				TimeColumns: map[string]string{
+					"inventory.shifts.start": "varchar",
+				},
+			},
+			fields: []string{
+				"spec.timezone.timeColumns[inventory.shifts.start]",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			old := validRedshiftSink()
+			old.Spec.Timezone = tc.old
+			rsk := validRedshiftSink()
+			rsk.Spec.Timezone = tc.new
+
+			err := rsk.ValidateUpdate(old)
+			if len(tc.fields) == 0 {
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error for fields: %v", tc.fields)
+			}
+			for _, field := range tc.fields {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("expected error for: %s, got: %v", field, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnparsableQuantity(t *testing.T) {
+	t.Parallel()
+
+	rsk := &RedshiftSink{}
+	err := json.Unmarshal([]byte(`{"spec": {"batcher": {"sinkGroup": {
+		"all": {"maxSizePerBatch": "10MB"}}}}}`), rsk)
+	if err == nil {
+		t.Errorf("expected error decoding the quantity")
+	}
+}
+
+func TestRedshiftSinkDefault(t *testing.T) {
+	t.Parallel()
+
+	rsk := validRedshiftSink()
+	rsk.Spec.Batcher.SinkGroup.Reload = &SinkGroupSpec{
+		MaxConcurrency: intPtr(5),
+		DeploymentUnit: &DeploymentUnit{
+			PodTemplate: &RedshiftPodTemplateSpec{Image: stringPtr("batcher:v1")},
+		},
+	}
+	rsk.Default()
+
+	batcher := rsk.Spec.Batcher.SinkGroup
+	if batcher.Main != nil || batcher.ReloadDupe != nil {
+		t.Errorf("expected batcher main and reloadDupe to not be filled from all")
+	}
+	expectedAll := &SinkGroupSpec{
+		MaxSizePerBatch:   quantityPtr("10Mi"),
+		MaxProcessingTime: int32Ptr(DefaultBatcherMaxProcessingTime),
+		MaxReloadingUnits: int32Ptr(DefaultMaxReloadingUnits),
+	}
+	if !reflect.DeepEqual(batcher.All, expectedAll) {
+		t.Errorf("batcher all, expected: %+v, got: %+v", expectedAll, batcher.All)
+	}
+	expected := &SinkGroupSpec{
+		MaxSizePerBatch:   quantityPtr("10Mi"),
+		MaxWaitSeconds:    intPtr(450),
+		MaxConcurrency:    intPtr(2),
+		MaxProcessingTime: int32Ptr(DefaultBatcherMaxProcessingTime),
+		MaxReloadingUnits: int32Ptr(DefaultMaxReloadingUnits),
+		DeploymentUnit: &DeploymentUnit{
+			PodTemplate: &RedshiftPodTemplateSpec{},
+		},
+	}
+	mainSpec := rsk.BatcherSinkGroupSpec(MainSinkGroup, nil)
+	if !reflect.DeepEqual(mainSpec, expected) {
+		t.Errorf("batcher main, expected: %+v, got: %+v", expected, mainSpec)
+	}
+	if *batcher.Reload.MaxConcurrency != 5 ||
+		batcher.Reload.MaxSizePerBatch.Cmp(resource.MustParse("0.5Mi")) != 0 ||
+		*batcher.Reload.DeploymentUnit.PodTemplate.Image != "batcher:v1" {
+		t.Errorf("batcher reload not defaulted from reload: %+v", batcher.Reload)
+	}
+	reloadDupe := rsk.BatcherSinkGroupSpec(ReloadDupeSinkGroup, nil)
+	if *reloadDupe.MaxWaitSeconds != 450 ||
+		reloadDupe.MaxSizePerBatch.Cmp(resource.MustParse("10Mi")) != 0 {
+		t.Errorf("batcher reloadDupe not defaulted from all: %+v", reloadDupe)
+	}
+
+	loader := rsk.Spec.Loader.SinkGroup
+	if *loader.Main.MaxWaitSeconds != 1800 ||
+		*loader.Main.MaxProcessingTime != DefaultLoaderMaxProcessingTime ||
+		loader.Main.MaxConcurrency != nil {
+		t.Errorf("loader main not defaulted: %+v", loader.Main)
+	}
+	if loader.All != nil || loader.Reload != nil {
+		t.Errorf("expected loader all and reload to not be defaulted")
+	}
+	loaderReload := rsk.LoaderSinkGroupSpec(ReloadSinkGroup, nil)
+	if *loaderReload.MaxWaitSeconds != 60 ||
+		loaderReload.MaxSizePerBatch.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("loader reload not defaulted: %+v", loaderReload)
+	}
+
+	// defaulting again does not change the object
+	defaulted := rsk.DeepCopy()
+	rsk.Default()
+	if !reflect.DeepEqual(rsk, defaulted) {
+		t.Errorf("defaulting is not idempotent")
+	}
+
+	// autoscale is defaulted only for the main sink group
+	autoscaled := validRedshiftSink()
+	autoscaled.Spec.Batcher.SinkGroup.All.Autoscale = &SinkGroupAutoscale{
+		MaxUnits: 5,
+	}
+	autoscaled.Default()
+	expectedAutoscale := &SinkGroupAutoscale{
+		MinUnits: int32Ptr(DefaultAutoscaleMinUnits),
+		MaxUnits: 5,
+		ScaleDownDelay: &metav1.Duration{
+			Duration: DefaultAutoscaleScaleDownDelay,
+		},
+	}
+	autoscaledMain := autoscaled.BatcherSinkGroupSpec(MainSinkGroup, nil)
+	if !reflect.DeepEqual(autoscaledMain.Autoscale, expectedAutoscale) {
+		t.Errorf("batcher main autoscale, expected: %+v, got: %+v",
+			expectedAutoscale, autoscaledMain.Autoscale)
+	}
+	if autoscaled.BatcherSinkGroupSpec(ReloadSinkGroup, nil).Autoscale != nil ||
+		autoscaled.BatcherSinkGroupSpec(ReloadDupeSinkGroup, nil).Autoscale != nil {
+		t.Errorf("expected autoscale only for the batcher main sink group")
+	}
+	if autoscaled.Spec.Loader.SinkGroup.Main.Autoscale != nil {
+		t.Errorf("expected loader main to not be autoscaled")
+	}
+
+	// deprecated spec is not defaulted
+	deprecated := validRedshiftSink()
+	deprecated.Spec.Batcher.SinkGroup = nil
+	deprecated.Default()
+	if deprecated.Spec.Batcher.SinkGroup != nil {
+		t.Errorf("expected deprecated batcher spec to not be defaulted")
+	}
+}
+
+func TestRedshiftSinkDefaultAllUpdate(t *testing.T) {
+	t.Parallel()
+
+	rsk := validRedshiftSink()
+	rsk.Default()
+
+	// all is updated on the already defaulted object
+	rsk.Spec.Batcher.SinkGroup.All.MaxSizePerBatch = quantityPtr("20Mi")
+	rsk.Spec.Batcher.SinkGroup.All.MaxWaitSeconds = intPtr(100)
+	rsk.Default()
+
+	for _, sgType := range []string{
+		MainSinkGroup,
+		ReloadSinkGroup,
+		ReloadDupeSinkGroup,
+	} {
+		spec := rsk.BatcherSinkGroupSpec(sgType, nil)
+		if spec.MaxSizePerBatch.Cmp(resource.MustParse("20Mi")) != 0 ||
+			*spec.MaxWaitSeconds != 100 {
+			t.Errorf("batcher %s does not follow the updated all: %+v",
+				sgType, spec)
+		}
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
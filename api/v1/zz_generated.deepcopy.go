@@ -23,6 +23,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -93,6 +94,16 @@ func (in *MaskStatus) DeepCopyInto(out *MaskStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.CurrentMaskSaltVersion != nil {
+		in, out := &in.CurrentMaskSaltVersion, &out.CurrentMaskSaltVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.DesiredMaskSaltVersion != nil {
+		in, out := &in.DesiredMaskSaltVersion, &out.DesiredMaskSaltVersion
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaskStatus.
@@ -105,6 +116,28 @@ func (in *MaskStatus) DeepCopy() *MaskStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviousRelease) DeepCopyInto(out *PreviousRelease) {
+	*out = *in
+	if in.ReleasedVersion != nil {
+		in, out := &in.ReleasedVersion, &out.ReleasedVersion
+		*out = new(string)
+		**out = **in
+	}
+	in.Group.DeepCopyInto(&out.Group)
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviousRelease.
+func (in *PreviousRelease) DeepCopy() *PreviousRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviousRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedshiftBatcherSpec) DeepCopyInto(out *RedshiftBatcherSpec) {
 	*out = *in
@@ -209,6 +242,78 @@ func (in *RedshiftPodTemplateSpec) DeepCopyInto(out *RedshiftPodTemplateSpec) {
 			}
 		}
 	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]corev1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftPodTemplateSpec.
@@ -307,6 +412,23 @@ func (in *RedshiftSinkSpec) DeepCopyInto(out *RedshiftSinkSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.ReloadTopics != nil {
+		in, out := &in.ReloadTopics, &out.ReloadTopics
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PreviousTableRetention != nil {
+		in, out := &in.PreviousTableRetention, &out.PreviousTableRetention
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Timezone != nil {
+		in, out := &in.Timezone, &out.Timezone
+		*out = new(Timezone)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftSinkSpec.
@@ -322,6 +444,18 @@ func (in *RedshiftSinkSpec) DeepCopy() *RedshiftSinkSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedshiftSinkStatus) DeepCopyInto(out *RedshiftSinkStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = new(TopicPhaseCounts)
+		**out = **in
+	}
 	if in.MaskStatus != nil {
 		in, out := &in.MaskStatus, &out.MaskStatus
 		*out = new(MaskStatus)
@@ -344,6 +478,20 @@ func (in *RedshiftSinkStatus) DeepCopyInto(out *RedshiftSinkStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.BatcherDedicatedTopics != nil {
+		in, out := &in.BatcherDedicatedTopics, &out.BatcherDedicatedTopics
+		*out = make(map[string]metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.LoaderDedicatedTopics != nil {
+		in, out := &in.LoaderDedicatedTopics, &out.LoaderDedicatedTopics
+		*out = make(map[string]metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.LoaderTopicGroupCurrentOffset != nil {
 		in, out := &in.LoaderTopicGroupCurrentOffset, &out.LoaderTopicGroupCurrentOffset
 		*out = make(map[string]int64, len(*in))
@@ -356,6 +504,20 @@ func (in *RedshiftSinkStatus) DeepCopyInto(out *RedshiftSinkStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReleaseValidationFailures != nil {
+		in, out := &in.ReleaseValidationFailures, &out.ReleaseValidationFailures
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PreviousReleases != nil {
+		in, out := &in.PreviousReleases, &out.PreviousReleases
+		*out = make(map[string]PreviousRelease, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftSinkStatus.
@@ -368,6 +530,21 @@ func (in *RedshiftSinkStatus) DeepCopy() *RedshiftSinkStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseAssertion) DeepCopyInto(out *ReleaseAssertion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseAssertion.
+func (in *ReleaseAssertion) DeepCopy() *ReleaseAssertion {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseAssertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseCondition) DeepCopyInto(out *ReleaseCondition) {
 	*out = *in
@@ -381,6 +558,31 @@ func (in *ReleaseCondition) DeepCopyInto(out *ReleaseCondition) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.MaxBatcherLagSeconds != nil {
+		in, out := &in.MaxBatcherLagSeconds, &out.MaxBatcherLagSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxLoaderLagSeconds != nil {
+		in, out := &in.MaxLoaderLagSeconds, &out.MaxLoaderLagSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ReleaseWindows != nil {
+		in, out := &in.ReleaseWindows, &out.ReleaseWindows
+		*out = make([]ReleaseWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequireApproval != nil {
+		in, out := &in.RequireApproval, &out.RequireApproval
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(ReleaseValidation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseCondition.
@@ -393,6 +595,47 @@ func (in *ReleaseCondition) DeepCopy() *ReleaseCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseValidation) DeepCopyInto(out *ReleaseValidation) {
+	*out = *in
+	if in.MaxRowCountDeviationPercent != nil {
+		in, out := &in.MaxRowCountDeviationPercent, &out.MaxRowCountDeviationPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = make([]ReleaseAssertion, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseValidation.
+func (in *ReleaseValidation) DeepCopy() *ReleaseValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseWindow) DeepCopyInto(out *ReleaseWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseWindow.
+func (in *ReleaseWindow) DeepCopy() *ReleaseWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SinkGroup) DeepCopyInto(out *SinkGroup) {
 	*out = *in
@@ -428,6 +671,41 @@ func (in *SinkGroup) DeepCopy() *SinkGroup {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SinkGroupAutoscale) DeepCopyInto(out *SinkGroupAutoscale) {
+	*out = *in
+	if in.MinUnits != nil {
+		in, out := &in.MinUnits, &out.MinUnits
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxLag != nil {
+		in, out := &in.MaxLag, &out.MaxLag
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxLoadSeconds != nil {
+		in, out := &in.MaxLoadSeconds, &out.MaxLoadSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleDownDelay != nil {
+		in, out := &in.ScaleDownDelay, &out.ScaleDownDelay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SinkGroupAutoscale.
+func (in *SinkGroupAutoscale) DeepCopy() *SinkGroupAutoscale {
+	if in == nil {
+		return nil
+	}
+	out := new(SinkGroupAutoscale)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SinkGroupSpec) DeepCopyInto(out *SinkGroupSpec) {
 	*out = *in
@@ -461,6 +739,11 @@ func (in *SinkGroupSpec) DeepCopyInto(out *SinkGroupSpec) {
 		*out = new(DeploymentUnit)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Autoscale != nil {
+		in, out := &in.Autoscale, &out.Autoscale
+		*out = new(SinkGroupAutoscale)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SinkGroupSpec.
@@ -473,6 +756,42 @@ func (in *SinkGroupSpec) DeepCopy() *SinkGroupSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Timezone) DeepCopyInto(out *Timezone) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TimeColumns != nil {
+		in, out := &in.TimeColumns, &out.TimeColumns
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Timezone.
+func (in *Timezone) DeepCopy() *Timezone {
+	if in == nil {
+		return nil
+	}
+	out := new(Timezone)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TopicMaskStatus) DeepCopyInto(out *TopicMaskStatus) {
 	*out = *in
@@ -492,3 +811,18 @@ func (in *TopicMaskStatus) DeepCopy() *TopicMaskStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicPhaseCounts) DeepCopyInto(out *TopicPhaseCounts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicPhaseCounts.
+func (in *TopicPhaseCounts) DeepCopy() *TopicPhaseCounts {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicPhaseCounts)
+	in.DeepCopyInto(out)
+	return out
+}
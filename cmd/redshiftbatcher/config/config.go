@@ -17,6 +17,8 @@ type Config struct {
 	S3Sink            s3sink.Config                 `yaml:"s3sink"`
 	SchemaRegistryURL string                        `yaml:"schemaRegistryURL"`
 	GitAccessToken    string                        `yaml:"gitAccessToken"`
+	GitSSHKey         string                        `yaml:"gitSSHKey,omitempty"`
+	GitSSHKnownHosts  string                        `yaml:"gitSSHKnownHosts,omitempty"`
 	SinkGroup         string                        `yaml:"sinkGroup,omitempty"`
 }
 
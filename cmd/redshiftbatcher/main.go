@@ -19,6 +19,7 @@ import (
 	"github.com/practo/klog/v2"
 	conf "github.com/practo/tipoca-stream/cmd/redshiftbatcher/config"
 	"github.com/practo/tipoca-stream/pkg/kafka"
+	"github.com/practo/tipoca-stream/pkg/masksource"
 	"github.com/practo/tipoca-stream/pkg/redshiftbatcher"
 	"github.com/practo/tipoca-stream/pkg/transformer/masker"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -61,11 +62,25 @@ func run(cmd *cobra.Command, args []string) {
 	// maskConfig is central to all the topics in the redshiftsink resource
 	// since the maskFile is at database level. Also centralizing
 	// it to keep it clean and make the git pull be a centarl one time activity
-	maskConfig, err := masker.NewMaskConfig(
-		"/",
+	maskSource, err := masksource.New(
 		viper.GetString("batcher.maskFile"),
+		masksource.Config{
+			GitAccessToken:    viper.GetString("gitAccessToken"),
+			GitSSHKey:         viper.GetString("gitSSHKey"),
+			GitSSHKnownHosts:  viper.GetString("gitSSHKnownHosts"),
+			S3Region:          config.S3Sink.Region,
+			S3AccessKeyId:     config.S3Sink.AccessKeyId,
+			S3SecretAccessKey: config.S3Sink.SecretAccessKey,
+		},
+	)
+	if err != nil {
+		klog.Errorf("Error making mask source: %v", err)
+		os.Exit(1)
+	}
+	maskConfig, err := masker.NewMaskConfigFromSource(
+		"/",
+		maskSource,
 		viper.GetString("batcher.maskFileVersion"),
-		viper.GetString("gitAccessToken"),
 	)
 	if err != nil {
 		klog.Errorf("Error loading mask config: %v", err)
@@ -23,6 +23,8 @@ type Config struct {
 	RedshiftMetrics   bool                        `yaml:"redshiftMetrics"`
 	Rsk               string                      `yaml:"rsk,omitempty"`
 	SinkGroup         string                      `yaml:"sinkGroup,omitempty"`
+	SlackBotToken     string                      `yaml:"slackBotToken,omitempty"`
+	SlackChannelID    string                      `yaml:"slackChannelID,omitempty"`
 }
 
 func LoadConfig(cmd *cobra.Command) (Config, error) {
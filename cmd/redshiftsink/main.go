@@ -18,6 +18,7 @@ import (
 	"context"
 	"flag"
 	"math/rand"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
@@ -33,6 +34,8 @@ import (
 	"k8s.io/klog/klogr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
+	event "sigs.k8s.io/controller-runtime/pkg/event"
+	manager "sigs.k8s.io/controller-runtime/pkg/manager"
 	metrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	tipocav1 "github.com/practo/tipoca-stream/api/v1"
@@ -69,13 +72,43 @@ func parseDatabase(databases string) []*string {
 	return dbs
 }
 
+// maskWebhookServer serves the mask webhook till the manager is stopped
+func maskWebhookServer(addr string, webhook http.Handler) manager.Runnable {
+	return manager.RunnableFunc(func(ctx context.Context) error {
+		mux := http.NewServeMux()
+		mux.Handle("/mask-webhook", webhook)
+		server := &http.Server{Addr: addr, Handler: mux}
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(
+				context.Background(), time.Second*10)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		setupLog.Info("Starting mask webhook", "addr", addr)
+		err := server.ListenAndServe()
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	})
+}
+
 func main() {
 	rand.Seed(time.Now().UnixNano())
 
-	var enableLeaderElection, collectRedshiftMetrics bool
+	if len(os.Args) > 1 && os.Args[1] == "mask" {
+		os.Exit(runMask(os.Args[2:]))
+	}
+
+	var enableLeaderElection, collectRedshiftMetrics, enableWebhooks bool
 	var batcherImage, loaderImage, secretRefName, secretRefNamespace string
 	var kafkaVersion, metricsAddr, allowedRsks, prometheusURL, databases string
 	var redshiftMaxOpenConns, redshiftMaxIdleConns int
+	var maskWebhookAddr string
+	var maskPollInterval time.Duration
 	flag.StringVar(&batcherImage, "default-batcher-image", "public.ecr.aws/practo/redshiftbatcher:v1.0.0-beta.4", "image to use for the redshiftbatcher")
 	flag.StringVar(&loaderImage, "default-loader-image", "public.ecr.aws/practo/redshiftloader:v1.0.0-beta.4", "image to use for the redshiftloader")
 	flag.StringVar(&secretRefName, "default-secret-ref-name", "redshiftsink-secret", "default secret name for all redshiftsink secret")
@@ -89,6 +122,9 @@ func main() {
 	flag.StringVar(&allowedRsks, "allowed-rsks", "", "comma separated list of names of rsk resources to allow, if empty all rsk resources are allowed")
 	flag.StringVar(&prometheusURL, "prometheus-url", "", "optional, giving prometheus makes the operator enable new features using time series data. Features: loader throttling, resetting offsets of 0 throughput topics.")
 	flag.StringVar(&databases, "databases", "", "comma separated list of all redshift databases to query for redshiftsink_operator.scan_query_total view. This is required for throttling support. Please note: the view should be manually created beforehand for all the specified databases.")
+	flag.StringVar(&maskWebhookAddr, "mask-webhook-addr", "", "optional, the address the mask webhook endpoint binds to. The github and gitlab push webhooks of the mask file repositories sent to /mask-webhook reconcile the redshiftsinks whose mask file was changed. The webhook secret is read from the env MASK_WEBHOOK_SECRET.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "enable the defaulting and validating admission webhooks of the redshiftsink resource, the webhook server listens on port 9443 and requires the serving certificates in /tmp/k8s-webhook-server/serving-certs")
+	flag.DurationVar(&maskPollInterval, "mask-poll-interval", 0, "interval at which the mask file git repositories are pulled for new versions, defaults to 30s and to 10m when the mask webhook is enabled")
 	flag.Parse()
 
 	ctrl.SetLogger(klogr.New())
@@ -121,6 +157,15 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	var maskWebhookEvents chan event.GenericEvent
+	if maskWebhookAddr != "" {
+		maskWebhookEvents = make(chan event.GenericEvent)
+		if maskPollInterval == 0 {
+			maskPollInterval = time.Minute * 10
+		}
+	}
+	maskSourceCache := new(sync.Map)
+
 	var allowedResources []string
 	if allowedRsks != "" {
 		allowedResources = strings.Split(allowedRsks, ",")
@@ -136,8 +181,9 @@ func main() {
 		KafkaTopicsCache:            new(sync.Map),
 		KafkaRealtimeCache:          new(sync.Map),
 		ReleaseCache:                new(sync.Map),
-		GitCache:                    new(sync.Map),
+		MaskSourceCache:             maskSourceCache,
 		IncludeTablesCache:          new(sync.Map),
+		MaskLintCache:               new(sync.Map),
 		DefaultBatcherImage:         batcherImage,
 		DefaultLoaderImage:          loaderImage,
 		DefaultSecretRefName:        secretRefName,
@@ -148,12 +194,38 @@ func main() {
 		AllowedResources:            allowedResources,
 		PrometheusClient:            prometheusClient,
 		RedshiftMetrics:             collectRedshiftMetrics,
+		MaskPollInterval:            maskPollInterval,
+		MaskWebhookEvents:           maskWebhookEvents,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "RedshiftSink")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = (&tipocav1.RedshiftSink{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "RedshiftSink")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if maskWebhookAddr != "" {
+		maskWebhookSecret := os.Getenv("MASK_WEBHOOK_SECRET")
+		if maskWebhookSecret == "" {
+			setupLog.Info("MASK_WEBHOOK_SECRET is required for the mask webhook")
+			os.Exit(1)
+		}
+		err = mgr.Add(maskWebhookServer(maskWebhookAddr, &controllers.MaskWebhook{
+			Client:          uncachedClient,
+			Secret:          maskWebhookSecret,
+			MaskSourceCache: maskSourceCache,
+			Events:          maskWebhookEvents,
+		}))
+		if err != nil {
+			setupLog.Error(err, "unable to add mask webhook")
+			os.Exit(1)
+		}
+	}
+
 	if !collectRedshiftMetrics {
 		setupLog.Info("Starting Operator... (redshift metrics feature is disabled)")
 		if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/practo/tipoca-stream/pkg/kafka"
+	"github.com/practo/tipoca-stream/pkg/transformer"
+	"github.com/practo/tipoca-stream/pkg/transformer/masker"
+)
+
+const maskUsage = `Usage: redshiftsink mask lint [flags]
+
+Validates the mask file strictly, exits non zero if errors are found.
+`
+
+// runMask runs the mask subcommands and returns the exit code
+func runMask(args []string) int {
+	if len(args) == 0 || args[0] != "lint" {
+		fmt.Fprint(os.Stderr, maskUsage)
+		return 2
+	}
+
+	var maskFile, maskFileVersion, gitToken, tables string
+	var kafkaBrokers, kafkaVersion, kafkaTopicRegexes string
+	var strict bool
+	fs := flag.NewFlagSet("mask lint", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, maskUsage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&maskFile, "mask-file", "", "local path or git url of the mask file")
+	fs.StringVar(&maskFileVersion, "mask-file-version", "", "git commit of the mask file, required if the mask file is a git url")
+	fs.StringVar(&gitToken, "git-token", os.Getenv("GIT_ACCESS_TOKEN"), "git access token to download the mask file, defaults to GIT_ACCESS_TOKEN")
+	fs.StringVar(&tables, "tables", "", "comma separated list of tables to check the tables of the mask file against")
+	fs.StringVar(&kafkaBrokers, "kafka-brokers", "", "comma separated list of kafka brokers to check the tables of the mask file against the topics")
+	fs.StringVar(&kafkaVersion, "kafka-version", "2.6.0", "kafka version")
+	fs.StringVar(&kafkaTopicRegexes, "kafka-topic-regexes", "", "comma separated list of regexes of the topics of the database")
+	fs.BoolVar(&strict, "strict", false, "exit non zero on warnings also")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return 2
+	}
+	if maskFile == "" {
+		fmt.Fprintln(os.Stderr, "--mask-file is required")
+		return 2
+	}
+
+	var lintTables []string
+	if tables != "" {
+		lintTables = strings.Split(tables, ",")
+	}
+	if kafkaBrokers != "" {
+		kafkaTables, err := tablesFromKafka(
+			kafkaBrokers, kafkaVersion, kafkaTopicRegexes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching kafka topics, err: %v\n", err)
+			return 1
+		}
+		lintTables = append(lintTables, kafkaTables...)
+	}
+
+	homeDir, err := ioutil.TempDir("", "masklint")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating temp dir, err: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(homeDir)
+
+	problems, err := masker.LintMaskFile(
+		homeDir, maskFile, maskFileVersion, gitToken, lintTables)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading mask file, err: %v\n", err)
+		return 1
+	}
+	for _, problem := range problems {
+		fmt.Println(problem.Error())
+	}
+	if masker.HasLintErrors(problems) || (strict && len(problems) > 0) {
+		return 1
+	}
+	fmt.Printf("%s: %d problems found\n", maskFile, len(problems))
+
+	return 0
+}
+
+// tablesFromKafka returns the tables of the topics matching the regexes
+func tablesFromKafka(brokers, version, regexes string) ([]string, error) {
+	client, err := kafka.NewClient(
+		strings.Split(brokers, ","), version, kafka.TLSConfig{})
+	if err != nil {
+		return nil, err
+	}
+	topics, err := client.Topics()
+	if err != nil {
+		return nil, err
+	}
+
+	var expressions []*regexp.Regexp
+	if regexes != "" {
+		for _, expression := range strings.Split(regexes, ",") {
+			rgx, err := regexp.Compile(strings.TrimSpace(expression))
+			if err != nil {
+				return nil, fmt.Errorf(
+					"Compling regex: %s failed, err:%v\n", expression, err)
+			}
+			expressions = append(expressions, rgx)
+		}
+	}
+
+	var tables []string
+	for _, topic := range topics {
+		// only debezium topics: server.database.table
+		if strings.Count(topic, ".") != 2 {
+			continue
+		}
+		matched := len(expressions) == 0
+		for _, rgx := range expressions {
+			if rgx.MatchString(topic) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		_, _, table := transformer.ParseTopic(topic)
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
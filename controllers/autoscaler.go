@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	klog "github.com/practo/klog/v2"
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	prometheus "github.com/practo/tipoca-stream/pkg/prometheus"
+	model "github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// autoscaleCachePrefix prefixes the topics in the realtime cache so that
+// the lag of the main consumer groups is not mixed with the reload ones
+const autoscaleCachePrefix = "autoscale/"
+
+// topicLoad is the load of a released topic used to scale the units,
+// the lag and the loadSeconds are nil when they could not be found
+type topicLoad struct {
+	topic       string
+	lag         *int64
+	loadSeconds *float64
+}
+
+// breaching tells if the load of the topic is beyond the thresholds
+func (t topicLoad) breaching(autoscale *tipocav1.SinkGroupAutoscale) bool {
+	if autoscale.MaxLag != nil && t.lag != nil && *t.lag > *autoscale.MaxLag {
+		return true
+	}
+	if autoscale.MaxLoadSeconds != nil && t.loadSeconds != nil &&
+		*t.loadSeconds > float64(*autoscale.MaxLoadSeconds) {
+		return true
+	}
+
+	return false
+}
+
+// unknown tells if none of the load of the topic could be found
+func (t topicLoad) unknown() bool {
+	return t.lag == nil && t.loadSeconds == nil
+}
+
+// sortTopicLoads sorts the topic loads with the heaviest first
+func sortTopicLoads(loads []topicLoad) {
+	value := func(v *int64) int64 {
+		if v == nil {
+			return -1
+		}
+		return *v
+	}
+	seconds := func(v *float64) float64 {
+		if v == nil {
+			return -1
+		}
+		return *v
+	}
+	sort.SliceStable(loads, func(i, j int) bool {
+		if value(loads[i].lag) != value(loads[j].lag) {
+			return value(loads[i].lag) > value(loads[j].lag)
+		}
+		if seconds(loads[i].loadSeconds) != seconds(loads[j].loadSeconds) {
+			return seconds(loads[i].loadSeconds) > seconds(loads[j].loadSeconds)
+		}
+		return loads[i].topic < loads[j].topic
+	})
+}
+
+// scaleDedicatedTopics computes the topics which should run in their own
+// units along with the last time they breached the thresholds. Topics
+// breaching the thresholds are moved to their own units, heaviest first,
+// keeping at least one unit for the rest of the topics. Topics are merged
+// back once they stay within the thresholds for the ScaleDownDelay or are
+// no longer released. Topics whose load could not be found keep their state.
+func scaleDedicatedTopics(
+	autoscale *tipocav1.SinkGroupAutoscale,
+	current map[string]metav1.Time,
+	loads []topicLoad,
+	now time.Time,
+) map[string]metav1.Time {
+	var scaleDownDelay time.Duration
+	if autoscale.ScaleDownDelay != nil {
+		scaleDownDelay = autoscale.ScaleDownDelay.Duration
+	}
+
+	sorted := make([]topicLoad, len(loads))
+	copy(sorted, loads)
+	sortTopicLoads(sorted)
+
+	retained := []topicLoad{}
+	added := []topicLoad{}
+	lastBreached := make(map[string]metav1.Time)
+	for _, load := range sorted {
+		last, dedicated := current[load.topic]
+		switch {
+		case load.breaching(autoscale):
+			lastBreached[load.topic] = metav1.NewTime(now)
+		case dedicated && (load.unknown() ||
+			now.Sub(last.Time) < scaleDownDelay):
+			lastBreached[load.topic] = last
+		default:
+			continue
+		}
+		if dedicated {
+			retained = append(retained, load)
+		} else {
+			added = append(added, load)
+		}
+	}
+
+	dedicatedTopics := make(map[string]metav1.Time)
+	for _, load := range append(retained, added...) {
+		if len(dedicatedTopics) >= int(autoscale.MaxUnits)-1 {
+			break
+		}
+		dedicatedTopics[load.topic] = lastBreached[load.topic]
+	}
+
+	return dedicatedTopics
+}
+
+// dedicatedTopicsChanged tells if the topics running in their own units
+// would change, the change in the last breached time is not considered
+func dedicatedTopicsChanged(current, desired map[string]metav1.Time) bool {
+	if len(current) != len(desired) {
+		return true
+	}
+	for topic := range desired {
+		_, ok := current[topic]
+		if !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loaderSecondsQuery returns the prometheus query for the average time
+// taken to load a batch of the loader topics of the main sink group
+func loaderSecondsQuery(rskName string) string {
+	selector := fmt.Sprintf(
+		`{rsk="%s",sink_group="%s"}`, rskName, MainSinkGroup)
+	return fmt.Sprintf(
+		"sum by (topic)(rate(rsk_loader_seconds_sum%s[10m])) / "+
+			"sum by (topic)(rate(rsk_loader_seconds_count%s[10m]))",
+		selector,
+		selector,
+	)
+}
+
+// autoscaler computes the load of the released topics and scales the
+// units of the main sink group
+type autoscaler struct {
+	rsk              *tipocav1.RedshiftSink
+	calc             *realtimeCalculator
+	prometheusClient prometheus.Client
+}
+
+// fetchLoads returns the batcher and the loader loads of the topics
+func (a *autoscaler) fetchLoads(
+	topics []string,
+	loadSeconds bool,
+) (
+	[]topicLoad, []topicLoad,
+) {
+	batcherLoads := []topicLoad{}
+	loaderLoads := []topicLoad{}
+
+	var loadVector *model.Vector
+	if loadSeconds && a.prometheusClient != nil {
+		vector, err := a.prometheusClient.QueryVector(
+			loaderSecondsQuery(a.rsk.Name))
+		if err != nil {
+			klog.Errorf(
+				"rsk/%s Error querying load seconds, err: %v", a.rsk.Name, err)
+		} else {
+			loadVector = vector
+		}
+	}
+
+	for _, topic := range topics {
+		group := topicGroup(a.rsk, topic)
+		if group == nil {
+			klog.Errorf("rsk/%s group not found for topic: %s",
+				a.rsk.Name, topic)
+			batcherLoads = append(batcherLoads, topicLoad{topic: topic})
+			loaderLoads = append(loaderLoads, topicLoad{topic: topic})
+			continue
+		}
+		loaderTopic := group.LoaderTopicPrefix + topic
+
+		key := autoscaleCachePrefix + topic
+		info, hit := a.calc.fetchRealtimeCache(key)
+		if !hit {
+			var err error
+			// the autoscaler needs only the lag, not the timestamps
+			info, err = a.calc.fetchOffsetInfo(
+				topic, &loaderTopic, group.ID, nil)
+			if err != nil {
+				klog.Errorf(
+					"rsk/%s Error fetching lag for topic: %s, err: %v",
+					a.rsk.Name,
+					topic,
+					err,
+				)
+			} else {
+				a.calc.cache.Store(key, info)
+			}
+		}
+
+		batcherLoad := topicLoad{topic: topic}
+		if info.batcher != nil && info.batcher.last != nil &&
+			info.batcher.current != nil {
+			lag := *info.batcher.last - *info.batcher.current
+			batcherLoad.lag = &lag
+		}
+		loaderLoad := topicLoad{topic: topic}
+		if info.loader != nil && info.loader.last != nil &&
+			info.loader.current != nil {
+			lag := *info.loader.last - *info.loader.current
+			loaderLoad.lag = &lag
+		}
+		if loadVector != nil {
+			seconds, err := a.prometheusClient.FilterVector(
+				*loadVector, "topic", loaderTopic)
+			if err != nil {
+				klog.Errorf(
+					"rsk/%s Error filtering load seconds of %s, err: %v",
+					a.rsk.Name,
+					loaderTopic,
+					err,
+				)
+			} else {
+				loaderLoad.loadSeconds = seconds
+			}
+		}
+		klog.V(4).Infof(
+			"rsk/%s %s autoscale batcherLag=%v loaderLag=%v loadSeconds=%v",
+			a.rsk.Name,
+			topic,
+			printInt64(batcherLoad.lag),
+			printInt64(loaderLoad.lag),
+			printFloat64(loaderLoad.loadSeconds),
+		)
+
+		batcherLoads = append(batcherLoads, batcherLoad)
+		loaderLoads = append(loaderLoads, loaderLoad)
+	}
+
+	return batcherLoads, loaderLoads
+}
+
+// scale updates the topics running in their own units of the main sink
+// group in the status, it returns true if the units would change.
+func (a *autoscaler) scale(released []string, now time.Time) bool {
+	batcherAutoscale := a.rsk.BatcherSinkGroupSpec(MainSinkGroup, nil).Autoscale
+	loaderAutoscale := a.rsk.LoaderSinkGroupSpec(MainSinkGroup, nil).Autoscale
+
+	var batcherLoads, loaderLoads []topicLoad
+	if batcherAutoscale != nil || loaderAutoscale != nil {
+		batcherLoads, loaderLoads = a.fetchLoads(
+			released,
+			loaderAutoscale != nil && loaderAutoscale.MaxLoadSeconds != nil,
+		)
+	}
+
+	var batcherDedicated, loaderDedicated map[string]metav1.Time
+	if batcherAutoscale != nil {
+		batcherDedicated = scaleDedicatedTopics(
+			batcherAutoscale,
+			a.rsk.Status.BatcherDedicatedTopics,
+			batcherLoads,
+			now,
+		)
+	}
+	if loaderAutoscale != nil {
+		loaderDedicated = scaleDedicatedTopics(
+			loaderAutoscale,
+			a.rsk.Status.LoaderDedicatedTopics,
+			loaderLoads,
+			now,
+		)
+	}
+
+	changed := false
+	if dedicatedTopicsChanged(
+		a.rsk.Status.BatcherDedicatedTopics, batcherDedicated) {
+		klog.V(2).Infof("rsk/%s batcher dedicated topics: %v",
+			a.rsk.Name, mapKeys(batcherDedicated))
+		changed = true
+	}
+	if dedicatedTopicsChanged(
+		a.rsk.Status.LoaderDedicatedTopics, loaderDedicated) {
+		klog.V(2).Infof("rsk/%s loader dedicated topics: %v",
+			a.rsk.Name, mapKeys(loaderDedicated))
+		changed = true
+	}
+	a.rsk.Status.BatcherDedicatedTopics = emptyToNil(batcherDedicated)
+	a.rsk.Status.LoaderDedicatedTopics = emptyToNil(loaderDedicated)
+
+	return changed
+}
+
+func emptyToNil(m map[string]metav1.Time) map[string]metav1.Time {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func mapKeys(m map[string]metav1.Time) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func printInt64(v *int64) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%d", *v)
+}
+
+func printFloat64(v *float64) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%.2f", *v)
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScaleDedicatedTopics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	lag := func(l int64) *int64 { return &l }
+	seconds := func(s float64) *float64 { return &s }
+	minutesAgo := func(m int) metav1.Time {
+		return metav1.NewTime(now.Add(-time.Duration(m) * time.Minute))
+	}
+	autoscale := &tipocav1.SinkGroupAutoscale{
+		MaxUnits:       3,
+		MaxLag:         lag(100),
+		MaxLoadSeconds: func(i int32) *int32 { return &i }(60),
+		ScaleDownDelay: &metav1.Duration{Duration: 10 * time.Minute},
+	}
+
+	tests := []struct {
+		name      string
+		current   map[string]metav1.Time
+		loads     []topicLoad
+		dedicated map[string]metav1.Time
+	}{
+		{
+			name: "test1: nothing breaching",
+			loads: []topicLoad{
+				{topic: "t1", lag: lag(10)},
+				{topic: "t2", lag: lag(100)},
+			},
+			dedicated: map[string]metav1.Time{},
+		},
+		{
+			name: "test2: breaching lag and load seconds",
+			loads: []topicLoad{
+				{topic: "t1", lag: lag(101)},
+				{topic: "t2", lag: lag(0), loadSeconds: seconds(61)},
+				{topic: "t3", lag: lag(0), loadSeconds: seconds(30)},
+			},
+			dedicated: map[string]metav1.Time{
+				"t1": metav1.NewTime(now),
+				"t2": metav1.NewTime(now),
+			},
+		},
+		{
+			name: "test3: heaviest first within max units",
+			loads: []topicLoad{
+				{topic: "t1", lag: lag(200)},
+				{topic: "t2", lag: lag(500)},
+				{topic: "t3", lag: lag(300)},
+			},
+			dedicated: map[string]metav1.Time{
+				"t2": metav1.NewTime(now),
+				"t3": metav1.NewTime(now),
+			},
+		},
+		{
+			name: "test4: dedicated topics are retained first",
+			current: map[string]metav1.Time{
+				"t1": minutesAgo(2),
+			},
+			loads: []topicLoad{
+				{topic: "t1", lag: lag(0)},
+				{topic: "t2", lag: lag(500)},
+				{topic: "t3", lag: lag(300)},
+			},
+			dedicated: map[string]metav1.Time{
+				"t1": minutesAgo(2),
+				"t2": metav1.NewTime(now),
+			},
+		},
+		{
+			name: "test5: merged back after the scale down delay",
+			current: map[string]metav1.Time{
+				"t1": minutesAgo(11),
+				"t2": minutesAgo(11),
+			},
+			loads: []topicLoad{
+				{topic: "t1", lag: lag(0)},
+				{topic: "t2", lag: lag(101)},
+			},
+			dedicated: map[string]metav1.Time{
+				"t2": metav1.NewTime(now),
+			},
+		},
+		{
+			name: "test6: unknown load keeps the state",
+			current: map[string]metav1.Time{
+				"t1": minutesAgo(30),
+			},
+			loads: []topicLoad{
+				{topic: "t1"},
+				{topic: "t2"},
+			},
+			dedicated: map[string]metav1.Time{
+				"t1": minutesAgo(30),
+			},
+		},
+		{
+			name: "test7: topics no longer released are merged back",
+			current: map[string]metav1.Time{
+				"t1": minutesAgo(1),
+			},
+			loads: []topicLoad{
+				{topic: "t2", lag: lag(0)},
+			},
+			dedicated: map[string]metav1.Time{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dedicated := scaleDedicatedTopics(
+				autoscale, tc.current, tc.loads, now)
+			if !reflect.DeepEqual(dedicated, tc.dedicated) {
+				t.Errorf("expected: %v, got: %v", tc.dedicated, dedicated)
+			}
+		})
+	}
+}
+
+func TestDedicatedTopicsChanged(t *testing.T) {
+	t.Parallel()
+
+	now := metav1.Now()
+	tests := []struct {
+		name    string
+		current map[string]metav1.Time
+		desired map[string]metav1.Time
+		changed bool
+	}{
+		{
+			name:    "test1: both empty",
+			current: nil,
+			desired: map[string]metav1.Time{},
+			changed: false,
+		},
+		{
+			name:    "test2: time updated",
+			current: map[string]metav1.Time{"t1": {}},
+			desired: map[string]metav1.Time{"t1": now},
+			changed: false,
+		},
+		{
+			name:    "test3: topic replaced",
+			current: map[string]metav1.Time{"t1": now},
+			desired: map[string]metav1.Time{"t2": now},
+			changed: true,
+		},
+		{
+			name:    "test4: topic added",
+			current: nil,
+			desired: map[string]metav1.Time{"t1": now},
+			changed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			changed := dedicatedTopicsChanged(tc.current, tc.desired)
+			if changed != tc.changed {
+				t.Errorf("expected: %v, got: %v", tc.changed, changed)
+			}
+		})
+	}
+}
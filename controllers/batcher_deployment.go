@@ -2,21 +2,25 @@ package controllers
 
 import (
 	"fmt"
+	"path/filepath"
 
 	tipocav1 "github.com/practo/tipoca-stream/api/v1"
 	"github.com/practo/tipoca-stream/cmd/redshiftbatcher/config"
 	"github.com/practo/tipoca-stream/pkg/kafka"
+	"github.com/practo/tipoca-stream/pkg/masksource"
 	"github.com/practo/tipoca-stream/pkg/redshiftbatcher"
 	"github.com/practo/tipoca-stream/pkg/s3sink"
 	yaml "gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	resource "k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
 	BatcherTag           = "batcher"
 	BatcherLabelInstance = "redshiftbatcher"
+
+	// maskFileMountDir is where the configmap mask files are mounted
+	maskFileMountDir = "/mask"
 )
 
 type Batcher struct {
@@ -28,114 +32,14 @@ type Batcher struct {
 }
 
 // applyBatcherSinkGroupDefaults applies the defaults for the batcher
-// deployments of the sink group. User does not need to specify big lengthy
-// configurations everytime. Defaults are optimized for maximum performance
-// and are recommended to use.
+// deployments of the sink group, the default image is used when the image
+// is not specified.
 func applyBatcherSinkGroupDefaults(
 	rsk *tipocav1.RedshiftSink,
 	sgType string,
 	defaultImage string,
 ) *tipocav1.SinkGroupSpec {
-	var maxSizePerBatch *resource.Quantity
-	var maxWaitSeconds *int
-	var maxConcurrency *int
-	var maxProcessingTime *int32
-	var image *string
-	var resources *corev1.ResourceRequirements
-	var tolerations *[]corev1.Toleration
-	var maxReloadingUnits *int32
-
-	// defaults by sinkgroup
-	switch sgType {
-	case MainSinkGroup:
-		maxSizePerBatch = toQuantityPtr(resource.MustParse("0.8Mi"))
-		maxWaitSeconds = toIntPtr(450)
-		maxConcurrency = toIntPtr(2)
-		maxProcessingTime = &redshiftbatcher.DefaultMaxProcessingTime
-		image = &defaultImage
-		maxReloadingUnits = toInt32Ptr(10)
-	case ReloadSinkGroup:
-		maxSizePerBatch = toQuantityPtr(resource.MustParse("0.5Mi"))
-		maxWaitSeconds = toIntPtr(60)
-		maxConcurrency = toIntPtr(10)
-		maxProcessingTime = &redshiftbatcher.DefaultMaxProcessingTime
-		image = &defaultImage
-		maxReloadingUnits = toInt32Ptr(10)
-	case ReloadDupeSinkGroup:
-		maxSizePerBatch = toQuantityPtr(resource.MustParse("0.5Mi"))
-		maxWaitSeconds = toIntPtr(450)
-		maxConcurrency = toIntPtr(10)
-		maxProcessingTime = &redshiftbatcher.DefaultMaxProcessingTime
-		image = &defaultImage
-		maxReloadingUnits = toInt32Ptr(10)
-	}
-
-	var specifiedSpec *tipocav1.SinkGroupSpec
-	// apply the sinkGroup spec rules
-	if rsk.Spec.Batcher.SinkGroup.All != nil {
-		specifiedSpec = rsk.Spec.Batcher.SinkGroup.All
-	}
-	switch sgType {
-	case MainSinkGroup:
-		if rsk.Spec.Batcher.SinkGroup.Main != nil {
-			specifiedSpec = rsk.Spec.Batcher.SinkGroup.Main
-		}
-	case ReloadSinkGroup:
-		if rsk.Spec.Batcher.SinkGroup.Reload != nil {
-			specifiedSpec = rsk.Spec.Batcher.SinkGroup.Reload
-		}
-	case ReloadDupeSinkGroup:
-		if rsk.Spec.Batcher.SinkGroup.ReloadDupe != nil {
-			specifiedSpec = rsk.Spec.Batcher.SinkGroup.ReloadDupe
-		}
-	}
-
-	// overwrite the defaults with the specified values
-	if specifiedSpec != nil {
-		if specifiedSpec.MaxSizePerBatch != nil {
-			maxSizePerBatch = specifiedSpec.MaxSizePerBatch
-		}
-		if specifiedSpec.MaxWaitSeconds != nil {
-			maxWaitSeconds = specifiedSpec.MaxWaitSeconds
-		}
-		if specifiedSpec.MaxConcurrency != nil {
-			maxConcurrency = specifiedSpec.MaxConcurrency
-		}
-		if specifiedSpec.MaxProcessingTime != nil {
-			maxProcessingTime = specifiedSpec.MaxProcessingTime
-		}
-		if specifiedSpec.MaxReloadingUnits != nil {
-			maxReloadingUnits = specifiedSpec.MaxReloadingUnits
-		}
-		if specifiedSpec.DeploymentUnit != nil {
-			if specifiedSpec.DeploymentUnit.PodTemplate != nil {
-				if specifiedSpec.DeploymentUnit.PodTemplate.Image != nil {
-					image = specifiedSpec.DeploymentUnit.PodTemplate.Image
-				}
-				if specifiedSpec.DeploymentUnit.PodTemplate.Resources != nil {
-					resources = specifiedSpec.DeploymentUnit.PodTemplate.Resources
-				}
-				if specifiedSpec.DeploymentUnit.PodTemplate.Tolerations != nil {
-					tolerations = specifiedSpec.DeploymentUnit.PodTemplate.Tolerations
-				}
-			}
-		}
-	}
-
-	return &tipocav1.SinkGroupSpec{
-		MaxSizePerBatch:   maxSizePerBatch,
-		MaxWaitSeconds:    maxWaitSeconds,
-		MaxConcurrency:    maxConcurrency,
-		MaxProcessingTime: maxProcessingTime,
-		MaxReloadingUnits: maxReloadingUnits,
-		DeploymentUnit: &tipocav1.DeploymentUnit{
-			PodTemplate: &tipocav1.RedshiftPodTemplateSpec{
-				Image:       image,
-				Resources:   resources,
-				Tolerations: tolerations,
-			},
-		},
-	}
+	return rsk.BatcherSinkGroupSpec(sgType, &defaultImage)
 }
 
 func batcherSecret(secret map[string]string) (map[string]string, error) {
@@ -184,7 +88,7 @@ func batcherName(rskName, sinkGroup, id string) string {
 func NewBatcher(
 	name string,
 	rsk *tipocav1.RedshiftSink,
-	maskFileVersion string,
+	version string,
 	secret map[string]string,
 	sinkGroup string,
 	sinkGroupSpec *tipocav1.SinkGroupSpec,
@@ -196,11 +100,36 @@ func NewBatcher(
 	Deployment,
 	error,
 ) {
-	secret, err := batcherSecret(secret)
+	maskFileVersion, maskSaltVersion := splitMaskVersion(version)
+	maskSalt, err := maskSaltByVersion(secret, maskSaltVersion)
+	if err != nil {
+		return nil, fmt.Errorf("batcher secret: %v", err)
+	}
+	gitSSHKey := secret["gitSSHKey"]
+	gitSSHKnownHosts := secret["gitSSHKnownHosts"]
+	secret, err = batcherSecret(secret)
 	if err != nil {
 		return nil, err
 	}
 
+	// configmap mask files are mounted from the snapshot of the version
+	// as the batchers can not read the configmaps
+	maskFile := rsk.Spec.Batcher.MaskFile
+	var configMapMounts []configMapMount
+	if masksource.IsConfigMap(maskFile) && maskFileVersion != "" {
+		name, key, err := masksource.ParseConfigMap(maskFile)
+		if err != nil {
+			return nil, err
+		}
+		maskFile = filepath.Join(maskFileMountDir, filepath.Base(key))
+		configMapMounts = append(configMapMounts, configMapMount{
+			name:       masksource.SnapshotName(name, maskFileVersion),
+			volumeName: "mask-file",
+			mountPath:  maskFile,
+			subPath:    key,
+		})
+	}
+
 	// defaults
 	kafkaVersion := rsk.Spec.KafkaVersion
 	if kafkaVersion == "" {
@@ -211,8 +140,7 @@ func NewBatcher(
 	var maxWaitSeconds, maxConcurrency *int
 	var maxProcessingTime int32 = redshiftbatcher.DefaultMaxProcessingTime
 	var image string
-	var resources *corev1.ResourceRequirements
-	var tolerations *[]corev1.Toleration
+	var podTemplate *tipocav1.RedshiftPodTemplateSpec
 	if sinkGroupSpec != nil {
 		m := sinkGroupSpec.MaxSizePerBatch.Value()
 		maxBytesPerBatch = &m
@@ -220,8 +148,7 @@ func NewBatcher(
 		maxConcurrency = sinkGroupSpec.MaxConcurrency
 		maxProcessingTime = *sinkGroupSpec.MaxProcessingTime
 		image = *sinkGroupSpec.DeploymentUnit.PodTemplate.Image
-		resources = sinkGroupSpec.DeploymentUnit.PodTemplate.Resources
-		tolerations = sinkGroupSpec.DeploymentUnit.PodTemplate.Tolerations
+		podTemplate = sinkGroupSpec.DeploymentUnit.PodTemplate
 	} else { // Deprecated
 		maxSize = rsk.Spec.Batcher.MaxSize
 		maxWaitSeconds = &rsk.Spec.Batcher.MaxWaitSeconds
@@ -237,8 +164,7 @@ func NewBatcher(
 		} else {
 			image = defaultImage
 		}
-		resources = rsk.Spec.Batcher.PodTemplate.Resources
-		tolerations = rsk.Spec.Batcher.PodTemplate.Tolerations
+		podTemplate = rsk.Spec.Batcher.PodTemplate
 	}
 	// defaults which are not configurable for the user
 	var sessionTimeoutSeconds int = 10
@@ -274,13 +200,14 @@ func NewBatcher(
 	conf := config.Config{
 		Batcher: redshiftbatcher.BatcherConfig{
 			Mask:             rsk.Spec.Batcher.Mask,
-			MaskSalt:         secret["maskSalt"],
-			MaskFile:         rsk.Spec.Batcher.MaskFile,
+			MaskSalt:         maskSalt,
+			MaskFile:         maskFile,
 			MaskFileVersion:  maskFileVersion,
 			MaxSize:          maxSize, // Deprecated
 			MaxWaitSeconds:   maxWaitSeconds,
 			MaxConcurrency:   maxConcurrency,
 			MaxBytesPerBatch: maxBytesPerBatch,
+			Timezone:         timezoneConfig(rsk),
 		},
 		ConsumerGroups: groupConfigs,
 		S3Sink: s3sink.Config{
@@ -292,6 +219,8 @@ func NewBatcher(
 		},
 		SchemaRegistryURL: secret["schemaRegistryURL"],
 		GitAccessToken:    secret["gitAccessToken"],
+		GitSSHKey:         gitSSHKey,
+		GitSSHKnownHosts:  gitSSHKnownHosts,
 		SinkGroup:         sinkGroup,
 	}
 	confBytes, err := yaml.Marshal(conf)
@@ -330,16 +259,22 @@ func NewBatcher(
 		namespace:   rsk.Namespace,
 		labels:      labels,
 		replicas:    &replicas,
-		resources:   resources,
-		tolerations: tolerations,
+		podTemplate: podTemplate,
 		image:       image,
 		args:        []string{"-v=4", "--config=/config.yaml"},
+
+		configMapMounts: configMapMounts,
+	}
+
+	podTemplateHash, err := podTemplateHash(podTemplate, image)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Batcher{
 		name:       objectName,
 		namespace:  rsk.Namespace,
-		deployment: deploymentFromSpec(deploySpec, configSpec),
+		deployment: deploymentFromSpec(deploySpec, configSpec, podTemplateHash),
 		config:     configFromSpec(configSpec),
 		topics:     topics,
 	}, nil
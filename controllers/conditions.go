@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	masksource "github.com/practo/tipoca-stream/pkg/masksource"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileError is the error of a failed reconcile with the reason
+// of the Degraded condition. reconciled is set when the error did not
+// stop the reconcile.
+type reconcileError struct {
+	reason     string
+	err        error
+	reconciled bool
+}
+
+func (e *reconcileError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reconcileError) Unwrap() error {
+	return e.err
+}
+
+// degraded sets the reason of the Degraded condition for the error
+func degraded(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &reconcileError{reason: reason, err: err}
+}
+
+// degradedReconciled sets the reason of the Degraded condition for the
+// error which did not stop the reconcile, the rest of the conditions are
+// set as for a successful reconcile.
+func degradedReconciled(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &reconcileError{reason: reason, err: err, reconciled: true}
+}
+
+// isReconciled tells if the reconcile completed in spite of the error
+func isReconciled(err error) bool {
+	var reconcileErr *reconcileError
+	if errors.As(err, &reconcileErr) {
+		return reconcileErr.reconciled
+	}
+
+	return false
+}
+
+// maskSourceUnreachableReason returns the reason of the Degraded condition
+// when the mask file could not be fetched from its source
+func maskSourceUnreachableReason(maskFile string) string {
+	if masksource.IsS3(maskFile) || masksource.IsConfigMap(maskFile) {
+		return tipocav1.ReasonMaskSourceUnreachable
+	}
+
+	return tipocav1.ReasonGitUnreachable
+}
+
+// degradedReason returns the reason of the Degraded condition for the error
+func degradedReason(err error) string {
+	var reconcileErr *reconcileError
+	if errors.As(err, &reconcileErr) {
+		return reconcileErr.reason
+	}
+
+	return tipocav1.ReasonReconcileFailed
+}
+
+// setCondition sets the condition in the rsk status, the transition
+// time changes only when the status of the condition changes.
+func setCondition(
+	rsk *tipocav1.RedshiftSink,
+	conditionType string,
+	status metav1.ConditionStatus,
+	reason string,
+	message string,
+) {
+	meta.SetStatusCondition(&rsk.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: rsk.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	// SetStatusCondition does not update the generation of existing ones
+	meta.FindStatusCondition(
+		rsk.Status.Conditions, conditionType).ObservedGeneration = rsk.Generation
+}
+
+// updateConditions sets the conditions of the rsk using the result of the
+// reconcile, the events of the sink groups changed by it and its error.
+func updateConditions(
+	rsk *tipocav1.RedshiftSink,
+	events []ReconcilerEvent,
+	err error,
+) {
+	topics := tipocav1.TopicPhaseCounts{}
+	if rsk.Status.Topics != nil {
+		topics = *rsk.Status.Topics
+	}
+
+	if err != nil && !isReconciled(err) {
+		reason := degradedReason(err)
+		setCondition(rsk, tipocav1.ConditionDegraded,
+			metav1.ConditionTrue, reason, err.Error())
+		setCondition(rsk, tipocav1.ConditionReady,
+			metav1.ConditionFalse, reason, err.Error())
+	} else {
+		rsk.Status.ObservedGeneration = rsk.Generation
+		if err != nil {
+			setCondition(rsk, tipocav1.ConditionDegraded,
+				metav1.ConditionTrue, degradedReason(err), err.Error())
+		} else {
+			setCondition(rsk, tipocav1.ConditionDegraded,
+				metav1.ConditionFalse, tipocav1.ReasonReconciled, "")
+		}
+		switch {
+		case topics.Total == 0:
+			setCondition(rsk, tipocav1.ConditionReady,
+				metav1.ConditionFalse, tipocav1.ReasonTopicsNotFound,
+				"No kafka topics match the kafkaTopicRegexes")
+		case len(events) > 0:
+			setCondition(rsk, tipocav1.ConditionReady,
+				metav1.ConditionFalse, tipocav1.ReasonProgressing,
+				"Sink groups are being updated")
+		default:
+			setCondition(rsk, tipocav1.ConditionReady,
+				metav1.ConditionTrue, tipocav1.ReasonReconciled,
+				fmt.Sprintf("%d topics are being sinked", topics.Total))
+		}
+	}
+
+	if topics.Reloading > 0 {
+		setCondition(rsk, tipocav1.ConditionReloading,
+			metav1.ConditionTrue, tipocav1.ReasonTopicsReloading,
+			fmt.Sprintf("%d of %d topics are reloading",
+				topics.Reloading, topics.Total))
+	} else {
+		setCondition(rsk, tipocav1.ConditionReloading,
+			metav1.ConditionFalse, tipocav1.ReasonNoTopicsReloading, "")
+	}
+
+	if len(rsk.Status.ReleaseValidationFailures) > 0 {
+		failed := []string{}
+		for topic := range rsk.Status.ReleaseValidationFailures {
+			failed = append(failed, topic)
+		}
+		sortStringSlice(failed)
+		setCondition(rsk, tipocav1.ConditionReleasing,
+			metav1.ConditionFalse, tipocav1.ReasonValidationFailed,
+			fmt.Sprintf("%d topics failed the release validation: %v",
+				len(failed), failed))
+	} else if topics.Realtime > 0 {
+		setCondition(rsk, tipocav1.ConditionReleasing,
+			metav1.ConditionTrue, tipocav1.ReasonTopicsRealtime,
+			fmt.Sprintf("%d of %d topics are realtime and being released",
+				topics.Realtime, topics.Total))
+	} else if topics.AwaitingApproval > 0 {
+		setCondition(rsk, tipocav1.ConditionReleasing,
+			metav1.ConditionFalse, tipocav1.ReasonAwaitingApproval,
+			fmt.Sprintf("%d of %d topics are realtime and awaiting release approval",
+				topics.AwaitingApproval, topics.Total))
+	} else {
+		setCondition(rsk, tipocav1.ConditionReleasing,
+			metav1.ConditionFalse, tipocav1.ReasonNoTopicsRealtime, "")
+	}
+
+	if len(events) > 0 || topics.Reloading > 0 || topics.Realtime > 0 {
+		setCondition(rsk, tipocav1.ConditionReconciling,
+			metav1.ConditionTrue, tipocav1.ReasonProgressing,
+			fmt.Sprintf(
+				"%d sink group changes, %d topics reloading, %d realtime",
+				len(events), topics.Reloading, topics.Realtime))
+	} else {
+		setCondition(rsk, tipocav1.ConditionReconciling,
+			metav1.ConditionFalse, tipocav1.ReasonReconciled, "")
+	}
+}
+
+// topicPhaseCounts counts the topics of the mask status by their phase
+func topicPhaseCounts(maskStatus *tipocav1.MaskStatus) *tipocav1.TopicPhaseCounts {
+	counts := &tipocav1.TopicPhaseCounts{}
+	if maskStatus == nil {
+		return counts
+	}
+
+	for _, topicStatus := range maskStatus.CurrentMaskStatus {
+		counts.Total++
+		switch topicStatus.Phase {
+		case tipocav1.MaskActive:
+			counts.Active++
+		case tipocav1.MaskReloading:
+			counts.Reloading++
+		case tipocav1.MaskRealtime:
+			counts.Realtime++
+		case tipocav1.MaskAwaitingApproval:
+			counts.AwaitingApproval++
+		case tipocav1.MaskRolledBack:
+			counts.RolledBack++
+		}
+	}
+
+	return counts
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateConditions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		topics             *tipocav1.TopicPhaseCounts
+		events             []ReconcilerEvent
+		err                error
+		conditions         map[string]metav1.ConditionStatus
+		readyReason        string
+		observedGeneration int64
+		failures           map[string]string
+		releasingReason    string
+	}{
+		{
+			name:   "test1: ready",
+			topics: &tipocav1.TopicPhaseCounts{Total: 3, Active: 3},
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:       metav1.ConditionTrue,
+				tipocav1.ConditionDegraded:    metav1.ConditionFalse,
+				tipocav1.ConditionReconciling: metav1.ConditionFalse,
+				tipocav1.ConditionReloading:   metav1.ConditionFalse,
+				tipocav1.ConditionReleasing:   metav1.ConditionFalse,
+			},
+			readyReason:        tipocav1.ReasonReconciled,
+			observedGeneration: 2,
+		},
+		{
+			name:   "test2: reloading and realtime topics",
+			topics: &tipocav1.TopicPhaseCounts{Total: 3, Active: 1, Reloading: 1, Realtime: 1},
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:       metav1.ConditionTrue,
+				tipocav1.ConditionReconciling: metav1.ConditionTrue,
+				tipocav1.ConditionReloading:   metav1.ConditionTrue,
+				tipocav1.ConditionReleasing:   metav1.ConditionTrue,
+			},
+			readyReason:        tipocav1.ReasonReconciled,
+			observedGeneration: 2,
+		},
+		{
+			name:   "test3: sink groups updated",
+			topics: &tipocav1.TopicPhaseCounts{Total: 3, Active: 3},
+			events: []ReconcilerEvent{DeploymentCreatedEvent{Name: "batcher"}},
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:       metav1.ConditionFalse,
+				tipocav1.ConditionReconciling: metav1.ConditionTrue,
+			},
+			readyReason:        tipocav1.ReasonProgressing,
+			observedGeneration: 2,
+		},
+		{
+			name:   "test4: topics not found",
+			topics: &tipocav1.TopicPhaseCounts{},
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:    metav1.ConditionFalse,
+				tipocav1.ConditionDegraded: metav1.ConditionFalse,
+			},
+			readyReason:        tipocav1.ReasonTopicsNotFound,
+			observedGeneration: 2,
+		},
+		{
+			name:   "test5: kafka unreachable",
+			topics: &tipocav1.TopicPhaseCounts{Total: 3, Active: 3},
+			err: degraded(
+				tipocav1.ReasonKafkaUnreachable, fmt.Errorf("dial timeout")),
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:    metav1.ConditionFalse,
+				tipocav1.ConditionDegraded: metav1.ConditionTrue,
+			},
+			readyReason:        tipocav1.ReasonKafkaUnreachable,
+			observedGeneration: 1,
+		},
+		{
+			name: "test6: unknown failure without topics",
+			err:  fmt.Errorf("failed"),
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:    metav1.ConditionFalse,
+				tipocav1.ConditionDegraded: metav1.ConditionTrue,
+			},
+			readyReason:        tipocav1.ReasonReconcileFailed,
+			observedGeneration: 1,
+		},
+		{
+			name:   "test7: realtime topics awaiting approval",
+			topics: &tipocav1.TopicPhaseCounts{Total: 3, Active: 2, AwaitingApproval: 1},
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:     metav1.ConditionTrue,
+				tipocav1.ConditionReleasing: metav1.ConditionFalse,
+			},
+			readyReason:        tipocav1.ReasonReconciled,
+			observedGeneration: 2,
+			releasingReason:    tipocav1.ReasonAwaitingApproval,
+		},
+		{
+			name:   "test8: release validation failed",
+			topics: &tipocav1.TopicPhaseCounts{Total: 3, Active: 2, Realtime: 1},
+			failures: map[string]string{
+				"db.inventory.orders": "topic: db.inventory.orders failed release validation",
+			},
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:     metav1.ConditionTrue,
+				tipocav1.ConditionReleasing: metav1.ConditionFalse,
+			},
+			readyReason:        tipocav1.ReasonReconciled,
+			observedGeneration: 2,
+			releasingReason:    tipocav1.ReasonValidationFailed,
+		},
+		{
+			name:   "test9: reconciled with invalid mask file",
+			topics: &tipocav1.TopicPhaseCounts{Total: 3, Active: 3},
+			err: degradedReconciled(
+				tipocav1.ReasonMaskInvalid, fmt.Errorf("lint errors")),
+			conditions: map[string]metav1.ConditionStatus{
+				tipocav1.ConditionReady:    metav1.ConditionTrue,
+				tipocav1.ConditionDegraded: metav1.ConditionTrue,
+			},
+			readyReason:        tipocav1.ReasonReconciled,
+			observedGeneration: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rsk := &tipocav1.RedshiftSink{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status: tipocav1.RedshiftSinkStatus{
+					ObservedGeneration:        1,
+					Topics:                    tc.topics,
+					ReleaseValidationFailures: tc.failures,
+				},
+			}
+			updateConditions(rsk, tc.events, tc.err)
+
+			for conditionType, status := range tc.conditions {
+				condition := meta.FindStatusCondition(
+					rsk.Status.Conditions, conditionType)
+				if condition == nil {
+					t.Errorf("condition: %s missing", conditionType)
+					continue
+				}
+				if condition.Status != status {
+					t.Errorf("condition: %s, expected: %v, got: %v",
+						conditionType, status, condition.Status)
+				}
+				if condition.ObservedGeneration != 2 {
+					t.Errorf("condition: %s, observedGeneration: %d",
+						conditionType, condition.ObservedGeneration)
+				}
+			}
+			ready := meta.FindStatusCondition(
+				rsk.Status.Conditions, tipocav1.ConditionReady)
+			if ready.Reason != tc.readyReason {
+				t.Errorf("expected reason: %v, got: %v",
+					tc.readyReason, ready.Reason)
+			}
+			if tc.releasingReason != "" {
+				releasing := meta.FindStatusCondition(
+					rsk.Status.Conditions, tipocav1.ConditionReleasing)
+				if releasing.Reason != tc.releasingReason {
+					t.Errorf("expected releasing reason: %v, got: %v",
+						tc.releasingReason, releasing.Reason)
+				}
+			}
+			if rsk.Status.ObservedGeneration != tc.observedGeneration {
+				t.Errorf("expected observedGeneration: %v, got: %v",
+					tc.observedGeneration, rsk.Status.ObservedGeneration)
+			}
+		})
+	}
+}
+
+func TestTopicPhaseCounts(t *testing.T) {
+	t.Parallel()
+
+	maskStatus := &tipocav1.MaskStatus{
+		CurrentMaskStatus: map[string]tipocav1.TopicMaskStatus{
+			"db.inventory.customers": {Phase: tipocav1.MaskActive},
+			"db.inventory.orders":    {Phase: tipocav1.MaskReloading},
+			"db.inventory.products":  {Phase: tipocav1.MaskReloading},
+			"db.inventory.users":     {Phase: tipocav1.MaskRealtime},
+			"db.inventory.items":     {Phase: tipocav1.MaskAwaitingApproval},
+			"db.inventory.invoices":  {Phase: tipocav1.MaskRolledBack},
+		},
+	}
+	expected := tipocav1.TopicPhaseCounts{
+		Total: 6, Active: 1, Reloading: 2, Realtime: 1, AwaitingApproval: 1,
+		RolledBack: 1,
+	}
+
+	counts := topicPhaseCounts(maskStatus)
+	if *counts != expected {
+		t.Errorf("expected: %+v, got: %+v", expected, *counts)
+	}
+}
+
+func TestMaskSourceUnreachableReason(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"https://github.com/practo/tipoca-stream/pkg/database.yaml": tipocav1.ReasonGitUnreachable,
+		"s3://bucket/inventory.yaml":                                tipocav1.ReasonMaskSourceUnreachable,
+		"configmap://masks/inventory.yaml":                          tipocav1.ReasonMaskSourceUnreachable,
+	}
+	for maskFile, expected := range tests {
+		got := maskSourceUnreachableReason(maskFile)
+		if got != expected {
+			t.Errorf("maskFile: %s, expected: %v, got: %v",
+				maskFile, expected, got)
+		}
+	}
+}
@@ -12,7 +12,6 @@ import (
 	yaml "gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	resource "k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -29,106 +28,14 @@ type Loader struct {
 }
 
 // applyLoaderSinkGroupDefaults applies the defaults for the loader
-// deployments of the sink group. User does not need to specify big lengthy
-// configurations everytime. Defaults are optimized for maximum performance
-// and are recommended to use.
+// deployments of the sink group, the default image is used when the image
+// is not specified.
 func applyLoaderSinkGroupDefaults(
 	rsk *tipocav1.RedshiftSink,
 	sgType string,
 	defaultImage string,
 ) *tipocav1.SinkGroupSpec {
-	var maxSizePerBatch *resource.Quantity
-	var maxWaitSeconds *int
-	var maxProcessingTime *int32
-	var image *string
-	var resources *corev1.ResourceRequirements
-	var tolerations *[]corev1.Toleration
-	var maxReloadingUnits *int32
-
-	// defaults by sinkgroup
-	switch sgType {
-	case MainSinkGroup:
-		maxSizePerBatch = toQuantityPtr(resource.MustParse("1Gi"))
-		maxWaitSeconds = toIntPtr(900)
-		maxProcessingTime = &redshiftloader.DefaultMaxProcessingTime
-		image = &defaultImage
-		maxReloadingUnits = toInt32Ptr(10)
-	case ReloadSinkGroup:
-		maxSizePerBatch = toQuantityPtr(resource.MustParse("1Gi"))
-		maxWaitSeconds = toIntPtr(60)
-		maxProcessingTime = &redshiftloader.DefaultMaxProcessingTime
-		image = &defaultImage
-		maxReloadingUnits = toInt32Ptr(10)
-	case ReloadDupeSinkGroup:
-		maxSizePerBatch = toQuantityPtr(resource.MustParse("1Gi"))
-		maxWaitSeconds = toIntPtr(900)
-		maxProcessingTime = &redshiftloader.DefaultMaxProcessingTime
-		image = &defaultImage
-		maxReloadingUnits = toInt32Ptr(10)
-	}
-
-	var specifiedSpec *tipocav1.SinkGroupSpec
-	// apply the sinkGroup spec rules
-	if rsk.Spec.Loader.SinkGroup.All != nil {
-		specifiedSpec = rsk.Spec.Loader.SinkGroup.All
-	}
-	switch sgType {
-	case MainSinkGroup:
-		if rsk.Spec.Loader.SinkGroup.Main != nil {
-			specifiedSpec = rsk.Spec.Loader.SinkGroup.Main
-		}
-	case ReloadSinkGroup:
-		if rsk.Spec.Loader.SinkGroup.Reload != nil {
-			specifiedSpec = rsk.Spec.Loader.SinkGroup.Reload
-		}
-	case ReloadDupeSinkGroup:
-		if rsk.Spec.Loader.SinkGroup.ReloadDupe != nil {
-			specifiedSpec = rsk.Spec.Loader.SinkGroup.ReloadDupe
-		}
-	}
-
-	// overwrite the defaults with the specified values
-	if specifiedSpec != nil {
-		if specifiedSpec.MaxSizePerBatch != nil {
-			maxSizePerBatch = specifiedSpec.MaxSizePerBatch
-		}
-		if specifiedSpec.MaxWaitSeconds != nil {
-			maxWaitSeconds = specifiedSpec.MaxWaitSeconds
-		}
-		if specifiedSpec.MaxProcessingTime != nil {
-			maxProcessingTime = specifiedSpec.MaxProcessingTime
-		}
-		if specifiedSpec.MaxReloadingUnits != nil {
-			maxReloadingUnits = specifiedSpec.MaxReloadingUnits
-		}
-		if specifiedSpec.DeploymentUnit != nil {
-			if specifiedSpec.DeploymentUnit.PodTemplate != nil {
-				if specifiedSpec.DeploymentUnit.PodTemplate.Image != nil {
-					image = specifiedSpec.DeploymentUnit.PodTemplate.Image
-				}
-				if specifiedSpec.DeploymentUnit.PodTemplate.Resources != nil {
-					resources = specifiedSpec.DeploymentUnit.PodTemplate.Resources
-				}
-				if specifiedSpec.DeploymentUnit.PodTemplate.Tolerations != nil {
-					tolerations = specifiedSpec.DeploymentUnit.PodTemplate.Tolerations
-				}
-			}
-		}
-	}
-
-	return &tipocav1.SinkGroupSpec{
-		MaxSizePerBatch:   maxSizePerBatch,
-		MaxWaitSeconds:    maxWaitSeconds,
-		MaxProcessingTime: maxProcessingTime,
-		MaxReloadingUnits: maxReloadingUnits,
-		DeploymentUnit: &tipocav1.DeploymentUnit{
-			PodTemplate: &tipocav1.RedshiftPodTemplateSpec{
-				Image:       image,
-				Resources:   resources,
-				Tolerations: tolerations,
-			},
-		},
-	}
+	return rsk.LoaderSinkGroupSpec(sgType, &defaultImage)
 }
 
 func loaderSecret(secret map[string]string) (map[string]string, error) {
@@ -209,6 +116,9 @@ func NewLoader(
 	Deployment,
 	error,
 ) {
+	// optional, the loaders notify the views they could not recreate
+	slackBotToken := secret["slackBotToken"]
+	slackChannelID := secret["slackChannelID"]
 	secret, err := loaderSecret(secret)
 	if err != nil {
 		return nil, err
@@ -224,16 +134,14 @@ func NewLoader(
 	var maxWaitSeconds *int
 	var maxProcessingTime int32 = redshiftloader.DefaultMaxProcessingTime
 	var image string
-	var resources *corev1.ResourceRequirements
-	var tolerations *[]corev1.Toleration
+	var podTemplate *tipocav1.RedshiftPodTemplateSpec
 	if sinkGroupSpec != nil {
 		m := sinkGroupSpec.MaxSizePerBatch.Value()
 		maxBytesPerBatch = &m
 		maxWaitSeconds = sinkGroupSpec.MaxWaitSeconds
 		maxProcessingTime = *sinkGroupSpec.MaxProcessingTime
 		image = *sinkGroupSpec.DeploymentUnit.PodTemplate.Image
-		resources = sinkGroupSpec.DeploymentUnit.PodTemplate.Resources
-		tolerations = sinkGroupSpec.DeploymentUnit.PodTemplate.Tolerations
+		podTemplate = sinkGroupSpec.DeploymentUnit.PodTemplate
 	} else { // Deprecated
 		maxSize = rsk.Spec.Loader.MaxSize
 		maxWaitSeconds = &rsk.Spec.Loader.MaxWaitSeconds
@@ -245,8 +153,7 @@ func NewLoader(
 		} else {
 			image = defaultImage
 		}
-		resources = rsk.Spec.Loader.PodTemplate.Resources
-		tolerations = rsk.Spec.Loader.PodTemplate.Tolerations
+		podTemplate = rsk.Spec.Loader.PodTemplate
 	}
 
 	// defaults which are not configurable for the user
@@ -290,6 +197,7 @@ func NewLoader(
 			MaxSize:          maxSize, // Deprecated
 			MaxWaitSeconds:   maxWaitSeconds,
 			MaxBytesPerBatch: maxBytesPerBatch,
+			Timezone:         timezoneConfig(rsk),
 		},
 		ConsumerGroups: groupConfigs,
 		S3Sink: s3sink.Config{
@@ -318,6 +226,8 @@ func NewLoader(
 		RedshiftMetrics: redshiftMetrics,
 		Rsk:             rsk.Name,
 		SinkGroup:       sinkGroup,
+		SlackBotToken:   slackBotToken,
+		SlackChannelID:  slackChannelID,
 	}
 	confBytes, err := yaml.Marshal(conf)
 	if err != nil {
@@ -355,16 +265,20 @@ func NewLoader(
 		namespace:   rsk.Namespace,
 		labels:      labels,
 		replicas:    &replicas,
-		resources:   resources,
-		tolerations: tolerations,
+		podTemplate: podTemplate,
 		image:       image,
 		args:        []string{"-v=2", "--config=/config.yaml"},
 	}
 
+	podTemplateHash, err := podTemplateHash(podTemplate, image)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Loader{
 		name:       objectName,
 		namespace:  rsk.Namespace,
-		deployment: deploymentFromSpec(deploySpec, configSpec),
+		deployment: deploymentFromSpec(deploySpec, configSpec, podTemplateHash),
 		config:     configFromSpec(configSpec),
 		topics:     topics,
 	}, nil
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	klog "github.com/practo/klog/v2"
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+)
+
+// sinkingReleased tells if the topic of the phase is sinking into the
+// released table and is not reloading.
+func sinkingReleased(phase tipocav1.MaskPhase) bool {
+	return phase == tipocav1.MaskActive || phase == tipocav1.MaskRolledBack
+}
+
+// manualReloadPending tells if the spec asks to reload the active topic
+// for a reload id it has not been reloaded for.
+func manualReloadPending(rsk *tipocav1.RedshiftSink, topic string) bool {
+	reloadID, ok := rsk.Spec.ReloadTopics[topic]
+	if !ok || reloadID == "" {
+		return false
+	}
+
+	topicStatus := currentTopicStatus(rsk, topic)
+	if topicStatus == nil || !sinkingReleased(topicStatus.Phase) {
+		return false
+	}
+
+	return topicStatus.ReloadID != reloadID
+}
+
+// manualReloadTopics returns the topics which are to be reloaded or are
+// reloading because of the ReloadTopics. They are considered as the topics
+// having a mask diff as the mask version does not change for them.
+func manualReloadTopics(rsk *tipocav1.RedshiftSink, topics []string) []string {
+	reloadTopics := []string{}
+	for _, topic := range topics {
+		if manualReloadPending(rsk, topic) {
+			reloadTopics = append(reloadTopics, topic)
+			continue
+		}
+		if topicReloadID(rsk, topic) != "" {
+			reloadTopics = append(reloadTopics, topic)
+		}
+	}
+	if len(reloadTopics) > 0 {
+		klog.V(2).Infof(
+			"rsk/%s manual reload topics: %v", rsk.Name, reloadTopics)
+	}
+
+	return reloadTopics
+}
+
+// topicReloadID returns the reload id of the topic being reloaded,
+// empty is returned if the topic is not reloading.
+func topicReloadID(rsk *tipocav1.RedshiftSink, topic string) string {
+	topicStatus := currentTopicStatus(rsk, topic)
+	if topicStatus == nil {
+		return ""
+	}
+
+	switch topicStatus.Phase {
+	case tipocav1.MaskReloading,
+		tipocav1.MaskRealtime,
+		tipocav1.MaskAwaitingApproval:
+		return topicStatus.ReloadID
+	}
+
+	return ""
+}
+
+// nextReloadID returns the reload id to record in the status of the
+// reloading topic, the id does not change till the topic gets released.
+func nextReloadID(rsk *tipocav1.RedshiftSink, topic string) string {
+	topicStatus := currentTopicStatus(rsk, topic)
+	if topicStatus != nil && !sinkingReleased(topicStatus.Phase) {
+		return topicStatus.ReloadID
+	}
+
+	return rsk.Spec.ReloadTopics[topic]
+}
+
+// reloadTopicGroup returns the consumer group of the reloading topic.
+// Manually reloaded topics get a consumer group and a loader topic
+// of their own for every reload id, as the mask version remains the same.
+func reloadTopicGroup(
+	rsk *tipocav1.RedshiftSink,
+	topic string,
+	version string,
+	prefix string,
+) tipocav1.Group {
+	groupID := groupIDFromTopicVersion(topic, version)
+	loaderPrefix := loaderPrefixFromVersion(prefix, version)
+
+	reloadID := topicReloadID(rsk, topic)
+	if reloadID != "" {
+		// hashing a string does not fail
+		hash, _ := getHashStructure(reloadID)
+		groupID = groupID + "-r" + hash
+		loaderPrefix = loaderPrefix + "r" + hash + "-"
+	}
+
+	return tipocav1.Group{
+		ID:                groupID,
+		LoaderTopicPrefix: loaderPrefix,
+	}
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+)
+
+func TestManualReload(t *testing.T) {
+	t.Parallel()
+
+	version := "6c557136b5f0ed1ee0e9cc1a1cfa1a2c5e45f1d0"
+	topics := []string{
+		"db.inventory.customers",
+		"db.inventory.orders",
+	}
+	activeStatus := func(reloadID string) tipocav1.TopicMaskStatus {
+		return tipocav1.TopicMaskStatus{
+			Version:         version,
+			Phase:           tipocav1.MaskActive,
+			ReleasedVersion: &version,
+			ReloadID:        reloadID,
+		}
+	}
+	rsk := &tipocav1.RedshiftSink{
+		Spec: tipocav1.RedshiftSinkSpec{
+			KafkaLoaderTopicPrefix: "loader-",
+			ReloadTopics: map[string]string{
+				"db.inventory.orders": "incident-1",
+			},
+		},
+		Status: tipocav1.RedshiftSinkStatus{
+			MaskStatus: &tipocav1.MaskStatus{
+				CurrentMaskVersion: &version,
+				DesiredMaskVersion: &version,
+				CurrentMaskStatus: map[string]tipocav1.TopicMaskStatus{
+					"db.inventory.customers": activeStatus(""),
+					"db.inventory.orders":    activeStatus(""),
+				},
+			},
+		},
+	}
+
+	buildStatus := func() *status {
+		diffTopics := manualReloadTopics(rsk, topics)
+		return newStatusBuilder().
+			setRedshiftSink(rsk).
+			setCurrentVersion(version).
+			setDesiredVersion(version).
+			setIncludeTables(topics).
+			setAllTopics(topics).
+			setDiffTopics(diffTopics).
+			computeReleased().
+			setRealtime().
+			computeReloading().
+			computeReloadingDupe().
+			build()
+	}
+	mainGroup := reloadTopicGroup(
+		rsk, "db.inventory.orders", version, "loader-")
+
+	// the topic asked in the spec starts reloading
+	s := buildStatus()
+	expected := []string{"db.inventory.orders"}
+	if !reflect.DeepEqual(s.reloading, expected) ||
+		!reflect.DeepEqual(s.reloadingDupe, expected) {
+		t.Fatalf("expected reloading: %v, got: %v, dupe: %v",
+			expected, s.reloading, s.reloadingDupe)
+	}
+	if !reflect.DeepEqual(s.released, []string{"db.inventory.customers"}) {
+		t.Errorf("unexpected released: %v", s.released)
+	}
+	orders := rsk.Status.MaskStatus.CurrentMaskStatus["db.inventory.orders"]
+	if orders.Phase != tipocav1.MaskReloading || orders.ReloadID != "incident-1" {
+		t.Errorf("unexpected status: %+v", orders)
+	}
+
+	// the reload uses a fresh consumer group and loader topic
+	reloadGroup := reloadTopicGroup(
+		rsk, "db.inventory.orders", version, "loader-")
+	if reloadGroup.ID == mainGroup.ID ||
+		reloadGroup.LoaderTopicPrefix == mainGroup.LoaderTopicPrefix {
+		t.Errorf("expected fresh group, got: %+v, main: %+v",
+			reloadGroup, mainGroup)
+	}
+
+	// the reload continues in the next reconcile
+	s = buildStatus()
+	if !reflect.DeepEqual(s.reloading, expected) {
+		t.Fatalf("expected reloading: %v, got: %v", expected, s.reloading)
+	}
+
+	// the topic gets released once realtime
+	s.realtime = expected
+	s.updateMaskStatus()
+	s.updateTopicsOnRelease("db.inventory.orders")
+	s.updateTopicGroup("db.inventory.orders")
+	s.updateMaskStatus()
+	if rsk.Status.TopicGroup["db.inventory.orders"].ID != reloadGroup.ID {
+		t.Errorf("expected released group: %v, got: %+v",
+			reloadGroup.ID, rsk.Status.TopicGroup["db.inventory.orders"])
+	}
+	orders = rsk.Status.MaskStatus.CurrentMaskStatus["db.inventory.orders"]
+	if !reflect.DeepEqual(orders, activeStatus("incident-1")) {
+		t.Errorf("unexpected status: %+v", orders)
+	}
+
+	// released topic is not reloaded again for the same reload id
+	s = buildStatus()
+	if len(s.reloading) != 0 || len(s.released) != 2 {
+		t.Errorf("unexpected reloading: %v, released: %v",
+			s.reloading, s.released)
+	}
+
+	// changing the reload id reloads it again
+	rsk.Spec.ReloadTopics["db.inventory.orders"] = "incident-2"
+	s = buildStatus()
+	if !reflect.DeepEqual(s.reloading, expected) {
+		t.Errorf("expected reloading: %v, got: %v", expected, s.reloading)
+	}
+}
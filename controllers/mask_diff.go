@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"fmt"
 	klog "github.com/practo/klog/v2"
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	masksource "github.com/practo/tipoca-stream/pkg/masksource"
 	transformer "github.com/practo/tipoca-stream/pkg/transformer"
 	masker "github.com/practo/tipoca-stream/pkg/transformer/masker"
 	"os"
@@ -13,14 +16,17 @@ import (
 // returns the list of topics whose mask values has changed.
 // returns the updated list of kafka topics
 // return the list of include tables based on desired mask config
+// A new desired version is linted first and is not rolled out on errors,
+// maskLintError is returned for it.
 func MaskDiff(
 	topics []string,
 	maskFile string,
+	maskSource masksource.Source,
 	desiredVersion string,
 	currentVersion string,
-	gitToken string,
 	kafkaTopicsCache *sync.Map,
 	includeTablesCache *sync.Map,
+	maskLintCache *sync.Map,
 ) (
 	[]string,
 	[]string,
@@ -53,27 +59,35 @@ func MaskDiff(
 	maskDir := filepath.Join(currentDir, "maskdiff")
 	os.Mkdir(maskDir, 0755)
 
-	desiredMaskConfig, err := masker.NewMaskConfig(
-		maskDir, maskFile, desiredVersion, gitToken)
+	if currentVersion != desiredVersion {
+		err = lintMaskFile(
+			maskDir, maskFile, maskSource, desiredVersion, topics, maskLintCache)
+		if err != nil {
+			return []string{}, topics, includeTables, err
+		}
+	}
+
+	desiredMaskConfig, err := masker.NewMaskConfigFromSource(
+		maskDir, maskSource, desiredVersion)
 	if err != nil {
 		return []string{}, topics, includeTables, err
 	}
 
-	var includedTabesMap map[string]bool
 	if desiredMaskConfig.IncludeTables != nil {
-		includedTabesMap = toMap(*desiredMaskConfig.IncludeTables)
 		// shrink the total topics by include tables specification
 		shrinkedTopics := []string{}
+		tables := []string{}
 		for _, topic := range topics {
 			_, _, table := transformer.ParseTopic(topic)
-			_, ok := includedTabesMap[table]
-			if ok {
+			if desiredMaskConfig.IncludeTable(table) {
 				shrinkedTopics = append(shrinkedTopics, topic)
+				tables = append(tables, table)
 			}
 		}
 		topics = shrinkedTopics
 		kafkaTopicsCache.Store(cacheKey, topics)
-		includeTables = *desiredMaskConfig.IncludeTables
+		// include tables can be patterns, expand them to the tables
+		includeTables = desiredMaskConfig.ExpandIncludeTables(tables)
 		includeTablesCache.Store(cacheKey, includeTables)
 	}
 
@@ -81,8 +95,8 @@ func MaskDiff(
 		return topics, topics, includeTables, nil
 	}
 
-	currentMaskConfig, err := masker.NewMaskConfig(
-		maskDir, maskFile, currentVersion, gitToken)
+	currentMaskConfig, err := masker.NewMaskConfigFromSource(
+		maskDir, maskSource, currentVersion)
 	if err != nil {
 		return []string{}, topics, includeTables, err
 	}
@@ -94,26 +108,16 @@ func MaskDiff(
 		return []string{}, topics, includeTables, nil
 	}
 
-	if desiredMaskConfig.IncludeTables != nil {
-		// ignore the tables which are not part of include tables
-		newTablesModified := make(map[string]bool)
-		for table, _ := range tablesModified {
-			_, ok := includedTabesMap[table]
-			if !ok {
-				klog.Warningf("Excluding table: %v", table)
-			} else {
-				newTablesModified[table] = true
-			}
-		}
-		tablesModified = newTablesModified
-	}
-
+	// modified tables can be patterns, topics are already shrinked
+	// by the include tables
 	modifiedTopics := []string{}
 	for _, topic := range topics {
 		_, _, table := transformer.ParseTopic(topic)
-		_, ok := tablesModified[table]
-		if ok {
-			modifiedTopics = append(modifiedTopics, topic)
+		for modifiedTable := range tablesModified {
+			if masker.MatchKey(modifiedTable, table) {
+				modifiedTopics = append(modifiedTopics, topic)
+				break
+			}
 		}
 	}
 
@@ -126,3 +130,82 @@ func MaskDiff(
 
 	return modifiedTopics, topics, includeTables, nil
 }
+
+// maskLintError is returned by MaskDiff when the desired version has
+// lint errors, the version is not rolled out.
+type maskLintError struct {
+	maskFile string
+	version  string
+}
+
+func (e *maskLintError) Error() string {
+	return fmt.Sprintf(
+		"maskFile: %s, version: %s has lint errors, not rolling out",
+		e.maskFile, e.version)
+}
+
+// lintMaskFile lints the mask file version against the tables of the
+// topics, a version is linted only once. The versions with lint errors
+// are cached as well so that they are not downloaded and linted again.
+func lintMaskFile(
+	maskDir string,
+	maskFile string,
+	maskSource masksource.Source,
+	version string,
+	topics []string,
+	maskLintCache *sync.Map,
+) error {
+	cacheKey := maskFile + version
+	lintErr, ok := maskLintCache.Load(cacheKey)
+	if ok {
+		if lintErr == nil {
+			return nil
+		}
+		return lintErr.(error)
+	}
+
+	tables := []string{}
+	for _, topic := range topics {
+		_, _, table := transformer.ParseTopic(topic)
+		tables = append(tables, table)
+	}
+
+	problems, err := masker.LintMaskSource(
+		maskDir, maskSource, version, tables)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		klog.Warningf("maskFile: %s, version: %s, %v", maskFile, version, problem)
+	}
+	if masker.HasLintErrors(problems) {
+		err := &maskLintError{maskFile: maskFile, version: version}
+		maskLintCache.Store(cacheKey, err)
+		return err
+	}
+	maskLintCache.Store(cacheKey, nil)
+
+	return nil
+}
+
+// lastDesiredMaskVersion returns the desired mask version in the status,
+// it is the last version which passed the lint and is being rolled out.
+// The current version is returned when there is none or it is the
+// invalid file version.
+func lastDesiredMaskVersion(
+	rsk *tipocav1.RedshiftSink,
+	currentVersion string,
+	invalidFileVersion string,
+) string {
+	if rsk.Status.MaskStatus == nil ||
+		rsk.Status.MaskStatus.DesiredMaskVersion == nil {
+		return currentVersion
+	}
+	desiredVersion := *rsk.Status.MaskStatus.DesiredMaskVersion
+	desiredFileVersion, _ := splitMaskVersion(desiredVersion)
+	if desiredFileVersion == "" || desiredFileVersion == invalidFileVersion {
+		return currentVersion
+	}
+
+	return desiredVersion
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+)
+
+// fakeMaskSource serves the mask file content and counts the downloads
+type fakeMaskSource struct {
+	content   string
+	downloads int
+}
+
+func (s *fakeMaskSource) Version() (string, error) {
+	return "v1", nil
+}
+
+func (s *fakeMaskSource) Download(dir string, version string) (string, error) {
+	s.downloads++
+	path := filepath.Join(dir, "database.yaml")
+
+	return path, ioutil.WriteFile(path, []byte(s.content), 0644)
+}
+
+func (s *fakeMaskSource) FileURL(version string) string {
+	return ""
+}
+
+func (s *fakeMaskSource) DiffURL(from string, to string) string {
+	return ""
+}
+
+func TestLintMaskFileCachesErrors(t *testing.T) {
+	t.Parallel()
+
+	source := &fakeMaskSource{content: "non_pi_keys:\n  customers:\n    - id\n"}
+	cache := &sync.Map{}
+	topics := []string{"db.inventory.customers"}
+
+	for i := 0; i < 2; i++ {
+		err := lintMaskFile(
+			t.TempDir(), "mask.yaml", source, "v1", topics, cache)
+		var lintErr *maskLintError
+		if !errors.As(err, &lintErr) {
+			t.Fatalf("expected lint error, got: %v", err)
+		}
+	}
+	if source.downloads != 1 {
+		t.Errorf("expected the version linted once, downloads: %d",
+			source.downloads)
+	}
+}
+
+func TestLastDesiredMaskVersion(t *testing.T) {
+	t.Parallel()
+
+	current := "a1b2c3-v1"
+	desired := "d4e5f6-v1"
+	tests := []struct {
+		name       string
+		maskStatus *tipocav1.MaskStatus
+		invalid    string
+		expected   string
+	}{
+		{
+			name:     "test1: no status",
+			invalid:  "f7a8b9",
+			expected: current,
+		},
+		{
+			name:       "test2: desired being rolled out",
+			maskStatus: &tipocav1.MaskStatus{DesiredMaskVersion: &desired},
+			invalid:    "f7a8b9",
+			expected:   desired,
+		},
+		{
+			name:       "test3: desired is the invalid version",
+			maskStatus: &tipocav1.MaskStatus{DesiredMaskVersion: &desired},
+			invalid:    "d4e5f6",
+			expected:   current,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rsk := &tipocav1.RedshiftSink{
+				Status: tipocav1.RedshiftSinkStatus{MaskStatus: tc.maskStatus},
+			}
+			got := lastDesiredMaskVersion(rsk, current, tc.invalid)
+			if got != tc.expected {
+				t.Errorf("expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
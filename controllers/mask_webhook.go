@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	klog "github.com/practo/klog/v2"
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	masksource "github.com/practo/tipoca-stream/pkg/masksource"
+	client "sigs.k8s.io/controller-runtime/pkg/client"
+	event "sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// MaskWebhook receives the push webhooks of the git repositories keeping
+// the mask files. It invalidates the cached versions of the mask files
+// changed by the push and reconciles their RedshiftSinks, so that the
+// mask changes are picked without waiting for the next poll.
+type MaskWebhook struct {
+	Client          client.Client
+	Secret          string
+	MaskSourceCache *sync.Map
+
+	// Events is watched by the RedshiftSinkReconciler
+	Events chan<- event.GenericEvent
+}
+
+func (w *MaskWebhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	push, err := masksource.ParseWebhook(req, w.Secret)
+	if err != nil {
+		klog.Warningf("Mask webhook rejected, err: %v", err)
+		if errors.Is(err, masksource.ErrWebhookSignature) {
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if push == nil {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), time.Second*30)
+	defer cancel()
+	rsks, err := w.touched(ctx, push)
+	if err != nil {
+		klog.Errorf("Mask webhook failed, err: %v", err)
+		http.Error(rw, "failed listing redshiftsinks", http.StatusInternalServerError)
+		return
+	}
+	klog.V(2).Infof(
+		"Mask webhook push to %s/%s touched %d rsks",
+		push.Host, push.Repo, len(rsks))
+
+	for i := range rsks {
+		w.Events <- event.GenericEvent{Object: &rsks[i]}
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// touched returns the RedshiftSinks whose mask file was changed by the push
+// after invalidating the cached versions of their mask files.
+func (w *MaskWebhook) touched(
+	ctx context.Context,
+	push *masksource.Push,
+) (
+	[]tipocav1.RedshiftSink,
+	error,
+) {
+	var list tipocav1.RedshiftSinkList
+	err := w.Client.List(ctx, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	var rsks []tipocav1.RedshiftSink
+	for _, rsk := range list.Items {
+		if !rsk.Spec.Batcher.Mask ||
+			!masksource.Touched(rsk.Spec.Batcher.MaskFile, push) {
+			continue
+		}
+		sourceLoaded, ok := w.MaskSourceCache.Load(maskSourceCacheKey(&rsk))
+		if ok {
+			invalidater, ok := sourceLoaded.(cachedMaskSource).source.(masksource.Invalidater)
+			if ok {
+				invalidater.Invalidate()
+			}
+		}
+		klog.V(2).Infof("rsk/%s mask file changed by push", rsk.Name)
+		rsks = append(rsks, rsk)
+	}
+
+	return rsks, nil
+}
@@ -46,32 +46,107 @@ func newRealtimeCalculator(
 	}
 }
 
-func (r *realtimeCalculator) maxLag(topic string) (int64, int64) {
-	var maxBatcherLag, maxLoaderLag int64
+// lagCondition is the maximum lag a consumer group can have to be
+// considered realtime, in messages and in seconds. The nil ones are
+// not checked.
+type lagCondition struct {
+	maxLag        *int64
+	maxLagSeconds *int64
+}
+
+// newLagCondition returns the lag condition, the lag in messages is
+// checked when the lag in seconds is not specified.
+func newLagCondition(maxLag, maxLagSeconds *int64) lagCondition {
+	if maxLag == nil && maxLagSeconds == nil {
+		var zero int64
+		maxLag = &zero
+	}
+
+	return lagCondition{maxLag: maxLag, maxLagSeconds: maxLagSeconds}
+}
+
+// realtime tells if the position is within the lag condition. The age of
+// the oldest unprocessed message is used for the lag in seconds, it is
+// not realtime when it is not known.
+func (c lagCondition) realtime(position *offsetPosition, now time.Time) bool {
+	if position == nil || position.last == nil || position.current == nil {
+		return false
+	}
+
+	lag := *position.last - *position.current
+	if c.maxLag != nil && lag > *c.maxLag {
+		return false
+	}
+	if c.maxLagSeconds == nil || lag <= 0 {
+		return true
+	}
+	if position.currentTimestamp == nil {
+		return false
+	}
+
+	return now.Sub(*position.currentTimestamp) <=
+		time.Duration(*c.maxLagSeconds)*time.Second
+}
+
+// lagConditions returns the batcher and the loader lag conditions of the
+// topic, TopicReleaseCondition overrides the ReleaseCondition.
+func (r *realtimeCalculator) lagConditions(topic string) (
+	lagCondition, lagCondition,
+) {
 	if r.rsk.Spec.ReleaseCondition == nil {
-		maxBatcherLag = DefaultMaxBatcherLag
-		maxLoaderLag = DefautMaxLoaderLag
-	} else {
-		if r.rsk.Spec.ReleaseCondition.MaxBatcherLag != nil {
-			maxBatcherLag = *r.rsk.Spec.ReleaseCondition.MaxBatcherLag
-		}
-		if r.rsk.Spec.ReleaseCondition.MaxLoaderLag != nil {
-			maxLoaderLag = *r.rsk.Spec.ReleaseCondition.MaxLoaderLag
-		}
-		if r.rsk.Spec.TopicReleaseCondition != nil {
-			d, ok := r.rsk.Spec.TopicReleaseCondition[topic]
-			if ok {
-				if d.MaxBatcherLag != nil {
-					maxBatcherLag = *d.MaxBatcherLag
-				}
-				if d.MaxLoaderLag != nil {
-					maxLoaderLag = *d.MaxLoaderLag
-				}
+		return newLagCondition(toInt64Ptr(DefaultMaxBatcherLag), nil),
+			newLagCondition(toInt64Ptr(DefautMaxLoaderLag), nil)
+	}
+
+	condition := *r.rsk.Spec.ReleaseCondition
+	if r.rsk.Spec.TopicReleaseCondition != nil {
+		d, ok := r.rsk.Spec.TopicReleaseCondition[topic]
+		if ok {
+			if d.MaxBatcherLag != nil {
+				condition.MaxBatcherLag = d.MaxBatcherLag
+			}
+			if d.MaxLoaderLag != nil {
+				condition.MaxLoaderLag = d.MaxLoaderLag
+			}
+			if d.MaxBatcherLagSeconds != nil {
+				condition.MaxBatcherLagSeconds = d.MaxBatcherLagSeconds
+			}
+			if d.MaxLoaderLagSeconds != nil {
+				condition.MaxLoaderLagSeconds = d.MaxLoaderLagSeconds
 			}
 		}
 	}
 
-	return maxBatcherLag, maxLoaderLag
+	return newLagCondition(
+			condition.MaxBatcherLag, condition.MaxBatcherLagSeconds),
+		newLagCondition(
+			condition.MaxLoaderLag, condition.MaxLoaderLagSeconds)
+}
+
+// fetchCurrentTimestamp fetches the timestamp of the oldest message not
+// processed by the consumer group when the lag in seconds is checked
+func (r *realtimeCalculator) fetchCurrentTimestamp(
+	topic string,
+	position *offsetPosition,
+	condition lagCondition,
+) error {
+	if condition.maxLagSeconds == nil ||
+		position.last == nil ||
+		position.current == nil ||
+		*position.current >= *position.last {
+		return nil
+	}
+
+	timestamp, err := r.kafkaClient.OffsetTimestamp(
+		topic, 0, *position.current)
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("rsk/%s %s, currentTimestamp=%v",
+		r.rsk.Name, topic, timestamp)
+	position.currentTimestamp = timestamp
+
+	return nil
 }
 
 // fetchRealtimeCache tires to get the topicRealtimeInfo from cache
@@ -112,6 +187,9 @@ func (r *realtimeCalculator) fetchRealtimeCache(
 type offsetPosition struct {
 	last    *int64
 	current *int64
+	// currentTimestamp is the timestamp of the message at the current
+	// offset, fetched only when the lag in seconds is checked
+	currentTimestamp *time.Time
 }
 
 type topicRealtimeInfo struct {
@@ -122,7 +200,8 @@ type topicRealtimeInfo struct {
 	loaderRealtime  bool
 }
 
-// fetchRealtimeInfo fetches the offset info for the topic
+// fetchRealtimeInfo fetches the offset info for the topic and the
+// timestamps at the current offsets for the lag in seconds checks
 func (r *realtimeCalculator) fetchRealtimeInfo(
 	topic string,
 	loaderTopic *string,
@@ -130,6 +209,34 @@ func (r *realtimeCalculator) fetchRealtimeInfo(
 	groupLoaderCurrentOffset *int64,
 ) (
 	topicRealtimeInfo, error,
+) {
+	info, err := r.fetchOffsetInfo(
+		topic, loaderTopic, desiredGroupID, groupLoaderCurrentOffset)
+	if err != nil {
+		return info, err
+	}
+
+	batcherCondition, loaderCondition := r.lagConditions(topic)
+	err = r.fetchCurrentTimestamp(topic, info.batcher, batcherCondition)
+	if err != nil {
+		return info, err
+	}
+	if loaderTopic == nil {
+		return info, nil
+	}
+
+	return info, r.fetchCurrentTimestamp(
+		*loaderTopic, info.loader, loaderCondition)
+}
+
+// fetchOffsetInfo fetches the offset info for the topic
+func (r *realtimeCalculator) fetchOffsetInfo(
+	topic string,
+	loaderTopic *string,
+	desiredGroupID string,
+	groupLoaderCurrentOffset *int64,
+) (
+	topicRealtimeInfo, error,
 ) {
 	klog.V(4).Infof("rsk/%s (fetching realtime) topic: %s", r.rsk.Name, topic)
 
@@ -232,13 +339,15 @@ func (r *realtimeCalculator) calculate(reloading []string, currentRealtime []str
 
 	for _, topic := range reloading {
 		// extract or compute consumer group info
-		reloadGroupID := groupIDFromTopicVersion(topic, r.desiredVersion)
+		reloadGroup := reloadTopicGroup(
+			r.rsk, topic, r.desiredVersion, r.rsk.Spec.KafkaLoaderTopicPrefix)
+		reloadGroupID := reloadGroup.ID
 		loaderCurrentOffset := loaderTopicGroupCurrentOffset(r.rsk, topic, reloadGroupID)
 
 		var loaderTopic *string
 		ltopic := fmt.Sprintf(
 			"%s%s",
-			loaderPrefixFromVersion(r.rsk.Spec.KafkaLoaderTopicPrefix, r.desiredVersion),
+			reloadGroup.LoaderTopicPrefix,
 			topic,
 		)
 		_, ok := allTopicsMap[ltopic]
@@ -282,12 +391,12 @@ func (r *realtimeCalculator) calculate(reloading []string, currentRealtime []str
 		}
 
 		// compute realtime
-		maxBatcherLag, maxLoaderLag := r.maxLag(topic)
+		batcherCondition, loaderCondition := r.lagConditions(topic)
 		if info.batcher != nil && info.batcher.last != nil {
 			if info.batcher.current != nil {
 				lag := *info.batcher.last - *info.batcher.current
 				klog.V(4).Infof("rsk/%s: %s lag=%v", r.rsk.Name, topic, lag)
-				if lag <= maxBatcherLag {
+				if batcherCondition.realtime(info.batcher, time.Now()) {
 					klog.V(4).Infof("rsk/%s: %s batcher realtime", r.rsk.Name, topic)
 					info.batcherRealtime = true
 					r.batchersRealtime = append(r.batchersRealtime, topic)
@@ -305,7 +414,7 @@ func (r *realtimeCalculator) calculate(reloading []string, currentRealtime []str
 			if info.loader.current != nil {
 				lag := *info.loader.last - *info.loader.current
 				klog.V(4).Infof("rsk/%s: %s lag=%v", r.rsk.Name, ltopic, lag)
-				if lag <= maxLoaderLag {
+				if loaderCondition.realtime(info.loader, time.Now()) {
 					klog.V(4).Infof("rsk/%s: %s loader realtime", r.rsk.Name, ltopic)
 					info.loaderRealtime = true
 					r.loadersRealtime = append(r.loadersRealtime, ltopic)
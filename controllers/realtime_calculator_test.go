@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeKafkaClient has the same offsets for all the topics and groups,
+// it counts the timestamp fetches
+type fakeKafkaClient struct {
+	last       int64
+	current    int64
+	timestamps int
+}
+
+func (c *fakeKafkaClient) Topics() ([]string, error) {
+	return []string{}, nil
+}
+
+func (c *fakeKafkaClient) LastOffset(topic string, partition int32) (int64, error) {
+	return c.last, nil
+}
+
+func (c *fakeKafkaClient) CurrentOffset(
+	id string, topic string, partition int32) (int64, error) {
+	return c.current, nil
+}
+
+func (c *fakeKafkaClient) OffsetTimestamp(
+	topic string, partition int32, offset int64) (*time.Time, error) {
+	c.timestamps++
+	timestamp := time.Now()
+	return &timestamp, nil
+}
+
+func (c *fakeKafkaClient) ListConsumerGroups() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (c *fakeKafkaClient) DeleteConsumerGroup(name string) error {
+	return nil
+}
+
+func TestLagConditionRealtime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	secondsAgo := func(s int) *time.Time {
+		timestamp := now.Add(-time.Duration(s) * time.Second)
+		return &timestamp
+	}
+
+	tests := []struct {
+		name      string
+		condition lagCondition
+		position  *offsetPosition
+		realtime  bool
+	}{
+		{
+			name:      "test1: within lag",
+			condition: newLagCondition(toInt64Ptr(100), nil),
+			position: &offsetPosition{
+				last: toInt64Ptr(1100), current: toInt64Ptr(1000),
+			},
+			realtime: true,
+		},
+		{
+			name:      "test2: beyond lag",
+			condition: newLagCondition(toInt64Ptr(100), nil),
+			position: &offsetPosition{
+				last: toInt64Ptr(1101), current: toInt64Ptr(1000),
+			},
+			realtime: false,
+		},
+		{
+			name:      "test3: no condition is zero lag",
+			condition: newLagCondition(nil, nil),
+			position: &offsetPosition{
+				last: toInt64Ptr(1001), current: toInt64Ptr(1000),
+			},
+			realtime: false,
+		},
+		{
+			name:      "test4: within lag seconds, lag not checked",
+			condition: newLagCondition(nil, toInt64Ptr(60)),
+			position: &offsetPosition{
+				last:             toInt64Ptr(90000),
+				current:          toInt64Ptr(1000),
+				currentTimestamp: secondsAgo(60),
+			},
+			realtime: true,
+		},
+		{
+			name:      "test5: beyond lag seconds",
+			condition: newLagCondition(nil, toInt64Ptr(60)),
+			position: &offsetPosition{
+				last:             toInt64Ptr(1001),
+				current:          toInt64Ptr(1000),
+				currentTimestamp: secondsAgo(61),
+			},
+			realtime: false,
+		},
+		{
+			name:      "test6: no lag, timestamp not needed",
+			condition: newLagCondition(nil, toInt64Ptr(60)),
+			position: &offsetPosition{
+				last: toInt64Ptr(1000), current: toInt64Ptr(1000),
+			},
+			realtime: true,
+		},
+		{
+			name:      "test7: unknown timestamp",
+			condition: newLagCondition(nil, toInt64Ptr(60)),
+			position: &offsetPosition{
+				last: toInt64Ptr(1001), current: toInt64Ptr(1000),
+			},
+			realtime: false,
+		},
+		{
+			name:      "test8: both conditions, beyond lag",
+			condition: newLagCondition(toInt64Ptr(10), toInt64Ptr(60)),
+			position: &offsetPosition{
+				last:             toInt64Ptr(1011),
+				current:          toInt64Ptr(1000),
+				currentTimestamp: secondsAgo(1),
+			},
+			realtime: false,
+		},
+		{
+			name:      "test9: both conditions met",
+			condition: newLagCondition(toInt64Ptr(10), toInt64Ptr(60)),
+			position: &offsetPosition{
+				last:             toInt64Ptr(1010),
+				current:          toInt64Ptr(1000),
+				currentTimestamp: secondsAgo(1),
+			},
+			realtime: true,
+		},
+		{
+			name:      "test10: consumer group not found",
+			condition: newLagCondition(toInt64Ptr(10), nil),
+			position:  &offsetPosition{last: toInt64Ptr(1010)},
+			realtime:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			realtime := tc.condition.realtime(tc.position, now)
+			if realtime != tc.realtime {
+				t.Errorf("expected: %v, got: %v", tc.realtime, realtime)
+			}
+		})
+	}
+}
+
+func TestLagConditions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		spec    tipocav1.RedshiftSinkSpec
+		batcher lagCondition
+		loader  lagCondition
+	}{
+		{
+			name: "test1: defaults",
+			batcher: lagCondition{
+				maxLag: toInt64Ptr(DefaultMaxBatcherLag),
+			},
+			loader: lagCondition{
+				maxLag: toInt64Ptr(DefautMaxLoaderLag),
+			},
+		},
+		{
+			name: "test2: lag seconds only",
+			spec: tipocav1.RedshiftSinkSpec{
+				ReleaseCondition: &tipocav1.ReleaseCondition{
+					MaxBatcherLagSeconds: toInt64Ptr(60),
+					MaxLoaderLag:         toInt64Ptr(5),
+				},
+			},
+			batcher: lagCondition{maxLagSeconds: toInt64Ptr(60)},
+			loader:  lagCondition{maxLag: toInt64Ptr(5)},
+		},
+		{
+			name: "test3: topic condition overrides",
+			spec: tipocav1.RedshiftSinkSpec{
+				ReleaseCondition: &tipocav1.ReleaseCondition{
+					MaxBatcherLag:       toInt64Ptr(100),
+					MaxLoaderLagSeconds: toInt64Ptr(300),
+				},
+				TopicReleaseCondition: map[string]tipocav1.ReleaseCondition{
+					"db.inventory.orders": {
+						MaxBatcherLagSeconds: toInt64Ptr(30),
+						MaxLoaderLagSeconds:  toInt64Ptr(600),
+					},
+				},
+			},
+			batcher: lagCondition{
+				maxLag:        toInt64Ptr(100),
+				maxLagSeconds: toInt64Ptr(30),
+			},
+			loader: lagCondition{maxLagSeconds: toInt64Ptr(600)},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			calc := &realtimeCalculator{
+				rsk: &tipocav1.RedshiftSink{Spec: tc.spec},
+			}
+			batcher, loader := calc.lagConditions("db.inventory.orders")
+			if !reflect.DeepEqual(batcher, tc.batcher) {
+				t.Errorf("batcher, expected: %+v, got: %+v", tc.batcher, batcher)
+			}
+			if !reflect.DeepEqual(loader, tc.loader) {
+				t.Errorf("loader, expected: %+v, got: %+v", tc.loader, loader)
+			}
+		})
+	}
+}
+
+func TestFetchOffsetInfoSkipsTimestamps(t *testing.T) {
+	t.Parallel()
+
+	rsk := &tipocav1.RedshiftSink{
+		ObjectMeta: metav1.ObjectMeta{Name: "rsk", Namespace: "ns"},
+		Spec: tipocav1.RedshiftSinkSpec{
+			ReleaseCondition: &tipocav1.ReleaseCondition{
+				MaxBatcherLagSeconds: toInt64Ptr(60),
+				MaxLoaderLagSeconds:  toInt64Ptr(60),
+			},
+		},
+	}
+	client := &fakeKafkaClient{last: 100, current: 90}
+	calc := newRealtimeCalculator(rsk, client, nil, "")
+	loaderTopic := "loader-db.inventory.orders"
+
+	info, err := calc.fetchOffsetInfo(
+		"db.inventory.orders", &loaderTopic, "orders", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.timestamps != 0 || info.batcher.currentTimestamp != nil {
+		t.Errorf("expected no timestamps fetched, got: %d", client.timestamps)
+	}
+	if *info.loader.last-*info.loader.current != 10 {
+		t.Errorf("expected loader lag: 10, got: %d",
+			*info.loader.last-*info.loader.current)
+	}
+
+	info, err = calc.fetchRealtimeInfo(
+		"db.inventory.orders", &loaderTopic, "orders", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.timestamps != 2 || info.loader.currentTimestamp == nil {
+		t.Errorf("expected batcher and loader timestamps, got: %d",
+			client.timestamps)
+	}
+}
@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	"crypto/sha1"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -29,8 +30,8 @@ import (
 	logr "github.com/go-logr/logr"
 	klog "github.com/practo/klog/v2"
 	tipocav1 "github.com/practo/tipoca-stream/api/v1"
-	git "github.com/practo/tipoca-stream/pkg/git"
 	kafka "github.com/practo/tipoca-stream/pkg/kafka"
+	masksource "github.com/practo/tipoca-stream/pkg/masksource"
 	prometheus "github.com/practo/tipoca-stream/pkg/prometheus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -40,6 +41,9 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
 	controller "sigs.k8s.io/controller-runtime/pkg/controller"
+	event "sigs.k8s.io/controller-runtime/pkg/event"
+	handler "sigs.k8s.io/controller-runtime/pkg/handler"
+	source "sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // RedshiftSinkReconciler reconciles a RedshiftSink object
@@ -55,8 +59,9 @@ type RedshiftSinkReconciler struct {
 	KafkaTopicsCache   *sync.Map
 	KafkaRealtimeCache *sync.Map
 	ReleaseCache       *sync.Map
-	GitCache           *sync.Map
+	MaskSourceCache    *sync.Map
 	IncludeTablesCache *sync.Map
+	MaskLintCache      *sync.Map
 
 	DefaultBatcherImage         string
 	DefaultLoaderImage          string
@@ -70,6 +75,11 @@ type RedshiftSinkReconciler struct {
 
 	PrometheusClient prometheus.Client
 	RedshiftMetrics  bool
+
+	// MaskPollInterval is the interval at which the mask file repositories
+	// are pulled, MaskWebhookEvents reconciles the rsks on mask file pushes.
+	MaskPollInterval  time.Duration
+	MaskWebhookEvents <-chan event.GenericEvent
 }
 
 const (
@@ -125,57 +135,89 @@ func secretByKey(secret map[string]string, key string) (string, error) {
 	return value, nil
 }
 
-// fetchLatestMaskFileVersion gets the latest mask file from remote
-// git repository. It the git hash of the maskFile. Supports only github at
-// present as maskFile parsing is not handled for all the types of url formats
-// but with very few line of changes it can support all the other git repository.
-func (r *RedshiftSinkReconciler) fetchLatestMaskFileVersion(
-	maskFile string,
-	gitToken string,
-) (
-	string,
-	string,
-	string,
-	error,
-) {
-	url, err := git.ParseURL(maskFile)
-	if err != nil {
-		return "", "", "", err
-	}
-
-	if url.Scheme != "https" {
-		return "", "", "", fmt.Errorf("scheme: %s not supported.\n", url.Scheme)
-	}
+// cachedMaskSource is the mask source kept in the MaskSourceCache along
+// with the hash of the credentials it was made with
+type cachedMaskSource struct {
+	source          masksource.Source
+	credentialsHash string
+}
 
-	var repo, filePath string
+// maskSourceCacheKey is the key of the mask source in the MaskSourceCache
+func maskSourceCacheKey(rsk *tipocav1.RedshiftSink) string {
+	return rsk.Namespace + rsk.Spec.Batcher.MaskFile
+}
 
-	switch url.Host {
-	case "github.com":
-		repo, filePath = git.ParseGithubURL(url.Path)
-	default:
-		return "", "", "", fmt.Errorf("parsing not supported for: %s\n", url.Host)
+// maskSource returns the source of the mask file of the rsk, the sources
+// are cached as they keep the git clones used to find the versions. The
+// source is made again when the credentials in the secret change.
+// pruneMaskSource deletes the copies of the mask file versions kept by
+// the source which are not used by any of the redshiftsinks of the
+// namespace using the mask file. latestVersion is kept as it is the one
+// being rolled out.
+func (r *RedshiftSinkReconciler) pruneMaskSource(
+	ctx context.Context,
+	rsk *tipocav1.RedshiftSink,
+	pruner masksource.Pruner,
+	latestVersion string,
+) error {
+	rskList := &tipocav1.RedshiftSinkList{}
+	err := r.List(ctx, rskList, client.InNamespace(rsk.Namespace))
+	if err != nil {
+		return err
+	}
+	rsks := []tipocav1.RedshiftSink{*rsk}
+	for _, item := range rskList.Items {
+		if item.Name != rsk.Name {
+			rsks = append(rsks, item)
+		}
 	}
 
-	var cache git.GitCacheInterface
+	versions := maskFileVersionsInUse(rsks, rsk.Spec.Batcher.MaskFile)
+	return pruner.Prune(append(versions, latestVersion))
+}
 
-	cacheLoaded, ok := r.GitCache.Load(repo)
+func (r *RedshiftSinkReconciler) maskSource(
+	rsk *tipocav1.RedshiftSink,
+	secret map[string]string,
+) (
+	masksource.Source,
+	error,
+) {
+	config := masksource.Config{
+		GitAccessToken:    secret["gitAccessToken"],
+		GitSSHKey:         secret["gitSSHKey"],
+		GitSSHKnownHosts:  secret["gitSSHKnownHosts"],
+		GitPollInterval:   r.MaskPollInterval,
+		S3Region:          secret["s3Region"],
+		S3AccessKeyId:     secret["s3AccessKeyId"],
+		S3SecretAccessKey: secret["s3SecretAccessKey"],
+		Namespace:         rsk.Namespace,
+		Client:            r.Client,
+	}
+	credentialsHash := config.CredentialsHash()
+
+	cacheKey := maskSourceCacheKey(rsk)
+	sourceLoaded, ok := r.MaskSourceCache.Load(cacheKey)
 	if ok {
-		cache = cacheLoaded.(git.GitCacheInterface)
-	} else {
-		repoURL := strings.ReplaceAll(maskFile, filePath, "")
-		cache, err = git.NewGitCache(repoURL, gitToken)
-		if err != nil {
-			return "", "", "", err
+		cached := sourceLoaded.(cachedMaskSource)
+		if cached.credentialsHash == credentialsHash {
+			return cached.source, nil
 		}
-		r.GitCache.Store(repo, cache)
+		klog.V(2).Infof(
+			"rsk/%s credentials changed, making the mask source again",
+			rsk.Name)
 	}
 
-	latestVersion, err := cache.GetFileVersion(filePath)
+	maskSource, err := masksource.New(rsk.Spec.Batcher.MaskFile, config)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
+	r.MaskSourceCache.Store(cacheKey, cachedMaskSource{
+		source:          maskSource,
+		credentialsHash: credentialsHash,
+	})
 
-	return latestVersion, repo, filePath, nil
+	return maskSource, nil
 }
 
 func resultRequeueMilliSeconds(ms int) ctrl.Result {
@@ -331,10 +373,19 @@ func (r *RedshiftSinkReconciler) reconcile(
 	rsk *tipocav1.RedshiftSink,
 	patcher *statusPatcher,
 ) (
-	ctrl.Result,
-	[]ReconcilerEvent,
-	error,
+	_ ctrl.Result,
+	_ []ReconcilerEvent,
+	reterr error,
 ) {
+	// maskLintErr is set when the latest mask version has lint errors,
+	// the reconcile continues with the last valid version
+	var maskLintErr error
+	defer func() {
+		if reterr == nil {
+			reterr = degradedReconciled(tipocav1.ReasonMaskInvalid, maskLintErr)
+		}
+	}()
+
 	var events []ReconcilerEvent
 	result := ctrl.Result{RequeueAfter: time.Second * 30}
 
@@ -344,28 +395,31 @@ func (r *RedshiftSinkReconciler) reconcile(
 		rsk.Spec.SecretRefNamespace,
 	)
 	if err != nil {
-		return result, events, err
+		return result, events, degraded(tipocav1.ReasonSecretUnavailable, err)
 	}
 
 	tlsConfig, err := r.makeTLSConfig(secret)
 	if err != nil {
-		return result, events, err
+		return result, events, degraded(tipocav1.ReasonSecretUnavailable, err)
 	}
 
 	kafkaClient, err := r.loadKafkaClient(rsk, tlsConfig)
 	if err != nil {
-		return result, events, fmt.Errorf("Error fetching kafka watcher, %v", err)
+		return result, events, degraded(tipocav1.ReasonKafkaUnreachable,
+			fmt.Errorf("Error fetching kafka watcher, %v", err))
 	}
 
 	kafkaTopics, err := r.fetchLatestTopics(
 		kafkaClient, rsk.Spec.KafkaTopicRegexes,
 	)
 	if err != nil {
-		return result, events, fmt.Errorf("Error fetching topics, err: %v", err)
+		return result, events, degraded(tipocav1.ReasonKafkaUnreachable,
+			fmt.Errorf("Error fetching topics, err: %v", err))
 	}
 	if len(kafkaTopics) == 0 {
 		klog.Warningf(
 			"Kafka topics not found for regex: %s", rsk.Spec.KafkaTopicRegexes)
+		rsk.Status.Topics = &tipocav1.TopicPhaseCounts{}
 		return result, events, nil
 	}
 
@@ -384,27 +438,42 @@ func (r *RedshiftSinkReconciler) reconcile(
 			buildBatchers(secret, r.DefaultBatcherImage, r.DefaultKafkaVersion, tlsConfig).
 			buildLoaders(secret, r.DefaultLoaderImage, "", r.DefaultKafkaVersion, tlsConfig, r.DefaultRedshiftMaxOpenConns, r.DefaultRedshiftMaxIdleConns, prometheusURL, r.RedshiftMetrics).
 			build()
+		rsk.Status.Topics = &tipocav1.TopicPhaseCounts{
+			Total:  len(kafkaTopics),
+			Active: len(kafkaTopics),
+		}
 		result, maskLessSinkGroupEvent, err := maskLessSinkGroup.reconcile(ctx)
 		if len(maskLessSinkGroupEvent) > 0 {
 			events = append(events, maskLessSinkGroupEvent...)
 		}
-		return result, events, err
+		return result, events, degraded(tipocav1.ReasonSinkGroupFailed, err)
 	}
 
-	gitToken, err := secretByKey(secret, "gitAccessToken")
+	maskSource, err := r.maskSource(rsk, secret)
 	if err != nil {
-		return result, events, err
+		return result, events, degraded(
+			maskSourceUnreachableReason(rsk.Spec.Batcher.MaskFile),
+			fmt.Errorf("Error making mask source, err: %v\n", err))
 	}
-
-	desiredMaskVersion, repo, filePath, err := r.fetchLatestMaskFileVersion(
-		rsk.Spec.Batcher.MaskFile,
-		gitToken,
-	)
+	desiredMaskFileVersion, err := maskSource.Version()
 	if err != nil {
-		return result, events, fmt.Errorf(
-			"Error fetching latest mask file version, err: %v\n", err)
-	}
+		return result, events, degraded(
+			maskSourceUnreachableReason(rsk.Spec.Batcher.MaskFile),
+			fmt.Errorf(
+				"Error fetching latest mask file version, err: %v\n", err))
+	}
+	desiredMaskVersion := maskVersion(
+		desiredMaskFileVersion,
+		rsk.Spec.Batcher.MaskSaltVersion,
+	)
 	klog.V(2).Infof("rsk/%s desiredMaskVersion=%v", rsk.Name, desiredMaskVersion)
+	if pruner, ok := maskSource.(masksource.Pruner); ok {
+		err = r.pruneMaskSource(ctx, rsk, pruner, desiredMaskFileVersion)
+		if err != nil {
+			klog.Errorf("rsk/%s Error pruning mask source, err: %v",
+				rsk.Name, err)
+		}
+	}
 
 	var currentMaskVersion string
 	if rsk.Status.MaskStatus != nil &&
@@ -415,18 +484,62 @@ func (r *RedshiftSinkReconciler) reconcile(
 		currentMaskVersion = ""
 	}
 	klog.V(2).Infof("rsk/%s currentMaskVersion=%v", rsk.Name, currentMaskVersion)
+	currentMaskFileVersion, currentMaskSaltVersion := splitMaskVersion(
+		currentMaskVersion)
 
 	diffTopics, kafkaTopics, includeTables, err := MaskDiff(
 		kafkaTopics,
 		rsk.Spec.Batcher.MaskFile,
-		desiredMaskVersion,
-		currentMaskVersion,
-		gitToken,
+		maskSource,
+		desiredMaskFileVersion,
+		currentMaskFileVersion,
 		r.KafkaTopicsCache,
 		r.IncludeTablesCache,
+		r.MaskLintCache,
 	)
+	var lintErr *maskLintError
+	if errors.As(err, &lintErr) && currentMaskVersion != "" {
+		// the running version keeps being reconciled till the mask
+		// file is fixed, the lint result is cached
+		maskLintErr = lintErr
+		desiredMaskFileVersion, _ = splitMaskVersion(
+			lastDesiredMaskVersion(rsk, currentMaskVersion, lintErr.version))
+		desiredMaskVersion = maskVersion(
+			desiredMaskFileVersion,
+			rsk.Spec.Batcher.MaskSaltVersion,
+		)
+		klog.Errorf("rsk/%s %v, desiredMaskVersion=%v",
+			rsk.Name, lintErr, desiredMaskVersion)
+		diffTopics, kafkaTopics, includeTables, err = MaskDiff(
+			kafkaTopics,
+			rsk.Spec.Batcher.MaskFile,
+			maskSource,
+			desiredMaskFileVersion,
+			currentMaskFileVersion,
+			r.KafkaTopicsCache,
+			r.IncludeTablesCache,
+			r.MaskLintCache,
+		)
+	}
 	if err != nil {
-		return result, events, fmt.Errorf("Error doing mask diff, err: %v", err)
+		return result, events, degraded(tipocav1.ReasonMaskInvalid,
+			fmt.Errorf("Error doing mask diff, err: %v", err))
+	}
+	// salt change changes the masked values of all the tables
+	if currentMaskVersion != "" &&
+		currentMaskSaltVersion != rsk.Spec.Batcher.MaskSaltVersion {
+		klog.V(2).Infof(
+			"rsk/%s maskSaltVersion changed %q => %q, all topics have diff",
+			rsk.Name,
+			currentMaskSaltVersion,
+			rsk.Spec.Batcher.MaskSaltVersion,
+		)
+		diffTopics = kafkaTopics
+	}
+	// topics asked to be reloaded in the spec do not have a mask diff
+	manualReloads := manualReloadTopics(rsk, kafkaTopics)
+	for _, topic := range manualReloads {
+		diffTopics = appendIfMissing(diffTopics, topic)
 	}
 
 	sBuilder := newStatusBuilder()
@@ -456,7 +569,7 @@ func (r *RedshiftSinkReconciler) reconcile(
 			status.fixMaskStatus()
 		}
 
-		if len(status.released) == 0 {
+		if len(status.released) == 0 && len(manualReloads) == 0 {
 			klog.Fatalf("rsk/%s unexpected status, released=0", rsk.Name)
 		}
 	}
@@ -477,8 +590,21 @@ func (r *RedshiftSinkReconciler) reconcile(
 	}
 
 	if !subSetSlice(currentRealtime, status.realtime) {
+		awaitingApproval := []string{}
+		currentRealtimeTopics := toMap(status.realtime)
 		for _, moreRealtime := range currentRealtime {
+			if currentRealtimeTopics[moreRealtime] {
+				continue
+			}
 			status.realtime = appendIfMissing(status.realtime, moreRealtime)
+			if releaseRequiresApproval(rsk, moreRealtime) {
+				status.awaitingApproval = appendIfMissing(
+					status.awaitingApproval, moreRealtime)
+				awaitingApproval = append(awaitingApproval, moreRealtime)
+			}
+		}
+		if len(awaitingApproval) > 0 {
+			notifyApprovalPending(secret, rsk, awaitingApproval)
 		}
 		klog.V(2).Infof(
 			"rsk/%s reconcile needed, realtime topics updated: %v",
@@ -491,6 +617,17 @@ func (r *RedshiftSinkReconciler) reconcile(
 	}
 	klog.V(2).Infof("rsk/%v reconciling all sinkGroups", rsk.Name)
 
+	// released topics lagging behind are moved to their own units
+	// of the main sink group when autoscaling is specified
+	scaler := &autoscaler{
+		rsk:              rsk,
+		calc:             calc,
+		prometheusClient: r.PrometheusClient,
+	}
+	if scaler.scale(status.released, time.Now()) {
+		klog.V(2).Infof("rsk/%s main sink group units rescaled", rsk.Name)
+	}
+
 	// SinkGroup are of following types:
 	// 1. main: sink group which has desiredMaskVersion
 	//      and has topics which have been released
@@ -550,7 +687,7 @@ func (r *RedshiftSinkReconciler) reconcile(
 	for _, sinkGroup := range sinkGroups {
 		result, sinkGroupEvents, err := sinkGroup.reconcile(ctx)
 		if err != nil {
-			return result, nil, err
+			return result, nil, degraded(tipocav1.ReasonSinkGroupFailed, err)
 		}
 		if len(sinkGroupEvents) > 0 {
 			events = append(events, sinkGroupEvents...)
@@ -561,10 +698,31 @@ func (r *RedshiftSinkReconciler) reconcile(
 		return resultRequeueMilliSeconds(3000), events, nil
 	}
 
+	rolledBack, err := r.rollbackReleases(
+		ctx,
+		rsk,
+		status,
+		secret,
+		maskSource,
+		currentMaskFileVersion,
+		desiredMaskFileVersion,
+		patcher,
+	)
+	if err != nil {
+		return resultRequeueMilliSeconds(15000), events,
+			degraded(tipocav1.ReasonRollbackFailed, err)
+	}
+	if rolledBack {
+		// sink groups are updated to resume the rolled back topics
+		return resultRequeueMilliSeconds(1500), events, nil
+	}
+
+	pruneReleaseValidationFailures(rsk, status.realtime)
 	if len(status.realtime) == 0 {
 		err := r.removeDeadConsumerGroups(rsk, kafkaClient)
 		if err != nil {
-			return resultRequeueMilliSeconds(15000), events, err
+			return resultRequeueMilliSeconds(15000), events,
+				degraded(tipocav1.ReasonKafkaUnreachable, err)
 		}
 		klog.V(2).Infof("rsk/%s nothing done in reconcile", rsk.Name)
 		if len(status.reloading) > 0 {
@@ -573,32 +731,59 @@ func (r *RedshiftSinkReconciler) reconcile(
 		return resultRequeueMilliSeconds(900000), events, nil
 	}
 
+	// approvals are consumed from the annotation before releasing
+	approved := approvedTopics(rsk, status.awaitingApproval)
+	if len(approved) > 0 {
+		err := r.removeApprovals(ctx, rsk, approved)
+		if err != nil {
+			return result, events, degraded(tipocav1.ReasonReconcileFailed,
+				fmt.Errorf("Error removing release approvals, err: %v", err))
+		}
+		klog.V(2).Infof("rsk/%s release approved: %v", rsk.Name, approved)
+		status.approveRelease(approved)
+	}
+
 	// release the realtime topics, topics in realtime (MaxTopicRelease) are
 	// taken as a group and is tried to release in single reconcile
 	// to reduce the time spent on rebalance of sink groups (optimization)
 	// #141
-	releaseCandidates := status.realtime
-	if len(status.realtime) >= MaxTopicRelease {
-		releaseCandidates = status.realtime[:MaxTopicRelease]
+	releasable := status.releasable(time.Now())
+	if len(releasable) == 0 {
+		klog.V(2).Infof(
+			"rsk/%s realtime topics awaiting approval or release window",
+			rsk.Name)
+		return resultRequeueMilliSeconds(60000), events, nil
+	}
+	releaser, err := newReleaser(
+		maskSource,
+		currentMaskFileVersion,
+		desiredMaskFileVersion,
+		secret,
+		rsk,
+	)
+	if err != nil {
+		return result, events, degraded(tipocav1.ReasonRedshiftUnreachable,
+			fmt.Errorf("Error making releaser, err: %v", err))
 	}
-	klog.V(2).Infof("rsk/%s releaseCandidates: %v", rsk.Name, releaseCandidates)
 
-	var releaser *releaser
-	if len(releaseCandidates) > 0 {
-		releaser, err = newReleaser(
-			repo,
-			filePath,
-			currentMaskVersion,
-			desiredMaskVersion,
-			secret,
-			rsk,
-		)
-		if err != nil {
-			return result, events, fmt.Errorf(
-				"Error making releaser, err: %v", err)
-		}
+	// reloaded tables are validated before the release, the failures
+	// are patched right away as the release disallows the main patch
+	beforeValidation := rsk.DeepCopy()
+	releaseCandidates, err := releaser.validatedCandidates(
+		ctx, releasable, ReloadTableSuffix, MaxTopicRelease)
+	patchErr := patcher.Patch(
+		ctx, beforeValidation, rsk, "release validation")
+	if patchErr != nil {
+		klog.Errorf("rsk/%s error patching validation status, err: %v",
+			rsk.Name, patchErr)
+	}
+	if err != nil {
+		return result, events, degraded(tipocav1.ReasonRedshiftUnreachable, err)
+	}
+	klog.V(2).Infof("rsk/%s releaseCandidates: %v", rsk.Name, releaseCandidates)
+	if len(releaseCandidates) == 0 {
+		return resultRequeueMilliSeconds(300000), events, nil
 	}
-
 	releasedTopics := []string{}
 	var releaseError error
 	for id, releasingTopic := range releaseCandidates {
@@ -636,10 +821,11 @@ func (r *RedshiftSinkReconciler) reconcile(
 			rsk.Namespace+rsk.Name,
 			releaseCache{lastCacheRefresh: &now},
 		)
-		status.notifyRelease(secret, repo, filePath)
+		status.notifyRelease(secret, maskSource)
 	}
 	if releaseError != nil {
-		return result, events, releaseError
+		return result, events,
+			degraded(tipocav1.ReasonReleaseFailed, releaseError)
 	}
 	if len(releasedTopics) > 0 {
 		klog.V(2).Infof("rsk/%v: all topics were released succesfully!", rsk.Name)
@@ -683,16 +869,24 @@ func (r *RedshiftSinkReconciler) Reconcile(
 		client:    r.Client,
 		allowMain: true,
 	}
+	// withoutConditions is the rsk before the conditions are updated
+	var withoutConditions *tipocav1.RedshiftSink
 
 	// Always attempt to patch the status after each reconciliation.
 	defer func() {
+		from, caller := original, "main"
 		if !patcher.allowMain {
+			// the releases and rollbacks have patched the rest of the
+			// status, only the conditions are patched
 			klog.V(4).Infof(
 				"rsk/%s patching is not allowed for main", redshiftsink.Name)
-			return
+			if withoutConditions == nil {
+				return
+			}
+			from, caller = withoutConditions, "conditions"
 		}
 
-		err := patcher.Patch(ctx, original, &redshiftsink, "main")
+		err := patcher.Patch(ctx, from, &redshiftsink, caller)
 		if err != nil {
 			reterr = kerrors.NewAggregate(
 				[]error{
@@ -706,7 +900,12 @@ func (r *RedshiftSinkReconciler) Reconcile(
 
 	// Perform a reconcile, getting back the desired result, any utilerrors
 	result, events, err := r.reconcile(ctx, &redshiftsink, patcher)
-	if err != nil {
+	withoutConditions = redshiftsink.DeepCopy()
+	updateConditions(&redshiftsink, events, err)
+	if isReconciled(err) {
+		klog.Warningf("rsk/%s reconciled with error: %v", redshiftsink.Name, err)
+		err = nil
+	} else if err != nil {
 		err = fmt.Errorf("Failed to reconcile: %s", err)
 	}
 
@@ -722,11 +921,18 @@ func (r *RedshiftSinkReconciler) Reconcile(
 
 // SetupWithManager sets up the controller and applies all controller configs
 func (r *RedshiftSinkReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&tipocav1.RedshiftSink{}).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.ConfigMap{}).
-		Owns(&corev1.Secret{}).
-		Complete(r)
+		Owns(&corev1.Secret{})
+	if r.MaskWebhookEvents != nil {
+		builder = builder.Watches(
+			&source.Channel{Source: r.MaskWebhookEvents},
+			&handler.EnqueueRequestForObject{},
+		)
+	}
+
+	return builder.Complete(r)
 }
@@ -3,11 +3,13 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"database/sql"
 	"github.com/practo/klog/v2"
 	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	"github.com/practo/tipoca-stream/pkg/masksource"
 	"github.com/practo/tipoca-stream/pkg/notify"
 	"github.com/practo/tipoca-stream/pkg/redshift"
 	"github.com/practo/tipoca-stream/pkg/transformer"
@@ -15,8 +17,7 @@ import (
 
 type releaser struct {
 	schema         string
-	repo           string
-	filePath       string
+	maskSource     masksource.Source
 	currentVersion string
 	desiredVersion string
 	redshifter     *redshift.Redshift
@@ -25,8 +26,7 @@ type releaser struct {
 }
 
 func newReleaser(
-	repo string,
-	filePath string,
+	maskSource masksource.Source,
 	currentVersion string,
 	desiredVersion string,
 	secret map[string]string,
@@ -43,8 +43,7 @@ func newReleaser(
 	return &releaser{
 		schema:         schema,
 		redshifter:     redshifter,
-		repo:           repo,
-		filePath:       filePath,
+		maskSource:     maskSource,
 		currentVersion: currentVersion,
 		desiredVersion: desiredVersion,
 		notifier:       makeNotifier(secret),
@@ -83,18 +82,43 @@ func (r *releaser) releaseTopic(
 	if err != nil {
 		return err
 	}
-	if tableExist {
-		klog.V(4).Infof("drop table %v", table)
-		err = r.redshifter.DropTableWithCascade(ctx, tx, schema, table)
+
+	// the live table is retained to allow a rollback of the release,
+	// it requires the consumer group of the live table to resume the sink
+	tg := topicGroup(r.rsk, topic)
+	retain := tableExist && tg != nil && retainPrevious(r.rsk)
+	var previousTable string
+	var views []redshift.View
+	if retain {
+		previousTable = previousTableName(
+			table, currentReleasedVersion(r.rsk, topic))
+		err = r.dropRetainedTables(ctx, tx, schema, topic, previousTable)
 		if err != nil {
 			return err
 		}
-	}
+		err = r.swapTable(ctx, tx, schema, table, reloadedTable, previousTable)
+		if err != nil {
+			return err
+		}
+	} else {
+		if tableExist {
+			// views dropped with the live table are recreated after release
+			views, err = r.redshifter.CaptureDependentViews(ctx, tx, schema, table)
+			if err != nil {
+				return err
+			}
+			klog.V(4).Infof("drop table %v", table)
+			err = r.redshifter.DropTableWithCascade(ctx, tx, schema, table)
+			if err != nil {
+				return err
+			}
+		}
 
-	klog.V(4).Infof("move table %v -> %v", reloadedTable, table)
-	err = r.redshifter.RenameTable(ctx, tx, schema, reloadedTable, table)
-	if err != nil {
-		return err
+		klog.V(4).Infof("move table %v -> %v", reloadedTable, table)
+		err = r.redshifter.RenameTable(ctx, tx, schema, reloadedTable, table)
+		if err != nil {
+			return err
+		}
 	}
 
 	if group != nil {
@@ -107,15 +131,12 @@ func (r *releaser) releaseTopic(
 
 	statusCopy := status.deepCopy()
 
-	// store info to cleanup dead consumer group after release
-	tg := topicGroup(r.rsk, topic)
-	if tg != nil {
-		addDeadConsumerGroups(r.rsk, consumerGroupID(
-			r.rsk.Name, r.rsk.Namespace, tg.ID, "-batcher"),
-		)
-		addDeadConsumerGroups(r.rsk, consumerGroupID(
-			r.rsk.Name, r.rsk.Namespace, tg.ID, "-loader"),
-		)
+	// store info to cleanup dead consumer group after release,
+	// the group of the retained table is cleaned when it is dropped
+	if retain {
+		r.retainPreviousRelease(topic, previousTable, *tg)
+	} else if tg != nil {
+		addDeadTopicGroup(r.rsk, *tg)
 	}
 
 	status.updateTopicsOnRelease(topic)
@@ -140,6 +161,7 @@ func (r *releaser) releaseTopic(
 		return fmt.Errorf("Error committing tx, err:%v\n", err)
 	}
 	klog.V(5).Infof("released topic in redshift: %s", topic)
+	r.recreateViews(ctx, schema, table, views)
 	time.Sleep(3 * time.Second)
 
 	return nil
@@ -175,6 +197,40 @@ func (r *releaser) release(
 	return nil
 }
 
+// recreateViews recreates the views dropped with the live table on the
+// released table, the views which could not be recreated are notified.
+func (r *releaser) recreateViews(
+	ctx context.Context,
+	schema string,
+	table string,
+	views []redshift.View,
+) {
+	if len(views) == 0 {
+		return
+	}
+	failures := r.redshifter.RecreateViews(ctx, views)
+	klog.V(2).Infof("rsk/%s recreated %d/%d views of table: %s",
+		r.rsk.Name, len(views)-len(failures), len(views), table)
+	if len(failures) == 0 || r.notifier == nil {
+		return
+	}
+
+	failed := []string{}
+	for _, failure := range failures {
+		failed = append(failed, failure.String())
+	}
+	err := r.notifier.Notify(fmt.Sprintf(
+		"Released table *%s.%s*, failed to recreate %d views dropped with it: %s",
+		schema,
+		table,
+		len(failures),
+		strings.Join(failed, "; "),
+	))
+	if err != nil {
+		klog.Errorf("views notification failed, err: %v", err)
+	}
+}
+
 func (r *releaser) notifyTopicRelease(
 	schema string,
 	topic string,
@@ -183,26 +239,20 @@ func (r *releaser) notifyTopicRelease(
 	_, _, table := transformer.ParseTopic(topic)
 
 	// notify
-	// TODO: make it generic for all git repos
 	if r.notifier == nil {
 		return
 	}
-	sha := r.desiredVersion
-	if len(r.desiredVersion) >= 6 {
-		sha = r.desiredVersion[:6]
-	}
 	message := fmt.Sprintf(
-		"Released table *%s.%s* with mask-version: <https://github.com/%s/blob/%s/%s | %s> and <https://github.com/%s/compare/%s...%s | mask-changes>.",
+		"Released table *%s.%s* with mask-version: %s",
 		schema,
 		table,
-		r.repo,
-		r.desiredVersion,
-		r.filePath,
-		sha,
-		r.repo,
-		r.currentVersion,
-		r.desiredVersion,
+		masksource.VersionLink(r.maskSource, r.desiredVersion),
 	)
+	diffURL := r.maskSource.DiffURL(r.currentVersion, r.desiredVersion)
+	if diffURL != "" {
+		message += fmt.Sprintf(" and <%s | mask-changes>", diffURL)
+	}
+	message += "."
 
 	err := r.notifier.Notify(message)
 	if err != nil {
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	klog "github.com/practo/klog/v2"
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	"github.com/practo/tipoca-stream/pkg/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const approveAllReleases = "*"
+
+// releaseRequiresApproval tells if the release of the topic needs to be
+// approved using the ApproveReleaseAnnotation. TopicReleaseCondition
+// takes precedence over the ReleaseCondition.
+func releaseRequiresApproval(rsk *tipocav1.RedshiftSink, topic string) bool {
+	var requireApproval *bool
+	if rsk.Spec.ReleaseCondition != nil {
+		requireApproval = rsk.Spec.ReleaseCondition.RequireApproval
+	}
+	condition, ok := rsk.Spec.TopicReleaseCondition[topic]
+	if ok && condition.RequireApproval != nil {
+		requireApproval = condition.RequireApproval
+	}
+
+	return requireApproval != nil && *requireApproval
+}
+
+// releaseWindows returns the windows in which the topic can be released.
+// TopicReleaseCondition takes precedence over the ReleaseCondition.
+func releaseWindows(
+	rsk *tipocav1.RedshiftSink,
+	topic string,
+) []tipocav1.ReleaseWindow {
+	var windows []tipocav1.ReleaseWindow
+	if rsk.Spec.ReleaseCondition != nil {
+		windows = rsk.Spec.ReleaseCondition.ReleaseWindows
+	}
+	condition, ok := rsk.Spec.TopicReleaseCondition[topic]
+	if ok && condition.ReleaseWindows != nil {
+		windows = condition.ReleaseWindows
+	}
+
+	return windows
+}
+
+// releaseWindowOpen tells if the topic can be released at the time.
+// Topics without release windows can be released anytime. Windows
+// which can not be parsed are treated as closed.
+func releaseWindowOpen(
+	rsk *tipocav1.RedshiftSink,
+	topic string,
+	now time.Time,
+) bool {
+	windows := releaseWindows(rsk, topic)
+	if len(windows) == 0 {
+		return true
+	}
+
+	for _, window := range windows {
+		schedule, err := util.ParseCronSchedule(window.Schedule)
+		if err != nil {
+			klog.Errorf("rsk/%s topic: %s, ignoring window, err: %v",
+				rsk.Name, topic, err)
+			continue
+		}
+		location := time.UTC
+		if window.Timezone != "" {
+			location, err = time.LoadLocation(window.Timezone)
+			if err != nil {
+				klog.Errorf("rsk/%s topic: %s, ignoring window, err: %v",
+					rsk.Name, topic, err)
+				continue
+			}
+		}
+		if schedule.Within(now.In(location), window.Duration.Duration) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// annotationList returns the comma separated values of the annotation
+func annotationList(rsk *tipocav1.RedshiftSink, annotation string) []string {
+	value, ok := rsk.Annotations[annotation]
+	if !ok {
+		return []string{}
+	}
+
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// releaseApprovals returns the approvals in the ApproveReleaseAnnotation
+func releaseApprovals(rsk *tipocav1.RedshiftSink) []string {
+	return annotationList(rsk, tipocav1.ApproveReleaseAnnotation)
+}
+
+// approvedTopics returns the topics awaiting approval which
+// have been approved in the ApproveReleaseAnnotation.
+func approvedTopics(rsk *tipocav1.RedshiftSink, awaitingApproval []string) []string {
+	approvals := toMap(releaseApprovals(rsk))
+	if approvals[approveAllReleases] {
+		return awaitingApproval
+	}
+
+	approved := []string{}
+	for _, topic := range awaitingApproval {
+		if approvals[topic] {
+			approved = append(approved, topic)
+		}
+	}
+
+	return approved
+}
+
+// approveRelease marks the topics as approved for the release
+func (s *status) approveRelease(topics []string) {
+	for _, topic := range topics {
+		s.awaitingApproval = removeFromSlice(s.awaitingApproval, topic)
+	}
+}
+
+// releasable returns the realtime topics which can be released now,
+// i.e. they are not awaiting approval and their release window is open.
+func (s *status) releasable(now time.Time) []string {
+	awaitingApproval := toMap(s.awaitingApproval)
+
+	topics := []string{}
+	for _, topic := range s.realtime {
+		if awaitingApproval[topic] {
+			continue
+		}
+		if !releaseWindowOpen(s.rsk, topic, now) {
+			klog.V(2).Infof("rsk/%s topic: %s, release window closed",
+				s.rsk.Name, topic)
+			continue
+		}
+		topics = append(topics, topic)
+	}
+
+	return topics
+}
+
+// removeApprovals removes the consumed approvals from the
+// ApproveReleaseAnnotation so that they do not approve the future releases.
+func (r *RedshiftSinkReconciler) removeApprovals(
+	ctx context.Context,
+	rsk *tipocav1.RedshiftSink,
+	topics []string,
+) error {
+	return r.removeFromAnnotationList(
+		ctx,
+		rsk,
+		tipocav1.ApproveReleaseAnnotation,
+		append([]string{approveAllReleases}, topics...),
+	)
+}
+
+// removeFromAnnotationList removes the values from the comma separated
+// values of the annotation, the annotation is removed when it gets empty.
+func (r *RedshiftSinkReconciler) removeFromAnnotationList(
+	ctx context.Context,
+	rsk *tipocav1.RedshiftSink,
+	annotation string,
+	values []string,
+) error {
+	remove := toMap(values)
+	remaining := []string{}
+	for _, value := range annotationList(rsk, annotation) {
+		if remove[value] {
+			continue
+		}
+		remaining = append(remaining, value)
+	}
+
+	patched := rsk.DeepCopy()
+	if len(remaining) == 0 {
+		delete(patched.Annotations, annotation)
+	} else {
+		patched.Annotations[annotation] = strings.Join(remaining, ",")
+	}
+
+	return r.Patch(ctx, patched, client.MergeFrom(rsk))
+}
+
+// notifyApprovalPending notifies the topics whose release need approval
+func notifyApprovalPending(
+	secret map[string]string,
+	rsk *tipocav1.RedshiftSink,
+	topics []string,
+) {
+	message := fmt.Sprintf(
+		"%s has %d tables awaiting release approval: %s, approve using: "+
+			"`kubectl annotate --overwrite redshiftsink %s -n %s %s=%s`",
+		rsk.Name,
+		len(topics),
+		strings.Join(topics, ", "),
+		rsk.Name,
+		rsk.Namespace,
+		tipocav1.ApproveReleaseAnnotation,
+		approveAllReleases,
+	)
+	klog.V(2).Infof("rsk/%s %s", rsk.Name, message)
+
+	notifier := makeNotifier(secret)
+	if notifier == nil {
+		return
+	}
+	err := notifier.Notify(message)
+	if err != nil {
+		klog.Errorf("rsk/%s notify approval pending failed: %v", rsk.Name, err)
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReleaseWindowOpen(t *testing.T) {
+	t.Parallel()
+
+	// 2021-06-07 is a monday
+	monday := time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC)
+	nightly := tipocav1.ReleaseWindow{
+		Schedule: "0 22 * * *",
+		Duration: metav1.Duration{Duration: 6 * time.Hour},
+		Timezone: "Asia/Kolkata",
+	}
+	weekend := tipocav1.ReleaseWindow{
+		Schedule: "0 0 * * 6",
+		Duration: metav1.Duration{Duration: 48 * time.Hour},
+	}
+
+	tests := []struct {
+		name      string
+		condition *tipocav1.ReleaseCondition
+		topic     map[string]tipocav1.ReleaseCondition
+		now       time.Time
+		open      bool
+	}{
+		{
+			name: "test1: no release condition",
+			now:  monday.Add(10 * time.Hour),
+			open: true,
+		},
+		{
+			name:      "test2: no windows",
+			condition: &tipocav1.ReleaseCondition{},
+			now:       monday.Add(10 * time.Hour),
+			open:      true,
+		},
+		{
+			name: "test3: window open in timezone",
+			condition: &tipocav1.ReleaseCondition{
+				ReleaseWindows: []tipocav1.ReleaseWindow{nightly},
+			},
+			// 22:00 IST is 16:30 UTC
+			now:  monday.Add(17 * time.Hour),
+			open: true,
+		},
+		{
+			name: "test4: window closed in timezone",
+			condition: &tipocav1.ReleaseCondition{
+				ReleaseWindows: []tipocav1.ReleaseWindow{nightly},
+			},
+			now:  monday.Add(23 * time.Hour),
+			open: false,
+		},
+		{
+			name: "test5: topic windows take precedence",
+			condition: &tipocav1.ReleaseCondition{
+				ReleaseWindows: []tipocav1.ReleaseWindow{nightly},
+			},
+			topic: map[string]tipocav1.ReleaseCondition{
+				"db.inventory.orders": {
+					ReleaseWindows: []tipocav1.ReleaseWindow{weekend},
+				},
+			},
+			now:  monday.Add(17 * time.Hour),
+			open: false,
+		},
+		{
+			name: "test6: any of the windows",
+			condition: &tipocav1.ReleaseCondition{
+				ReleaseWindows: []tipocav1.ReleaseWindow{nightly, weekend},
+			},
+			now:  monday.Add(-time.Hour),
+			open: true,
+		},
+		{
+			name: "test7: invalid window is closed",
+			condition: &tipocav1.ReleaseCondition{
+				ReleaseWindows: []tipocav1.ReleaseWindow{
+					{Schedule: "0 22 * *", Duration: nightly.Duration},
+				},
+			},
+			now:  monday.Add(23 * time.Hour),
+			open: false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rsk := &tipocav1.RedshiftSink{
+				Spec: tipocav1.RedshiftSinkSpec{
+					ReleaseCondition:      tc.condition,
+					TopicReleaseCondition: tc.topic,
+				},
+			}
+			open := releaseWindowOpen(rsk, "db.inventory.orders", tc.now)
+			if open != tc.open {
+				t.Errorf("expected open: %v, got: %v", tc.open, open)
+			}
+		})
+	}
+}
+
+func TestReleaseApproval(t *testing.T) {
+	t.Parallel()
+
+	yes, no := true, false
+	rsk := &tipocav1.RedshiftSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				tipocav1.ApproveReleaseAnnotation: "db.inventory.orders, db.inventory.products",
+			},
+		},
+		Spec: tipocav1.RedshiftSinkSpec{
+			ReleaseCondition: &tipocav1.ReleaseCondition{
+				RequireApproval: &yes,
+			},
+			TopicReleaseCondition: map[string]tipocav1.ReleaseCondition{
+				"db.inventory.customers": {RequireApproval: &no},
+			},
+		},
+	}
+
+	if !releaseRequiresApproval(rsk, "db.inventory.orders") {
+		t.Errorf("expected db.inventory.orders to require approval")
+	}
+	if releaseRequiresApproval(rsk, "db.inventory.customers") {
+		t.Errorf("expected db.inventory.customers to not require approval")
+	}
+
+	awaitingApproval := []string{"db.inventory.items", "db.inventory.orders"}
+	approved := approvedTopics(rsk, awaitingApproval)
+	if !reflect.DeepEqual(approved, []string{"db.inventory.orders"}) {
+		t.Errorf("expected approved: [db.inventory.orders], got: %v", approved)
+	}
+
+	rsk.Annotations[tipocav1.ApproveReleaseAnnotation] = "*"
+	approved = approvedTopics(rsk, awaitingApproval)
+	if !reflect.DeepEqual(approved, awaitingApproval) {
+		t.Errorf("expected approved: %v, got: %v", awaitingApproval, approved)
+	}
+
+	s := &status{
+		rsk:              rsk,
+		realtime:         []string{"db.inventory.customers", "db.inventory.items", "db.inventory.orders"},
+		awaitingApproval: awaitingApproval,
+	}
+	releasable := s.releasable(time.Now())
+	if !reflect.DeepEqual(releasable, []string{"db.inventory.customers"}) {
+		t.Errorf("expected releasable: [db.inventory.customers], got: %v", releasable)
+	}
+
+	s.approveRelease([]string{"db.inventory.orders"})
+	releasable = s.releasable(time.Now())
+	expected := []string{"db.inventory.customers", "db.inventory.orders"}
+	if !reflect.DeepEqual(releasable, expected) {
+		t.Errorf("expected releasable: %v, got: %v", expected, releasable)
+	}
+}
@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	klog "github.com/practo/klog/v2"
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	"github.com/practo/tipoca-stream/pkg/masksource"
+	"github.com/practo/tipoca-stream/pkg/transformer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rolledBackTableSuffix is used to name the table replaced by a rollback
+// before it is dropped
+const rolledBackTableSuffix = "_rolledback"
+
+// retainPrevious tells if the releases retain the live table
+func retainPrevious(rsk *tipocav1.RedshiftSink) bool {
+	return rsk.Spec.PreviousTableRetention != nil &&
+		rsk.Spec.PreviousTableRetention.Duration > 0
+}
+
+// previousTableName is the name the live table is retained with, the
+// version is the released mask version of the live table.
+func previousTableName(table string, version *string) string {
+	if version == nil || *version == "" {
+		return table + "_previous"
+	}
+	suffix := strings.ToLower(
+		strings.ReplaceAll(shortMaskVersion(*version), "-", "_"))
+
+	return table + "_previous_" + suffix
+}
+
+// swapTable replaces the live table with the replacement table, the live
+// table is renamed to retainAs. Regular views bind the tables by oid and
+// follow the live table on rename, so they are replaced with late binding
+// views which bind the new live table by name.
+func (r *releaser) swapTable(
+	ctx context.Context,
+	tx *sql.Tx,
+	schema string,
+	table string,
+	replacement string,
+	retainAs string,
+) error {
+	views, err := r.redshifter.DependentViews(ctx, tx, schema, table)
+	if err != nil {
+		return err
+	}
+
+	klog.V(4).Infof("move table %v -> %v", table, retainAs)
+	err = r.redshifter.RenameTable(ctx, tx, schema, table, retainAs)
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("move table %v -> %v", replacement, table)
+	err = r.redshifter.RenameTable(ctx, tx, schema, replacement, table)
+	if err != nil {
+		return err
+	}
+
+	for _, view := range views {
+		klog.V(4).Infof("replace view %s.%s with late binding view",
+			view.Schema, view.Name)
+		err = r.redshifter.ReplaceViewLateBinding(ctx, tx, view)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropRetainedTables drops the table retained by the last release of the
+// topic and any leftover table with the name the live table is retained as.
+func (r *releaser) dropRetainedTables(
+	ctx context.Context,
+	tx *sql.Tx,
+	schema string,
+	topic string,
+	previousTable string,
+) error {
+	tables := []string{previousTable}
+	previous, ok := r.rsk.Status.PreviousReleases[topic]
+	if ok {
+		tables = appendIfMissing(tables, previous.Table)
+	}
+
+	for _, table := range tables {
+		exist, err := r.redshifter.TableExist(ctx, schema, table)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			continue
+		}
+		klog.V(4).Infof("drop table %v", table)
+		err = r.redshifter.DropTableWithCascade(ctx, tx, schema, table)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retainPreviousRelease stores the table retained by the release of the
+// topic, the consumer group of the table retained before it is cleaned up.
+func (r *releaser) retainPreviousRelease(
+	topic string,
+	previousTable string,
+	group tipocav1.Group,
+) {
+	if r.rsk.Status.PreviousReleases == nil {
+		r.rsk.Status.PreviousReleases = make(map[string]tipocav1.PreviousRelease)
+	}
+	last, ok := r.rsk.Status.PreviousReleases[topic]
+	if ok {
+		addDeadTopicGroup(r.rsk, last.Group)
+	}
+
+	r.rsk.Status.PreviousReleases[topic] = tipocav1.PreviousRelease{
+		Table:           previousTable,
+		ReleasedVersion: currentReleasedVersion(r.rsk, topic),
+		Group:           group,
+		ExpiresAt: metav1.NewTime(
+			time.Now().Add(r.rsk.Spec.PreviousTableRetention.Duration)),
+	}
+}
+
+// rollbackAllowed tells if the last release of the topic can be rolled back
+func rollbackAllowed(rsk *tipocav1.RedshiftSink, topic string) error {
+	_, ok := rsk.Status.PreviousReleases[topic]
+	if !ok {
+		return fmt.Errorf("no retained table to rollback to")
+	}
+	topicStatus := currentTopicStatus(rsk, topic)
+	if topicStatus == nil || topicStatus.Phase != tipocav1.MaskActive {
+		return fmt.Errorf("topic is not active")
+	}
+
+	return nil
+}
+
+// rolledBackTopicsByVersion separates the rolled back topics whose
+// released version is not the version, keyed by their released version.
+// They keep sinking with the mask version of the table they were rolled
+// back to, so that the table is not migrated to the schema of the version.
+func rolledBackTopicsByVersion(
+	rsk *tipocav1.RedshiftSink,
+	topics []string,
+	version string,
+) (
+	[]string,
+	map[string][]string,
+) {
+	remaining := []string{}
+	rolledBack := make(map[string][]string)
+	for _, topic := range topics {
+		topicStatus := currentTopicStatus(rsk, topic)
+		if topicStatus == nil ||
+			topicStatus.Phase != tipocav1.MaskRolledBack ||
+			topicStatus.ReleasedVersion == nil ||
+			*topicStatus.ReleasedVersion == "" ||
+			*topicStatus.ReleasedVersion == version {
+			remaining = append(remaining, topic)
+			continue
+		}
+		releasedVersion := *topicStatus.ReleasedVersion
+		rolledBack[releasedVersion] = append(rolledBack[releasedVersion], topic)
+	}
+
+	return remaining, rolledBack
+}
+
+// rollbackTopic swaps the live table of the topic with the table retained
+// by its last release. The sink of the topic is resumed using the consumer
+// group of the retained table and its mask status is reverted.
+func (r *releaser) rollbackTopic(
+	ctx context.Context,
+	tx *sql.Tx,
+	schema string,
+	topic string,
+	group *string,
+	status *status,
+	patcher *statusPatcher,
+) error {
+	previous := r.rsk.Status.PreviousReleases[topic]
+	_, _, table := transformer.ParseTopic(topic)
+	rolledBackTable := table + rolledBackTableSuffix
+
+	exist, err := r.redshifter.TableExist(ctx, schema, previous.Table)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("retained table: %s does not exist", previous.Table)
+	}
+	err = r.swapTable(ctx, tx, schema, table, previous.Table, rolledBackTable)
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("drop table %v", rolledBackTable)
+	err = r.redshifter.DropTableWithCascade(ctx, tx, schema, rolledBackTable)
+	if err != nil {
+		return err
+	}
+	if group != nil {
+		klog.V(4).Infof("granting schema access for table: %v to group: %v", table, *group)
+		err = r.redshifter.GrantSchemaAccess(ctx, tx, schema, table, *group)
+		if err != nil {
+			return err
+		}
+	}
+
+	statusCopy := status.deepCopy()
+
+	tg := topicGroup(r.rsk, topic)
+	if tg != nil {
+		addDeadTopicGroup(r.rsk, *tg)
+	}
+	updateTopicGroup(r.rsk, topic, previous.Group)
+	var version string
+	if previous.ReleasedVersion != nil {
+		version = *previous.ReleasedVersion
+	}
+	r.rsk.Status.MaskStatus.CurrentMaskStatus[topic] = tipocav1.TopicMaskStatus{
+		Version:         version,
+		Phase:           tipocav1.MaskRolledBack,
+		ReleasedVersion: previous.ReleasedVersion,
+		ReloadID:        currentReloadID(r.rsk, topic),
+	}
+	delete(r.rsk.Status.PreviousReleases, topic)
+	status.updateMaskStatus()
+
+	err = patcher.Patch(ctx, statusCopy.rsk, status.rsk, fmt.Sprintf("rollback %s", topic))
+	if err != nil {
+		// revert (patched later)
+		status.overwrite(statusCopy)
+		klog.V(2).Infof("rsk/%s reverted rollback for %s", status.rsk.Name, topic)
+		return fmt.Errorf("Error patching rsk status, err: %v, rollback failed for :%s", err, topic)
+	}
+
+	patcher.allowMain = false
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("Error committing tx, err:%v\n", err)
+	}
+	klog.V(2).Infof("rsk/%s rolled back topic in redshift: %s", r.rsk.Name, topic)
+
+	return nil
+}
+
+func (r *releaser) rollback(
+	ctx context.Context,
+	schema string,
+	topic string,
+	group *string,
+	status *status,
+	patcher *statusPatcher,
+) error {
+	tx, err := r.redshifter.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Error creating database tx, err: %v\n", err)
+	}
+
+	err = r.rollbackTopic(ctx, tx, schema, topic, group, status, patcher)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			klog.Errorf(
+				"Error rolling back failed rollback tx for topic: %s, rollbackErr: %v",
+				topic,
+				rollbackErr,
+			)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// dropExpiredPreviousReleases drops the retained tables whose retention
+// has expired and cleans up their consumer groups.
+func (r *releaser) dropExpiredPreviousReleases(
+	ctx context.Context,
+	schema string,
+	now time.Time,
+) error {
+	for _, topic := range expiredPreviousReleases(r.rsk, now) {
+		previous := r.rsk.Status.PreviousReleases[topic]
+		exist, err := r.redshifter.TableExist(ctx, schema, previous.Table)
+		if err != nil {
+			return err
+		}
+		if exist {
+			tx, err := r.redshifter.Begin(ctx)
+			if err != nil {
+				return fmt.Errorf("Error creating database tx, err: %v\n", err)
+			}
+			klog.V(2).Infof("rsk/%s drop expired table %v", r.rsk.Name, previous.Table)
+			err = r.redshifter.DropTableWithCascade(ctx, tx, schema, previous.Table)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			err = tx.Commit()
+			if err != nil {
+				return fmt.Errorf("Error committing tx, err:%v\n", err)
+			}
+		}
+		addDeadTopicGroup(r.rsk, previous.Group)
+		delete(r.rsk.Status.PreviousReleases, topic)
+	}
+
+	return nil
+}
+
+// expiredPreviousReleases returns the topics whose retained table has expired
+func expiredPreviousReleases(rsk *tipocav1.RedshiftSink, now time.Time) []string {
+	topics := []string{}
+	for topic, previous := range rsk.Status.PreviousReleases {
+		if now.After(previous.ExpiresAt.Time) {
+			topics = append(topics, topic)
+		}
+	}
+	sortStringSlice(topics)
+
+	return topics
+}
+
+func (r *releaser) notifyTopicRollback(schema string, topic string) {
+	if r.notifier == nil {
+		return
+	}
+	_, _, table := transformer.ParseTopic(topic)
+	version := ""
+	topicStatus := currentTopicStatus(r.rsk, topic)
+	if topicStatus != nil {
+		version = topicStatus.Version
+	}
+	err := r.notifier.Notify(fmt.Sprintf(
+		"Rolled back table *%s.%s* to mask-version: %s.",
+		schema,
+		table,
+		masksource.VersionLink(r.maskSource, version),
+	))
+	if err != nil {
+		klog.Errorf("rsk/%s rollback notification failed, err: %v", r.rsk.Name, err)
+	}
+}
+
+// rollbackReleases rolls back the releases of the topics asked in the
+// RollbackReleaseAnnotation and drops the retained tables which have
+// expired. It returns true if any of the releases were rolled back.
+func (r *RedshiftSinkReconciler) rollbackReleases(
+	ctx context.Context,
+	rsk *tipocav1.RedshiftSink,
+	status *status,
+	secret map[string]string,
+	maskSource masksource.Source,
+	currentVersion string,
+	desiredVersion string,
+	patcher *statusPatcher,
+) (
+	bool,
+	error,
+) {
+	topics := annotationList(rsk, tipocav1.RollbackReleaseAnnotation)
+	now := time.Now()
+	if len(topics) == 0 && len(expiredPreviousReleases(rsk, now)) == 0 {
+		return false, nil
+	}
+
+	releaser, err := newReleaser(
+		maskSource,
+		currentVersion,
+		desiredVersion,
+		secret,
+		rsk,
+	)
+	if err != nil {
+		return false, fmt.Errorf("Error making releaser, err: %v", err)
+	}
+
+	schema := rsk.Spec.Loader.RedshiftSchema
+	processed := []string{}
+	var rollbackErr error
+	for _, topic := range topics {
+		err := rollbackAllowed(rsk, topic)
+		if err != nil {
+			klog.Warningf("rsk/%s topic: %s, rollback ignored: %v",
+				rsk.Name, topic, err)
+			processed = append(processed, topic)
+			continue
+		}
+		rollbackErr = releaser.rollback(
+			ctx,
+			schema,
+			topic,
+			rsk.Spec.Loader.RedshiftGroup,
+			status,
+			patcher,
+		)
+		if rollbackErr != nil {
+			rollbackErr = fmt.Errorf(
+				"Error rolling back topic: %s, err: %v", topic, rollbackErr)
+			break
+		}
+		processed = append(processed, topic)
+		releaser.notifyTopicRollback(schema, topic)
+	}
+	if len(processed) > 0 {
+		err := r.removeFromAnnotationList(
+			ctx, rsk, tipocav1.RollbackReleaseAnnotation, processed)
+		if err != nil {
+			klog.Errorf("rsk/%s error removing rollbacks from annotation, err: %v",
+				rsk.Name, err)
+		}
+	}
+	if rollbackErr != nil {
+		return !patcher.allowMain, rollbackErr
+	}
+	// status changes of the rollbacks are patched already
+	if !patcher.allowMain {
+		return true, nil
+	}
+
+	return false, releaser.dropExpiredPreviousReleases(ctx, schema, now)
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPreviousTableName(t *testing.T) {
+	t.Parallel()
+
+	version := "2932aac7f7c2ffc7a6d2c9f5e7a8bb6cc17a53ab"
+	saltedVersion := "2932aac7f7c2ffc7a6d2c9f5e7a8bb6cc17a53ab-Salt"
+	empty := ""
+
+	tests := []struct {
+		name     string
+		version  *string
+		expected string
+	}{
+		{name: "test1: no version", version: nil, expected: "orders_previous"},
+		{name: "test2: empty version", version: &empty, expected: "orders_previous"},
+		{name: "test3: version", version: &version, expected: "orders_previous_2932aa"},
+		{
+			name:     "test4: salted version",
+			version:  &saltedVersion,
+			expected: "orders_previous_" + "2932aa_salt",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := previousTableName("orders", tc.version)
+			if got != tc.expected {
+				t.Errorf("expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRollbackAllowed(t *testing.T) {
+	t.Parallel()
+
+	rsk := &tipocav1.RedshiftSink{
+		Status: tipocav1.RedshiftSinkStatus{
+			MaskStatus: &tipocav1.MaskStatus{
+				CurrentMaskStatus: map[string]tipocav1.TopicMaskStatus{
+					"db.inventory.orders":    {Phase: tipocav1.MaskActive},
+					"db.inventory.customers": {Phase: tipocav1.MaskReloading},
+					"db.inventory.products":  {Phase: tipocav1.MaskActive},
+				},
+			},
+			PreviousReleases: map[string]tipocav1.PreviousRelease{
+				"db.inventory.orders":    {Table: "orders_previous"},
+				"db.inventory.customers": {Table: "customers_previous"},
+			},
+		},
+	}
+
+	tests := []struct {
+		topic   string
+		allowed bool
+	}{
+		{topic: "db.inventory.orders", allowed: true},
+		{topic: "db.inventory.customers", allowed: false},
+		{topic: "db.inventory.products", allowed: false},
+	}
+
+	for _, tc := range tests {
+		err := rollbackAllowed(rsk, tc.topic)
+		if (err == nil) != tc.allowed {
+			t.Errorf("topic: %s, expected allowed: %v, got err: %v",
+				tc.topic, tc.allowed, err)
+		}
+	}
+}
+
+func TestExpiredPreviousReleases(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 3, 10, 10, 0, 0, 0, time.UTC)
+	rsk := &tipocav1.RedshiftSink{
+		Status: tipocav1.RedshiftSinkStatus{
+			PreviousReleases: map[string]tipocav1.PreviousRelease{
+				"db.inventory.orders": {
+					ExpiresAt: metav1.NewTime(now.Add(-time.Minute)),
+				},
+				"db.inventory.customers": {
+					ExpiresAt: metav1.NewTime(now.Add(time.Minute)),
+				},
+				"db.inventory.invoices": {
+					ExpiresAt: metav1.NewTime(now.Add(-time.Hour)),
+				},
+			},
+		},
+	}
+
+	expected := []string{"db.inventory.invoices", "db.inventory.orders"}
+	got := expiredPreviousReleases(rsk, now)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+}
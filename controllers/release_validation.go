@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	klog "github.com/practo/klog/v2"
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	"github.com/practo/tipoca-stream/pkg/redshift"
+	"github.com/practo/tipoca-stream/pkg/transformer"
+)
+
+// validationFailure is a failed pre-release check. The check does not
+// change between the runs, the detail has the numbers of the run.
+type validationFailure struct {
+	check  string
+	detail string
+}
+
+// releaseValidationError is returned when the reloaded table fails the
+// pre-release checks, the release of the topic is blocked till they pass.
+type releaseValidationError struct {
+	topic    string
+	failures []validationFailure
+}
+
+func (e *releaseValidationError) Error() string {
+	details := []string{}
+	for _, failure := range e.failures {
+		details = append(details, failure.detail)
+	}
+
+	return fmt.Sprintf(
+		"topic: %s failed release validation: %s",
+		e.topic,
+		strings.Join(details, "; "),
+	)
+}
+
+// failedChecks describes the failed checks without the numbers of the
+// run so that it only changes when different checks fail.
+func (e *releaseValidationError) failedChecks() string {
+	checks := []string{}
+	for _, failure := range e.failures {
+		checks = append(checks, failure.check)
+	}
+
+	return fmt.Sprintf(
+		"topic: %s failed release validation checks: %s",
+		e.topic,
+		strings.Join(checks, ", "),
+	)
+}
+
+// releaseValidation returns the pre-release checks of the topic.
+// TopicReleaseCondition takes precedence over the ReleaseCondition.
+func releaseValidation(
+	rsk *tipocav1.RedshiftSink,
+	topic string,
+) *tipocav1.ReleaseValidation {
+	var validation *tipocav1.ReleaseValidation
+	if rsk.Spec.ReleaseCondition != nil {
+		validation = rsk.Spec.ReleaseCondition.Validation
+	}
+	condition, ok := rsk.Spec.TopicReleaseCondition[topic]
+	if ok && condition.Validation != nil {
+		validation = condition.Validation
+	}
+
+	return validation
+}
+
+// rowCountDeviationPercent returns the deviation in percent of the
+// row count of the reloaded table from the row count of the live table.
+func rowCountDeviationPercent(live, reloaded int64) float64 {
+	if live == 0 {
+		if reloaded == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+
+	return math.Abs(float64(reloaded-live)) * 100 / float64(live)
+}
+
+// renderAssertion replaces {table} and {liveTable} in the assertion query
+func renderAssertion(query, schema, table, liveTable string) string {
+	return strings.NewReplacer(
+		"{table}", fmt.Sprintf(`"%s"."%s"`, schema, table),
+		"{liveTable}", fmt.Sprintf(`"%s"."%s"`, schema, liveTable),
+	).Replace(query)
+}
+
+func primaryKeys(table *redshift.Table) []string {
+	keys := []string{}
+	for _, column := range table.Columns {
+		if column.PrimaryKey {
+			keys = append(keys, column.Name)
+		}
+	}
+
+	return keys
+}
+
+// validate runs the pre-release checks on the reloaded table of the topic
+// in a read only transaction. releaseValidationError is returned when any
+// of the checks fail, other errors are returned when the checks could
+// not be run.
+func (r *releaser) validate(
+	ctx context.Context,
+	schema string,
+	topic string,
+	tableSuffix string,
+) error {
+	validation := releaseValidation(r.rsk, topic)
+	if validation == nil {
+		return nil
+	}
+	_, _, table := transformer.ParseTopic(topic)
+	reloadedTable := table + tableSuffix
+
+	tx, err := r.redshifter.BeginReadOnly(ctx)
+	if err != nil {
+		return fmt.Errorf("Error creating read only tx, err: %v", err)
+	}
+	defer func() {
+		err := tx.Rollback()
+		if err != nil {
+			klog.Errorf("Error closing read only tx, err: %v", err)
+		}
+	}()
+
+	failures := []validationFailure{}
+	if validation.MaxRowCountDeviationPercent != nil {
+		liveExist, err := r.redshifter.TableExist(ctx, schema, table)
+		if err != nil {
+			return err
+		}
+		if liveExist {
+			live, err := r.redshifter.RowCount(ctx, tx, schema, table)
+			if err != nil {
+				return err
+			}
+			reloaded, err := r.redshifter.RowCount(ctx, tx, schema, reloadedTable)
+			if err != nil {
+				return err
+			}
+			maxDeviation := *validation.MaxRowCountDeviationPercent
+			deviation := rowCountDeviationPercent(live, reloaded)
+			if deviation > float64(maxDeviation) {
+				failures = append(failures, validationFailure{
+					check: "maxRowCountDeviationPercent",
+					detail: fmt.Sprintf(
+						"row count: %d deviates %.2f%% from the live row count: %d, allowed: %d%%",
+						reloaded, deviation, live, maxDeviation),
+				})
+			}
+		}
+	}
+
+	if validation.UniquePrimaryKeys || validation.NotNullPrimaryKeys {
+		meta, err := r.redshifter.GetTableMetadata(ctx, schema, reloadedTable)
+		if err != nil {
+			return err
+		}
+		keys := primaryKeys(meta)
+		if len(keys) == 0 {
+			klog.Warningf(
+				"rsk/%s table: %s has no primary key, skipped key validations",
+				r.rsk.Name, reloadedTable)
+		}
+		if len(keys) > 0 && validation.UniquePrimaryKeys {
+			duplicates, err := r.redshifter.DuplicateKeyCount(
+				ctx, tx, schema, reloadedTable, keys)
+			if err != nil {
+				return err
+			}
+			if duplicates > 0 {
+				failures = append(failures, validationFailure{
+					check: "uniquePrimaryKeys",
+					detail: fmt.Sprintf(
+						"%d primary keys %v are duplicate", duplicates, keys),
+				})
+			}
+		}
+		if len(keys) > 0 && validation.NotNullPrimaryKeys {
+			nulls, err := r.redshifter.NullKeyCount(
+				ctx, tx, schema, reloadedTable, keys)
+			if err != nil {
+				return err
+			}
+			if nulls > 0 {
+				failures = append(failures, validationFailure{
+					check: "notNullPrimaryKeys",
+					detail: fmt.Sprintf(
+						"%d rows have null primary keys %v", nulls, keys),
+				})
+			}
+		}
+	}
+
+	for _, assertion := range validation.Assertions {
+		query := renderAssertion(assertion.SQL, schema, reloadedTable, table)
+		value, err := r.redshifter.QueryInt64(ctx, tx, query)
+		if err != nil {
+			// the tx is aborted on error, the rest can not be run
+			failures = append(failures, validationFailure{
+				check: fmt.Sprintf("assertion: %s could not be run", assertion.Name),
+				detail: fmt.Sprintf(
+					"assertion: %s could not be run, err: %v",
+					assertion.Name, strings.TrimSpace(err.Error())),
+			})
+			break
+		}
+		if value != 0 {
+			failures = append(failures, validationFailure{
+				check: fmt.Sprintf("assertion: %s", assertion.Name),
+				detail: fmt.Sprintf(
+					"assertion: %s returned %d", assertion.Name, value),
+			})
+		}
+	}
+
+	if len(failures) > 0 {
+		return &releaseValidationError{topic: topic, failures: failures}
+	}
+
+	return nil
+}
+
+// validatedCandidates runs the pre-release checks on the topics in order
+// and returns at max maxTopics topics which passed them. The failed checks
+// are recorded in the status and are notified when they change.
+func (r *releaser) validatedCandidates(
+	ctx context.Context,
+	topics []string,
+	tableSuffix string,
+	maxTopics int,
+) (
+	[]string,
+	error,
+) {
+	candidates := []string{}
+	for _, topic := range topics {
+		if len(candidates) >= maxTopics {
+			break
+		}
+		err := r.validate(ctx, r.schema, topic, tableSuffix)
+		var validationErr *releaseValidationError
+		if errors.As(err, &validationErr) {
+			r.recordValidationFailure(topic, validationErr)
+			continue
+		}
+		if err != nil {
+			return candidates, fmt.Errorf(
+				"Error validating topic: %s, err: %v", topic, err)
+		}
+		delete(r.rsk.Status.ReleaseValidationFailures, topic)
+		candidates = append(candidates, topic)
+	}
+
+	return candidates, nil
+}
+
+// recordValidationFailure logs the failure with the numbers of the run,
+// only the failed checks are kept in the status and notified. The numbers
+// change every run on a busy table and would update the status and
+// notify on every reconcile.
+func (r *releaser) recordValidationFailure(
+	topic string, err *releaseValidationError) {
+	klog.Errorf("rsk/%s %v", r.rsk.Name, err)
+
+	failure := err.failedChecks()
+	if r.rsk.Status.ReleaseValidationFailures[topic] == failure {
+		return
+	}
+	if r.rsk.Status.ReleaseValidationFailures == nil {
+		r.rsk.Status.ReleaseValidationFailures = make(map[string]string)
+	}
+	r.rsk.Status.ReleaseValidationFailures[topic] = failure
+
+	if r.notifier == nil {
+		return
+	}
+	notifyErr := r.notifier.Notify(fmt.Sprintf(
+		"%s release blocked, %s", r.rsk.Name, failure))
+	if notifyErr != nil {
+		klog.Errorf("rsk/%s notify validation failure failed: %v",
+			r.rsk.Name, notifyErr)
+	}
+}
+
+// pruneReleaseValidationFailures removes the validation failures of the
+// topics which are no more realtime.
+func pruneReleaseValidationFailures(
+	rsk *tipocav1.RedshiftSink,
+	realtime []string,
+) {
+	realtimeTopics := toMap(realtime)
+	for topic := range rsk.Status.ReleaseValidationFailures {
+		if !realtimeTopics[topic] {
+			delete(rsk.Status.ReleaseValidationFailures, topic)
+		}
+	}
+	if len(rsk.Status.ReleaseValidationFailures) == 0 {
+		rsk.Status.ReleaseValidationFailures = nil
+	}
+}
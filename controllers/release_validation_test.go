@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+)
+
+func TestRowCountDeviationPercent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		live      int64
+		reloaded  int64
+		deviation float64
+	}{
+		{name: "test1: same", live: 200, reloaded: 200, deviation: 0},
+		{name: "test2: less", live: 200, reloaded: 190, deviation: 5},
+		{name: "test3: more", live: 200, reloaded: 210, deviation: 5},
+		{name: "test4: both empty", live: 0, reloaded: 0, deviation: 0},
+		{name: "test5: live empty", live: 0, reloaded: 10, deviation: math.Inf(1)},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			deviation := rowCountDeviationPercent(tc.live, tc.reloaded)
+			if deviation != tc.deviation {
+				t.Errorf("expected: %v, got: %v", tc.deviation, deviation)
+			}
+		})
+	}
+}
+
+func TestRenderAssertion(t *testing.T) {
+	t.Parallel()
+
+	query := renderAssertion(
+		"SELECT COUNT(*) FROM {table} r LEFT JOIN {liveTable} l ON r.id = l.id WHERE l.id IS NULL",
+		"inventory",
+		"orders_reload_1a2b3c",
+		"orders",
+	)
+	expected := `SELECT COUNT(*) FROM "inventory"."orders_reload_1a2b3c" r LEFT JOIN "inventory"."orders" l ON r.id = l.id WHERE l.id IS NULL`
+	if query != expected {
+		t.Errorf("expected: %v, got: %v", expected, query)
+	}
+}
+
+func TestReleaseValidation(t *testing.T) {
+	t.Parallel()
+
+	deviation := int32(5)
+	sinkValidation := &tipocav1.ReleaseValidation{UniquePrimaryKeys: true}
+	topicValidation := &tipocav1.ReleaseValidation{
+		MaxRowCountDeviationPercent: &deviation,
+	}
+	rsk := &tipocav1.RedshiftSink{
+		Spec: tipocav1.RedshiftSinkSpec{
+			ReleaseCondition: &tipocav1.ReleaseCondition{
+				Validation: sinkValidation,
+			},
+			TopicReleaseCondition: map[string]tipocav1.ReleaseCondition{
+				"db.inventory.orders":    {Validation: topicValidation},
+				"db.inventory.customers": {},
+			},
+		},
+		Status: tipocav1.RedshiftSinkStatus{
+			ReleaseValidationFailures: map[string]string{
+				"db.inventory.orders":   "failed",
+				"db.inventory.products": "failed",
+			},
+		},
+	}
+
+	if releaseValidation(rsk, "db.inventory.orders") != topicValidation {
+		t.Errorf("expected topic validation for db.inventory.orders")
+	}
+	if releaseValidation(rsk, "db.inventory.customers") != sinkValidation {
+		t.Errorf("expected sink validation for db.inventory.customers")
+	}
+
+	pruneReleaseValidationFailures(rsk, []string{"db.inventory.orders"})
+	expected := map[string]string{"db.inventory.orders": "failed"}
+	if !reflect.DeepEqual(rsk.Status.ReleaseValidationFailures, expected) {
+		t.Errorf("expected failures: %v, got: %v",
+			expected, rsk.Status.ReleaseValidationFailures)
+	}
+
+	pruneReleaseValidationFailures(rsk, []string{})
+	if rsk.Status.ReleaseValidationFailures != nil {
+		t.Errorf("expected no failures, got: %v",
+			rsk.Status.ReleaseValidationFailures)
+	}
+}
+
+type fakeNotifier struct {
+	messages []string
+}
+
+func (n *fakeNotifier) Notify(message string) error {
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func TestRecordValidationFailure(t *testing.T) {
+	t.Parallel()
+
+	topic := "db.inventory.orders"
+	notifier := &fakeNotifier{}
+	r := &releaser{
+		notifier: notifier,
+		rsk:      &tipocav1.RedshiftSink{},
+	}
+
+	runs := []*releaseValidationError{
+		{
+			topic: topic,
+			failures: []validationFailure{
+				{check: "maxRowCountDeviationPercent", detail: "row count: 190"},
+				{check: "uniquePrimaryKeys", detail: "2 primary keys"},
+			},
+		},
+		{
+			topic: topic,
+			failures: []validationFailure{
+				{check: "maxRowCountDeviationPercent", detail: "row count: 195"},
+				{check: "uniquePrimaryKeys", detail: "3 primary keys"},
+			},
+		},
+		{
+			topic: topic,
+			failures: []validationFailure{
+				{check: "uniquePrimaryKeys", detail: "3 primary keys"},
+			},
+		},
+	}
+	for _, run := range runs {
+		r.recordValidationFailure(topic, run)
+	}
+
+	expected := "topic: db.inventory.orders failed release validation checks: uniquePrimaryKeys"
+	if r.rsk.Status.ReleaseValidationFailures[topic] != expected {
+		t.Errorf("expected failure: %v, got: %v",
+			expected, r.rsk.Status.ReleaseValidationFailures[topic])
+	}
+	if len(notifier.messages) != 2 {
+		t.Errorf("expected 2 notifications for the changed checks, got: %v",
+			notifier.messages)
+	}
+}
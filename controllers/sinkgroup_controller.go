@@ -19,9 +19,9 @@ import (
 
 const (
 	AllSinkGroup        = "all"
-	MainSinkGroup       = "main"
-	ReloadSinkGroup     = "reload"
-	ReloadDupeSinkGroup = "reload-dupe"
+	MainSinkGroup       = tipocav1.MainSinkGroup
+	ReloadSinkGroup     = tipocav1.ReloadSinkGroup
+	ReloadDupeSinkGroup = tipocav1.ReloadDupeSinkGroup
 
 	DefaultMaxBatcherLag = int64(100)
 	DefautMaxLoaderLag   = int64(10)
@@ -146,6 +146,13 @@ func (sb *buildSinkGroup) buildBatchers(
 	tlsConfig *kafka.TLSConfig,
 ) sinkGroupBuilder {
 	batchers := []Deployment{}
+	// rolled back topics are batched with the mask version of their table
+	topics := sb.topics
+	var rolledBack map[string][]string
+	if sb.sgType == MainSinkGroup && sb.rsk.Spec.Batcher.Mask {
+		topics, rolledBack = rolledBackTopicsByVersion(
+			sb.rsk, sb.topics, sb.maskVersion)
+	}
 	if sb.rsk.Spec.Batcher.SinkGroup != nil {
 		var sinkGroupSpec, mainSinkGroupSpec *tipocav1.SinkGroupSpec
 		sinkGroupSpec = applyBatcherSinkGroupDefaults(
@@ -175,9 +182,18 @@ func (sb *buildSinkGroup) buildBatchers(
 			)
 			allocator.allocateReloadingUnits()
 			units = allocator.units
+		} else if sb.sgType == MainSinkGroup && sinkGroupSpec.Autoscale != nil {
+			units = allocateAutoscaledUnits(
+				topics,
+				sb.rsk.Status.BatcherDedicatedTopics,
+				sinkGroupSpec,
+				100,
+			)
 		} else { // MainSinkGroup or ReloadDupeSinkGroup
-			units = allocateUnitWithChunks(sb.topics, sinkGroupSpec, 100)
+			units = allocateUnitWithChunks(topics, sinkGroupSpec, 100)
 		}
+		units = append(units, allocateRolledBackUnits(
+			rolledBack, sinkGroupSpec, 100)...)
 
 		for _, unit := range units {
 			consumerGroups, err := computeConsumerGroups(
@@ -186,10 +202,14 @@ func (sb *buildSinkGroup) buildBatchers(
 				klog.Fatalf(
 					"Error computing consumer group from status, err: %v", err)
 			}
+			maskVersion := sb.maskVersion
+			if unit.maskVersion != "" {
+				maskVersion = unit.maskVersion
+			}
 			batcher, err := NewBatcher(
 				batcherName(sb.rsk.Name, sb.sgType, unit.id),
 				sb.rsk,
-				sb.maskVersion,
+				maskVersion,
 				secret,
 				sb.sgType,
 				unit.sinkGroupSpec,
@@ -204,27 +224,37 @@ func (sb *buildSinkGroup) buildBatchers(
 			batchers = append(batchers, batcher)
 		}
 	} else { // Deprecated
-		consumerGroups, err := computeConsumerGroups(sb.topicGroups, sb.topics)
-		if err != nil {
-			klog.Fatalf(
-				"Error computing consumer group from status, err: %v", err)
-		}
-		batcher, err := NewBatcher(
-			batcherName(sb.rsk.Name, sb.sgType, ""),
-			sb.rsk,
-			sb.maskVersion,
-			secret,
-			sb.sgType,
-			nil,
-			consumerGroups,
-			defaultImage,
-			defaultKafkaVersion,
-			tlsConfig,
-		)
-		if err != nil {
-			klog.Fatalf("Error making batcher: %v", err)
+		units := []deploymentUnit{{topics: topics}}
+		units = append(units, allocateRolledBackUnits(
+			rolledBack, nil, len(sb.topics))...)
+		for _, unit := range units {
+			consumerGroups, err := computeConsumerGroups(
+				sb.topicGroups, unit.topics)
+			if err != nil {
+				klog.Fatalf(
+					"Error computing consumer group from status, err: %v", err)
+			}
+			maskVersion := sb.maskVersion
+			if unit.maskVersion != "" {
+				maskVersion = unit.maskVersion
+			}
+			batcher, err := NewBatcher(
+				batcherName(sb.rsk.Name, sb.sgType, unit.id),
+				sb.rsk,
+				maskVersion,
+				secret,
+				sb.sgType,
+				nil,
+				consumerGroups,
+				defaultImage,
+				defaultKafkaVersion,
+				tlsConfig,
+			)
+			if err != nil {
+				klog.Fatalf("Error making batcher: %v", err)
+			}
+			batchers = append(batchers, batcher)
 		}
-		batchers = append(batchers, batcher)
 	}
 
 	sb.batchers = batchers
@@ -276,9 +306,16 @@ func (sb *buildSinkGroup) buildLoaders(
 				units = append(units, deploymentUnit{
 					id:            unit.id,
 					sinkGroupSpec: unit.sinkGroupSpec,
-					topics:        makeBatcherTopics(unit.topics),
+					topics:        makeBatcherTopics(sb.topicGroups, unit.topics),
 				})
 			}
+		} else if sb.sgType == MainSinkGroup && sinkGroupSpec.Autoscale != nil {
+			units = allocateAutoscaledUnits(
+				sb.topics,
+				sb.rsk.Status.LoaderDedicatedTopics,
+				sinkGroupSpec,
+				100,
+			)
 		} else { // MainSinkGroup or ReloadDupeSinkGroup
 			units = allocateUnitWithChunks(sb.topics, sinkGroupSpec, 100)
 		}
@@ -383,14 +420,7 @@ func topicGroupBySinkGroup(
 	switch sinkGroupName {
 	case ReloadSinkGroup:
 		for _, topic := range topics {
-			groupID := groupIDFromTopicVersion(topic, version)
-			groups[topic] = tipocav1.Group{
-				ID: groupID,
-				LoaderTopicPrefix: loaderPrefixFromVersion(
-					prefix,
-					version,
-				),
-			}
+			groups[topic] = reloadTopicGroup(rsk, topic, version, prefix)
 		}
 		return groups
 	case ReloadDupeSinkGroup:
@@ -432,21 +462,11 @@ func consumerGroupID(rskName, rskNamespace, groupID string, suffix string) strin
 func groupIDFromTopicVersion(topic string, version string) string {
 	_, _, table := transformer.ParseTopic(topic)
 
-	groupID := version
-	if len(version) >= 6 {
-		groupID = groupID[:6]
-	}
-
-	return fmt.Sprintf("%s-%s", table, groupID)
+	return fmt.Sprintf("%s-%s", table, shortMaskVersion(version))
 }
 
 func loaderPrefixFromVersion(prefix string, version string) string {
-	sVersion := version
-	if len(sVersion) >= 6 {
-		sVersion = sVersion[:6]
-	}
-
-	return prefix + sVersion + "-"
+	return prefix + shortMaskVersion(version) + "-"
 }
 
 type consumerGroup struct {
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	batcherconfig "github.com/practo/tipoca-stream/cmd/redshiftbatcher/config"
+	"github.com/practo/tipoca-stream/cmd/redshiftloader/config"
+	"github.com/practo/tipoca-stream/pkg/kafka"
+	yaml "gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testSecret() map[string]string {
+	secret := make(map[string]string)
+	for _, key := range []string{
+		"maskSalt",
+		"s3BatcherBucketDir",
+		"gitAccessToken",
+		"s3Region",
+		"s3Bucket",
+		"s3LoaderBucketDir",
+		"s3AccessKeyId",
+		"s3SecretAccessKey",
+		"schemaRegistryURL",
+		"redshiftHost",
+		"redshiftPort",
+		"redshiftDatabase",
+		"redshiftUser",
+		"redshiftPassword",
+	} {
+		secret[key] = key
+	}
+
+	return secret
+}
+
+// loaderConsumerGroups returns the topic regexes of the consumer groups
+// of the loaders, keyed by the consumer group id
+func loaderConsumerGroups(
+	t *testing.T,
+	loaders []Deployment,
+) map[string]string {
+	groups := make(map[string]string)
+	for _, loader := range loaders {
+		var conf config.Config
+		err := yaml.Unmarshal(
+			[]byte(loader.Config().Data["config.yaml"]), &conf)
+		if err != nil {
+			t.Fatalf("Error unmarshalling config of %s, err: %v",
+				loader.Name(), err)
+		}
+		for _, group := range conf.ConsumerGroups {
+			groups[group.GroupID] = group.TopicRegexes
+		}
+	}
+
+	return groups
+}
+
+func TestBuildReloadLoaders(t *testing.T) {
+	t.Parallel()
+
+	fileVersion := "6c557136b5f0ed1ee0e9cc1a1cfa1a2c5e45f1d0"
+	topics := []string{
+		"db.inventory.customers",
+		"db.inventory.orders",
+	}
+	reloadHash, err := getHashStructure("incident-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloadPrefix := "loader-6c5571-r" + reloadHash + "-"
+
+	tests := []struct {
+		name           string
+		version        string
+		maskStatus     *tipocav1.MaskStatus
+		realtime       []string
+		last           []topicLast
+		expectedTopics []string
+		expectedGroups map[string]string
+	}{
+		{
+			name:    "salt version",
+			version: maskVersion(fileVersion, "v2"),
+			realtime: []string{
+				"loader-6c5571-v2-db.inventory.customers",
+			},
+			last: []topicLast{
+				{topic: "loader-6c5571-v2-db.inventory.orders", last: 10},
+			},
+			expectedTopics: []string{
+				"loader-6c5571-v2-db.inventory.customers",
+				"loader-6c5571-v2-db.inventory.orders",
+			},
+			expectedGroups: map[string]string{
+				"ns-rsk-customers-6c5571-v2-loader": "^loader-6c5571-v2-db.inventory.customers$",
+				"ns-rsk-orders-6c5571-v2-loader":    "^loader-6c5571-v2-db.inventory.orders$",
+			},
+		},
+		{
+			name:    "manual reload",
+			version: fileVersion,
+			maskStatus: &tipocav1.MaskStatus{
+				CurrentMaskStatus: map[string]tipocav1.TopicMaskStatus{
+					"db.inventory.orders": {
+						Version:  fileVersion,
+						Phase:    tipocav1.MaskReloading,
+						ReloadID: "incident-1",
+					},
+				},
+			},
+			last: []topicLast{
+				{topic: reloadPrefix + "db.inventory.orders", last: 10},
+				{topic: "loader-6c5571-db.inventory.customers", last: 20},
+			},
+			expectedTopics: []string{
+				"loader-6c5571-db.inventory.customers",
+				reloadPrefix + "db.inventory.orders",
+			},
+			expectedGroups: map[string]string{
+				"ns-rsk-customers-6c5571-loader":                  "^loader-6c5571-db.inventory.customers$",
+				"ns-rsk-orders-6c5571-r" + reloadHash + "-loader": "^" + reloadPrefix + "db.inventory.orders$",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rsk := &tipocav1.RedshiftSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rsk",
+					Namespace: "ns",
+				},
+				Spec: tipocav1.RedshiftSinkSpec{
+					KafkaLoaderTopicPrefix: "loader-",
+					Loader: tipocav1.RedshiftLoaderSpec{
+						SinkGroup: &tipocav1.SinkGroup{},
+					},
+				},
+				Status: tipocav1.RedshiftSinkStatus{
+					MaskStatus: tc.maskStatus,
+				},
+			}
+			calc := &realtimeCalculator{
+				rsk:             rsk,
+				loadersRealtime: tc.realtime,
+				loadersLast:     tc.last,
+			}
+
+			sg := newSinkGroupBuilder().
+				setRedshiftSink(rsk).
+				setType(ReloadSinkGroup).
+				setTopics(topics).
+				setMaskVersion(tc.version).
+				setTopicGroups().
+				setRealtimeCalculator(calc).
+				buildLoaders(testSecret(), "loader", ReloadTableSuffix,
+					"2.6.0", &kafka.TLSConfig{}, 10, 10, "", false).
+				build()
+
+			gotTopics := sg.loaderDeploymentTopics()
+			sort.Strings(gotTopics)
+			if !reflect.DeepEqual(tc.expectedTopics, gotTopics) {
+				t.Errorf("expected topics: %v, got: %v",
+					tc.expectedTopics, gotTopics)
+			}
+
+			gotGroups := loaderConsumerGroups(t, sg.loaders)
+			if !reflect.DeepEqual(tc.expectedGroups, gotGroups) {
+				t.Errorf("expected consumer groups: %v, got: %v",
+					tc.expectedGroups, gotGroups)
+			}
+		})
+	}
+}
+
+func TestBuildMainBatchersRolledBack(t *testing.T) {
+	t.Parallel()
+
+	desiredVersion := "6c557136b5f0ed1ee0e9cc1a1cfa1a2c5e45f1d0"
+	rolledBackVersion := "ab1c2d36b5f0ed1ee0e9cc1a1cfa1a2c5e45f1d0"
+	topics := []string{
+		"db.inventory.customers",
+		"db.inventory.orders",
+	}
+	rsk := &tipocav1.RedshiftSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rsk",
+			Namespace: "ns",
+		},
+		Spec: tipocav1.RedshiftSinkSpec{
+			KafkaLoaderTopicPrefix: "loader-",
+			Batcher: tipocav1.RedshiftBatcherSpec{
+				Mask:      true,
+				MaskFile:  "https://github.com/practo/tipoca-stream/pkg/database.yaml",
+				SinkGroup: &tipocav1.SinkGroup{},
+			},
+		},
+		Status: tipocav1.RedshiftSinkStatus{
+			MaskStatus: &tipocav1.MaskStatus{
+				CurrentMaskStatus: map[string]tipocav1.TopicMaskStatus{
+					"db.inventory.customers": {
+						Version:         desiredVersion,
+						Phase:           tipocav1.MaskActive,
+						ReleasedVersion: &desiredVersion,
+					},
+					"db.inventory.orders": {
+						Version:         rolledBackVersion,
+						Phase:           tipocav1.MaskRolledBack,
+						ReleasedVersion: &rolledBackVersion,
+					},
+				},
+			},
+		},
+	}
+
+	sg := newSinkGroupBuilder().
+		setRedshiftSink(rsk).
+		setType(MainSinkGroup).
+		setTopics(topics).
+		setMaskVersion(desiredVersion).
+		setTopicGroups().
+		setRealtimeCalculator(nil).
+		buildBatchers(testSecret(), "batcher", "2.6.0", &kafka.TLSConfig{}).
+		build()
+
+	expected := map[string]string{
+		"db.inventory.customers": desiredVersion,
+		"db.inventory.orders":    rolledBackVersion,
+	}
+	got := make(map[string]string)
+	for _, batcher := range sg.batchers {
+		var conf batcherconfig.Config
+		err := yaml.Unmarshal(
+			[]byte(batcher.Config().Data["config.yaml"]), &conf)
+		if err != nil {
+			t.Fatalf("Error unmarshalling config of %s, err: %v",
+				batcher.Name(), err)
+		}
+		for _, topic := range batcher.Topics() {
+			got[topic] = conf.Batcher.MaskFileVersion
+		}
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected mask versions: %v, got: %v", expected, got)
+	}
+}
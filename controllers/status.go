@@ -5,6 +5,7 @@ import (
 	"fmt"
 	klog "github.com/practo/klog/v2"
 	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	masksource "github.com/practo/tipoca-stream/pkg/masksource"
 	"reflect"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
 	"strings"
@@ -22,6 +23,9 @@ type status struct {
 	realtime      []string
 	reloading     []string
 	reloadingDupe []string
+
+	// awaitingApproval are the realtime topics waiting for release approval
+	awaitingApproval []string
 }
 
 type statusBuilder interface {
@@ -53,6 +57,8 @@ type buildStatus struct {
 	realtime       []string
 	reloading      []string
 	reloadingDupe  []string
+
+	awaitingApproval []string
 }
 
 func (sb *buildStatus) setRedshiftSink(rsk *tipocav1.RedshiftSink) statusBuilder {
@@ -115,10 +121,21 @@ func (sb *buildStatus) computeReleased() statusBuilder {
 			if status.Phase == tipocav1.MaskActive && status.Version != sb.desiredVersion {
 				released = appendIfMissing(released, topic)
 			}
+			// rolled back topics keep sinking in the main sink group
+			if status.Phase == tipocav1.MaskRolledBack {
+				released = appendIfMissing(released, topic)
+			}
 		}
 	} else {
 		klog.V(2).Infof("rsk/%s, Status empty, released=0 ", sb.rsk.Name)
 	}
+
+	// topics asked to be reloaded in the spec are reloaded again
+	for _, topic := range released {
+		if manualReloadPending(sb.rsk, topic) {
+			released = removeFromSlice(released, topic)
+		}
+	}
 	sb.released = released
 	sortStringSlice(sb.released)
 
@@ -126,9 +143,16 @@ func (sb *buildStatus) computeReleased() statusBuilder {
 }
 
 func (sb *buildStatus) setRealtime() statusBuilder {
+	sb.awaitingApproval = currentTopicsByMaskStatus(
+		sb.rsk, tipocav1.MaskAwaitingApproval, sb.desiredVersion,
+	)
+	sortStringSlice(sb.awaitingApproval)
+
+	// topics awaiting approval are realtime
 	sb.realtime = currentTopicsByMaskStatus(
 		sb.rsk, tipocav1.MaskRealtime, sb.desiredVersion,
 	)
+	sb.realtime = append(sb.realtime, sb.awaitingApproval...)
 	sortStringSlice(sb.realtime)
 
 	return sb
@@ -169,6 +193,9 @@ func (sb *buildStatus) computeReloading() statusBuilder {
 				if curr.Phase == tipocav1.MaskReloading {
 					reConstructingReloading = appendIfMissing(reConstructingReloading, topic)
 				}
+				if manualReloadPending(sb.rsk, topic) {
+					reConstructingReloading = appendIfMissing(reConstructingReloading, topic)
+				}
 			}
 		} else {
 			klog.V(2).Infof("rsk/%s, Status empty, newly created topics left", sb.rsk.Name)
@@ -213,6 +240,8 @@ func (sb *buildStatus) build() *status {
 		realtime:       sb.realtime,
 		reloading:      sb.reloading,
 		reloadingDupe:  sb.reloadingDupe,
+
+		awaitingApproval: sb.awaitingApproval,
 	}
 
 	s.updateMaskStatus()
@@ -257,6 +286,15 @@ func currentReleasedVersion(rsk *tipocav1.RedshiftSink, topic string) *string {
 	return status.ReleasedVersion
 }
 
+func currentReloadID(rsk *tipocav1.RedshiftSink, topic string) string {
+	status := currentTopicStatus(rsk, topic)
+	if status == nil {
+		return ""
+	}
+
+	return status.ReloadID
+}
+
 func currentTopicsByMaskStatus(rsk *tipocav1.RedshiftSink, phase tipocav1.MaskPhase, version string) []string {
 	if rsk.Status.MaskStatus == nil ||
 		rsk.Status.MaskStatus.CurrentMaskStatus == nil {
@@ -294,6 +332,7 @@ func (s *status) overwrite(copyStatus *status) {
 	s.realtime = copyStatus.realtime
 	s.reloading = copyStatus.reloading
 	s.reloadingDupe = copyStatus.reloadingDupe
+	s.awaitingApproval = copyStatus.awaitingApproval
 }
 
 func (s *status) deepCopy() *status {
@@ -307,6 +346,8 @@ func (s *status) deepCopy() *status {
 		realtime:       s.realtime,
 		reloading:      s.reloading,
 		reloadingDupe:  s.reloadingDupe,
+
+		awaitingApproval: s.awaitingApproval,
 	}
 
 	return copy
@@ -321,6 +362,7 @@ func (s *status) info() {
 	klog.V(2).Infof("%s reloading:      %d %v", rskName, len(s.reloading), s.reloading)
 	klog.V(2).Infof("%s rDupe:          %d %v", rskName, len(s.reloadingDupe), s.reloadingDupe)
 	klog.V(2).Infof("%s realtime:       %d %v", rskName, len(s.realtime), s.realtime)
+	klog.V(2).Infof("%s approval:       %d %v", rskName, len(s.awaitingApproval), s.awaitingApproval)
 }
 
 // manyReloading checks the percentage of reloading topics of the total topics
@@ -344,11 +386,13 @@ func (s *status) updateTopicsOnRelease(releasedTopic string) {
 	s.reloading = removeFromSlice(s.reloading, releasedTopic)
 	s.reloadingDupe = removeFromSlice(s.reloadingDupe, releasedTopic)
 	s.realtime = removeFromSlice(s.realtime, releasedTopic)
+	s.awaitingApproval = removeFromSlice(s.awaitingApproval, releasedTopic)
 }
 
 func (s *status) computerCurrentMaskStatus() map[string]tipocav1.TopicMaskStatus {
 	topicsReleased := toMap(s.released)
 	topicsRealtime := toMap(s.realtime)
+	topicsAwaitingApproval := toMap(s.awaitingApproval)
 	topicsReloading := toMap(s.reloading)
 	status := make(map[string]tipocav1.TopicMaskStatus)
 
@@ -357,25 +401,38 @@ func (s *status) computerCurrentMaskStatus() map[string]tipocav1.TopicMaskStatus
 		// and the redshift schema operations for it is also done properly
 		_, ok := topicsReleased[topic]
 		if ok {
+			// rolled back topics stay at the version they were rolled back to
+			topicStatus := currentTopicStatus(s.rsk, topic)
+			if topicStatus != nil && topicStatus.Phase == tipocav1.MaskRolledBack {
+				status[topic] = *topicStatus
+				continue
+			}
 			status[topic] = tipocav1.TopicMaskStatus{
 				Version:         s.desiredVersion,
 				Phase:           tipocav1.MaskActive,
 				ReleasedVersion: &s.desiredVersion,
+				ReloadID:        currentReloadID(s.rsk, topic),
 			}
 			continue
 		}
 
 		releasedVersion := currentReleasedVersion(s.rsk, topic)
+		reloadID := nextReloadID(s.rsk, topic)
 
 		// the topic is waiting to get released, it has reached realtime
 		// release can happen any time soon, since it is one operation
 		// per reconcile, the topics might be there in this state
 		_, ok = topicsRealtime[topic]
 		if ok {
+			phase := tipocav1.MaskRealtime
+			if topicsAwaitingApproval[topic] {
+				phase = tipocav1.MaskAwaitingApproval
+			}
 			status[topic] = tipocav1.TopicMaskStatus{
 				Version:         s.desiredVersion,
-				Phase:           tipocav1.MaskRealtime,
+				Phase:           phase,
 				ReleasedVersion: releasedVersion,
+				ReloadID:        reloadID,
 			}
 			continue
 		}
@@ -387,6 +444,7 @@ func (s *status) computerCurrentMaskStatus() map[string]tipocav1.TopicMaskStatus
 				Version:         s.desiredVersion,
 				Phase:           tipocav1.MaskReloading,
 				ReleasedVersion: releasedVersion,
+				ReloadID:        reloadID,
 			}
 			continue
 		}
@@ -403,6 +461,7 @@ func (s *status) computerCurrentMaskStatus() map[string]tipocav1.TopicMaskStatus
 			Version:         s.desiredVersion,
 			Phase:           tipocav1.MaskActive,
 			ReleasedVersion: &s.desiredVersion,
+			ReloadID:        s.rsk.Spec.ReloadTopics[topic],
 		}
 	}
 
@@ -423,8 +482,7 @@ func (s *status) computeDesiredMaskStatus() map[string]tipocav1.TopicMaskStatus
 
 func (s *status) notifyRelease(
 	secret map[string]string,
-	repo string,
-	filePath string,
+	maskSource masksource.Source,
 ) {
 	if len(s.allTopics) == len(s.released) &&
 		len(s.reloading) == 0 && len(s.realtime) == 0 {
@@ -436,9 +494,10 @@ func (s *status) notifyRelease(
 			return
 		}
 
-		sha := s.desiredVersion
-		if len(s.desiredVersion) >= 6 {
-			sha = s.desiredVersion[:6]
+		fileVersion, saltVersion := splitMaskVersion(s.desiredVersion)
+		sha := fileVersion
+		if len(fileVersion) >= 6 {
+			sha = fileVersion[:6]
 		}
 		message := fmt.Sprintf(
 			"%s has %d tables live",
@@ -451,13 +510,13 @@ func (s *status) notifyRelease(
 			return
 		}
 		releaseMessage := fmt.Sprintf(
-			"%s with mask-version: <https://github.com/%s/blob/%s/%s | %s>",
+			"%s with mask-version: %s",
 			message,
-			repo,
-			s.desiredVersion,
-			filePath,
-			sha,
+			masksource.VersionLink(maskSource, fileVersion),
 		)
+		if saltVersion != "" {
+			releaseMessage += fmt.Sprintf(" and mask-salt-version: %s", saltVersion)
+		}
 		err := notifier.Notify(releaseMessage)
 		if err != nil {
 			klog.Errorf("release notification failed, err: %v", err)
@@ -477,25 +536,34 @@ func (s *status) updateMaskStatus() {
 	}
 
 	maskStatus := tipocav1.MaskStatus{
-		CurrentMaskStatus:  s.computerCurrentMaskStatus(),
-		DesiredMaskStatus:  s.computeDesiredMaskStatus(),
-		CurrentMaskVersion: currentVersion,
-		DesiredMaskVersion: &s.desiredVersion,
+		CurrentMaskStatus:      s.computerCurrentMaskStatus(),
+		DesiredMaskStatus:      s.computeDesiredMaskStatus(),
+		CurrentMaskVersion:     currentVersion,
+		DesiredMaskVersion:     &s.desiredVersion,
+		CurrentMaskSaltVersion: maskSaltVersion(*currentVersion),
+		DesiredMaskSaltVersion: maskSaltVersion(s.desiredVersion),
 	}
 	s.rsk.Status.MaskStatus = &maskStatus
+	s.rsk.Status.Topics = topicPhaseCounts(&maskStatus)
+}
+
+// maskSaltVersion returns the salt version of the mask version,
+// nil is returned when the salt is not versioned.
+func maskSaltVersion(version string) *string {
+	_, saltVersion := splitMaskVersion(version)
+	if saltVersion == "" {
+		return nil
+	}
+
+	return &saltVersion
 }
 
 func (s *status) updateTopicGroup(topic string) {
 	klog.V(5).Infof("updating topic group: %s %+v", topic, s.rsk.Status)
 
-	groupID := groupIDFromTopicVersion(topic, s.desiredVersion)
-	prefix := loaderPrefixFromVersion(s.rsk.Spec.KafkaLoaderTopicPrefix, s.desiredVersion)
-
-	group := tipocav1.Group{
-		LoaderCurrentOffset: nil, // Deprecated
-		LoaderTopicPrefix:   prefix,
-		ID:                  groupID,
-	}
+	group := reloadTopicGroup(
+		s.rsk, topic, s.desiredVersion, s.rsk.Spec.KafkaLoaderTopicPrefix)
+	group.LoaderCurrentOffset = nil // Deprecated
 	updateTopicGroup(s.rsk, topic, group)
 }
 
@@ -571,10 +639,12 @@ func (s *status) fixMaskStatus() {
 			s.reloading = []string{}
 
 			maskStatus := tipocav1.MaskStatus{
-				CurrentMaskStatus:  s.computerCurrentMaskStatus(),
-				DesiredMaskStatus:  s.computeDesiredMaskStatus(),
-				CurrentMaskVersion: &s.currentVersion,
-				DesiredMaskVersion: &s.desiredVersion,
+				CurrentMaskStatus:      s.computerCurrentMaskStatus(),
+				DesiredMaskStatus:      s.computeDesiredMaskStatus(),
+				CurrentMaskVersion:     &s.currentVersion,
+				DesiredMaskVersion:     &s.desiredVersion,
+				CurrentMaskSaltVersion: maskSaltVersion(s.currentVersion),
+				DesiredMaskSaltVersion: maskSaltVersion(s.desiredVersion),
 			}
 			s.rsk.Status.MaskStatus = &maskStatus
 			klog.V(2).Infof("rsk/%s fixed maskStatus: %+v", s.rsk.Name, maskStatus)
@@ -587,7 +657,8 @@ func (s *status) deleteLoaderTopicGroupCurrentOffset(topic string) {
 		return
 	}
 
-	groupID := groupIDFromTopicVersion(topic, s.desiredVersion)
+	groupID := reloadTopicGroup(
+		s.rsk, topic, s.desiredVersion, s.rsk.Spec.KafkaLoaderTopicPrefix).ID
 	tg := fmt.Sprintf("%s-%s", topic, groupID)
 
 	delete(s.rsk.Status.LoaderTopicGroupCurrentOffset, tg)
@@ -628,6 +699,17 @@ func addDeadConsumerGroups(rsk *tipocav1.RedshiftSink, consumerGroupID string) {
 	rsk.Status.DeadConsumerGroups = append(rsk.Status.DeadConsumerGroups, consumerGroupID)
 }
 
+// addDeadTopicGroup stores the batcher and loader consumer groups
+// of the topic group to be cleaned up
+func addDeadTopicGroup(rsk *tipocav1.RedshiftSink, group tipocav1.Group) {
+	addDeadConsumerGroups(rsk, consumerGroupID(
+		rsk.Name, rsk.Namespace, group.ID, "-batcher"),
+	)
+	addDeadConsumerGroups(rsk, consumerGroupID(
+		rsk.Name, rsk.Namespace, group.ID, "-loader"),
+	)
+}
+
 // statusPatcher is used to update the status of rsk
 type statusPatcher struct {
 	client client.Client
@@ -5,6 +5,7 @@ import (
 	"github.com/practo/klog/v2"
 	tipocav1 "github.com/practo/tipoca-stream/api/v1"
 	transformer "github.com/practo/tipoca-stream/pkg/transformer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sort"
 	"strings"
 )
@@ -53,6 +54,9 @@ type deploymentUnit struct {
 	id            string
 	sinkGroupSpec *tipocav1.SinkGroupSpec
 	topics        []string
+	// maskVersion is the mask version of the unit when it differs from
+	// the mask version of the sink group
+	maskVersion string
 }
 
 func sortTopicsByLastOffset(topicsLast []topicLast) []string {
@@ -75,6 +79,11 @@ func k8sCompatibleName(name string) string {
 }
 
 func (u *unitAllocator) unitID(topic string) string {
+	return topicUnitID(topic)
+}
+
+// topicUnitID is the id of the unit running only the topic
+func topicUnitID(topic string) string {
 	_, _, table := transformer.ParseTopic(topic)
 
 	table = k8sCompatibleName(table)
@@ -214,3 +223,81 @@ func allocateUnitWithChunks(
 
 	return units
 }
+
+// allocateAutoscaledUnits allocates a unit to each of the dedicated topics
+// and spreads the rest of the topics across the shared units, used by the
+// MainSinkGroup when autoscaling is specified. The shared units are as many
+// as needed to keep the total units at MinUnits, there is always at least
+// one shared unit and the total is kept within MaxUnits unless the chunkSize
+// needs more shared units.
+func allocateAutoscaledUnits(
+	topics []string,
+	dedicatedTopics map[string]metav1.Time,
+	sinkGroupSpec *tipocav1.SinkGroupSpec,
+	chunkSize int,
+) []deploymentUnit {
+	autoscale := sinkGroupSpec.Autoscale
+	units := []deploymentUnit{}
+	ids := make(map[string]bool)
+	shared := []string{}
+	for _, topic := range topics {
+		_, ok := dedicatedTopics[topic]
+		if !ok || len(units) >= int(autoscale.MaxUnits)-1 {
+			shared = append(shared, topic)
+			continue
+		}
+		id := topicUnitID(topic)
+		for n := 1; ids[id]; n++ {
+			id = fmt.Sprintf("%s-%d", topicUnitID(topic), n)
+		}
+		ids[id] = true
+		units = append(units, deploymentUnit{
+			id:            id,
+			sinkGroupSpec: sinkGroupSpec,
+			topics:        []string{topic},
+		})
+	}
+	if len(shared) == 0 {
+		return units
+	}
+
+	sharedUnits := 1
+	if autoscale.MinUnits != nil && int(*autoscale.MinUnits)-len(units) > 1 {
+		sharedUnits = int(*autoscale.MinUnits) - len(units)
+	}
+	sharedChunkSize := (len(shared) + sharedUnits - 1) / sharedUnits
+	if sharedChunkSize > chunkSize {
+		sharedChunkSize = chunkSize
+	}
+
+	return append(units, allocateUnitWithChunks(
+		shared, sinkGroupSpec, sharedChunkSize)...)
+}
+
+// allocateRolledBackUnits allocates units in chunks to the rolled back
+// topics of every mask version, the units run with the mask version of
+// their topics. Used by the MainSinkGroup batchers.
+func allocateRolledBackUnits(
+	topicsByVersion map[string][]string,
+	sinkGroupSpec *tipocav1.SinkGroupSpec,
+	chunkSize int,
+) []deploymentUnit {
+	versions := []string{}
+	for version := range topicsByVersion {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	units := []deploymentUnit{}
+	for _, version := range versions {
+		prefix := "rb-" + k8sCompatibleName(shortMaskVersion(version))
+		for _, unit := range allocateUnitWithChunks(
+			topicsByVersion[version], sinkGroupSpec, chunkSize) {
+			unit.id = prefix + "-" + unit.id
+			unit.maskVersion = version
+			units = append(units, unit)
+		}
+	}
+
+	return units
+}
@@ -3,6 +3,9 @@ package controllers
 import (
 	"reflect"
 	"testing"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestAllocateUnitChunks(t *testing.T) {
@@ -585,3 +588,131 @@ func TestAllocateReloadingUnits(t *testing.T) {
 		})
 	}
 }
+
+func TestAllocateAutoscaledUnits(t *testing.T) {
+	t.Parallel()
+
+	minUnits := func(i int32) *int32 { return &i }
+	topics := []string{
+		"db.inventory.t1",
+		"db.inventory.t2",
+		"db.inventory.t3",
+		"db.inventory.t4",
+		"db.inventory.t5",
+	}
+
+	tests := []struct {
+		name      string
+		topics    []string
+		dedicated []string
+		minUnits  *int32
+		maxUnits  int32
+		chunkSize int
+		units     []deploymentUnit
+	}{
+		{
+			name:      "test1: single shared unit",
+			minUnits:  minUnits(1),
+			maxUnits:  3,
+			chunkSize: 100,
+			units: []deploymentUnit{
+				{id: "0", topics: topics},
+			},
+		},
+		{
+			name:      "test2: dedicated topics",
+			dedicated: []string{"db.inventory.t2", "db.inventory.t4"},
+			minUnits:  minUnits(1),
+			maxUnits:  3,
+			chunkSize: 100,
+			units: []deploymentUnit{
+				{id: "t2", topics: []string{"db.inventory.t2"}},
+				{id: "t4", topics: []string{"db.inventory.t4"}},
+				{id: "0", topics: []string{
+					"db.inventory.t1", "db.inventory.t3", "db.inventory.t5",
+				}},
+			},
+		},
+		{
+			name:      "test3: shared units keep the min units",
+			dedicated: []string{"db.inventory.t1"},
+			minUnits:  minUnits(3),
+			maxUnits:  5,
+			chunkSize: 100,
+			units: []deploymentUnit{
+				{id: "t1", topics: []string{"db.inventory.t1"}},
+				{id: "0", topics: []string{"db.inventory.t2", "db.inventory.t3"}},
+				{id: "1", topics: []string{"db.inventory.t4", "db.inventory.t5"}},
+			},
+		},
+		{
+			name:      "test4: dedicated units within max units",
+			dedicated: []string{"db.inventory.t1", "db.inventory.t2"},
+			minUnits:  minUnits(1),
+			maxUnits:  2,
+			chunkSize: 100,
+			units: []deploymentUnit{
+				{id: "t1", topics: []string{"db.inventory.t1"}},
+				{id: "0", topics: []string{
+					"db.inventory.t2",
+					"db.inventory.t3",
+					"db.inventory.t4",
+					"db.inventory.t5",
+				}},
+			},
+		},
+		{
+			name:      "test5: chunk size is respected",
+			minUnits:  minUnits(1),
+			maxUnits:  2,
+			chunkSize: 3,
+			units: []deploymentUnit{
+				{id: "0", topics: []string{
+					"db.inventory.t1", "db.inventory.t2", "db.inventory.t3",
+				}},
+				{id: "1", topics: []string{"db.inventory.t4", "db.inventory.t5"}},
+			},
+		},
+		{
+			name:      "test6: all topics dedicated",
+			topics:    []string{"db.inventory.t1"},
+			dedicated: []string{"db.inventory.t1"},
+			minUnits:  minUnits(1),
+			maxUnits:  3,
+			chunkSize: 100,
+			units: []deploymentUnit{
+				{id: "t1", topics: []string{"db.inventory.t1"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dedicated := make(map[string]metav1.Time)
+			for _, topic := range tc.dedicated {
+				dedicated[topic] = metav1.Now()
+			}
+			allocateTopics := topics
+			if tc.topics != nil {
+				allocateTopics = tc.topics
+			}
+			spec := &tipocav1.SinkGroupSpec{
+				Autoscale: &tipocav1.SinkGroupAutoscale{
+					MinUnits: tc.minUnits,
+					MaxUnits: tc.maxUnits,
+				},
+			}
+			for i := range tc.units {
+				tc.units[i].sinkGroupSpec = spec
+			}
+			gotUnits := allocateAutoscaledUnits(
+				allocateTopics, dedicated, spec, tc.chunkSize)
+			if !reflect.DeepEqual(gotUnits, tc.units) {
+				t.Errorf("\nexpected (%v): %+v\ngot (%v): %+v\n",
+					len(tc.units), tc.units, len(gotUnits), gotUnits)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
@@ -11,6 +12,7 @@ import (
 	hashstructure "github.com/mitchellh/hashstructure/v2"
 	klog "github.com/practo/klog/v2"
 	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	transformer "github.com/practo/tipoca-stream/pkg/transformer"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -25,6 +27,10 @@ const (
 	InstanceName   = "practo.dev/name"
 	SinkGroupLabel = "practo.dev/sinkgroup"
 	RskResource    = "practo.dev/resource"
+
+	// PodTemplateHashAnnotation is the hash of the pod template the
+	// deployment was made with, it is used to detect changes in it.
+	PodTemplateHashAnnotation = "practo.dev/pod-template-hash"
 )
 
 type deploymentSpec struct {
@@ -32,10 +38,19 @@ type deploymentSpec struct {
 	namespace   string
 	labels      map[string]string
 	replicas    *int32
-	resources   *corev1.ResourceRequirements
-	tolerations *[]corev1.Toleration
+	podTemplate *tipocav1.RedshiftPodTemplateSpec
 	image       string
 	args        []string
+	// configMapMounts are the configmaps mounted other than the config
+	configMapMounts []configMapMount
+}
+
+// configMapMount mounts the key(subPath) of the configmap at the mountPath
+type configMapMount struct {
+	name       string
+	volumeName string
+	mountPath  string
+	subPath    string
 }
 
 type configMapSpec struct {
@@ -62,12 +77,16 @@ func configFromSpec(configSpec configMapSpec) *corev1.ConfigMap {
 func deploymentFromSpec(
 	deploySpec deploymentSpec,
 	configSpec configMapSpec,
+	podTemplateHash string,
 ) *appsv1.Deployment {
 	d := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      deploySpec.name,
 			Namespace: deploySpec.namespace,
 			Labels:    deploySpec.labels,
+			Annotations: map[string]string{
+				PodTemplateHashAnnotation: podTemplateHash,
+			},
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: deploySpec.replicas,
@@ -116,17 +135,105 @@ func deploymentFromSpec(
 		},
 	}
 
-	if deploySpec.resources != nil {
-		d.Spec.Template.Spec.Containers[0].Resources = *deploySpec.resources
+	for _, mount := range deploySpec.configMapMounts {
+		d.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+			d.Spec.Template.Spec.Containers[0].VolumeMounts,
+			corev1.VolumeMount{
+				MountPath: mount.mountPath,
+				SubPath:   mount.subPath,
+				Name:      mount.volumeName,
+			},
+		)
+		d.Spec.Template.Spec.Volumes = append(
+			d.Spec.Template.Spec.Volumes,
+			corev1.Volume{
+				Name: mount.volumeName,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: mount.name,
+						},
+					},
+				},
+			},
+		)
 	}
 
-	if deploySpec.tolerations != nil {
-		d.Spec.Template.Spec.Tolerations = *deploySpec.tolerations
-	}
+	applyPodTemplate(d, deploySpec.podTemplate)
 
 	return d
 }
 
+// applyPodTemplate customizes the pods of the deployment using the pod
+// template. The labels of the pod template can not override the labels
+// set by the operator as the deployment selects its pods using them.
+func applyPodTemplate(
+	d *appsv1.Deployment,
+	podTemplate *tipocav1.RedshiftPodTemplateSpec,
+) {
+	if podTemplate == nil {
+		return
+	}
+	pod := &d.Spec.Template
+	container := &pod.Spec.Containers[0]
+
+	if podTemplate.Resources != nil {
+		container.Resources = *podTemplate.Resources
+	}
+	if podTemplate.Tolerations != nil {
+		pod.Spec.Tolerations = *podTemplate.Tolerations
+	}
+	if podTemplate.ImagePullPolicy != "" {
+		container.ImagePullPolicy = podTemplate.ImagePullPolicy
+	}
+	if len(podTemplate.Labels) > 0 {
+		labels := make(map[string]string)
+		for key, value := range podTemplate.Labels {
+			labels[key] = value
+		}
+		for key, value := range pod.Labels {
+			labels[key] = value
+		}
+		pod.Labels = labels
+	}
+	pod.Annotations = podTemplate.Annotations
+
+	container.Env = podTemplate.Env
+	container.EnvFrom = podTemplate.EnvFrom
+	container.SecurityContext = podTemplate.ContainerSecurityContext
+	container.LivenessProbe = podTemplate.LivenessProbe
+	container.ReadinessProbe = podTemplate.ReadinessProbe
+
+	pod.Spec.ImagePullSecrets = podTemplate.ImagePullSecrets
+	pod.Spec.NodeSelector = podTemplate.NodeSelector
+	pod.Spec.Affinity = podTemplate.Affinity
+	pod.Spec.TopologySpreadConstraints = podTemplate.TopologySpreadConstraints
+	pod.Spec.PriorityClassName = podTemplate.PriorityClassName
+	pod.Spec.ServiceAccountName = podTemplate.ServiceAccountName
+	pod.Spec.SecurityContext = podTemplate.SecurityContext
+}
+
+// podTemplateHash returns the hash of the pod template and the image used
+// to make the pods. The pod template is hashed in its json form as it has
+// the quantities whose values are in the unexported fields.
+func podTemplateHash(
+	podTemplate *tipocav1.RedshiftPodTemplateSpec,
+	image string,
+) (string, error) {
+	podTemplateBytes, err := json.Marshal(podTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	return getHashStructure(struct {
+		PodTemplate string
+		Image       string
+	}{
+		PodTemplate: string(podTemplateBytes),
+		Image:       image,
+	})
+}
+
 func getHashStructure(v interface{}) (string, error) {
 	h, err := hashstructure.Hash(
 		v,
@@ -153,10 +260,71 @@ func toInt32Ptr(i int32) *int32 {
 	return &i
 }
 
+func toInt64Ptr(i int64) *int64 {
+	return &i
+}
+
 func toQuantityPtr(r resource.Quantity) *resource.Quantity {
 	return &r
 }
 
+// timezoneConfig converts the timezone spec into the configuration
+// understood by the batcher and the loader.
+func timezoneConfig(rsk *tipocav1.RedshiftSink) transformer.TimezoneConfig {
+	if rsk.Spec.Timezone == nil {
+		return transformer.TimezoneConfig{}
+	}
+
+	return transformer.TimezoneConfig{
+		Source:                rsk.Spec.Timezone.Source,
+		Databases:             rsk.Spec.Timezone.Databases,
+		Tables:                rsk.Spec.Timezone.Tables,
+		TimestampWithTimezone: rsk.Spec.Timezone.TimestampWithTimezone,
+		TimeType:              rsk.Spec.Timezone.TimeType,
+		TimeColumns:           rsk.Spec.Timezone.TimeColumns,
+	}
+}
+
+// maskVersion is the version of masking used by the operator to track the
+// mask rollouts. It is the mask file version, suffixed with the mask salt
+// version when the salt is versioned. Git versions never contain "-".
+func maskVersion(fileVersion, saltVersion string) string {
+	if saltVersion == "" {
+		return fileVersion
+	}
+
+	return fileVersion + "-" + saltVersion
+}
+
+// splitMaskVersion returns the mask file version and the mask salt version
+func splitMaskVersion(version string) (string, string) {
+	parts := strings.SplitN(version, "-", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// shortMaskVersion is used to name the consumer groups and the loader topics
+func shortMaskVersion(version string) string {
+	fileVersion, saltVersion := splitMaskVersion(version)
+	if len(fileVersion) >= 6 {
+		fileVersion = fileVersion[:6]
+	}
+
+	return maskVersion(fileVersion, saltVersion)
+}
+
+// maskSaltByVersion returns the mask salt of the version from the secret
+func maskSaltByVersion(secret map[string]string, saltVersion string) (string, error) {
+	if saltVersion == "" {
+		return secretByKey(secret, "maskSalt")
+	}
+
+	return secretByKey(secret, "maskSalt-"+saltVersion)
+}
+
 func sortStringSlice(t []string) {
 	sort.Sort(sort.StringSlice(t))
 }
@@ -223,16 +391,26 @@ func makeLoaderTopics(prefix string, topics []string) []string {
 	return prefixedTopics
 }
 
-// TODO: fix me please, prefix cannot contain "-" because of this
-func makeBatcherTopics(topics []string) []string {
+// makeBatcherTopics returns the batcher topics of the loader topics by
+// removing the loader topic prefix of their topic group, the prefix can
+// contain "-" as the mask salt version and the reload id are part of it.
+func makeBatcherTopics(
+	topicGroups map[string]tipocav1.Group,
+	loaderTopics []string,
+) []string {
+	batcherTopics := make(map[string]string)
+	for topic, group := range topicGroups {
+		batcherTopics[group.LoaderTopicPrefix+topic] = topic
+	}
+
 	var prefixRemovedTopics []string
-	for _, topic := range topics {
-		splitted := strings.Split(topic, "-")
-		if len(splitted) > 2 {
-			prefixRemovedTopics = append(prefixRemovedTopics, strings.Join(splitted[2:], "-"))
-		} else {
-			klog.Warningf("ignored topic: %s", topic)
+	for _, loaderTopic := range loaderTopics {
+		topic, ok := batcherTopics[loaderTopic]
+		if !ok {
+			klog.Warningf("ignored topic: %s", loaderTopic)
+			continue
 		}
+		prefixRemovedTopics = append(prefixRemovedTopics, topic)
 	}
 
 	return prefixRemovedTopics
@@ -366,6 +544,15 @@ func deploymentSpecEqual(
 		return false
 	}
 
+	// the pod spec is defaulted by the api server and can not be compared,
+	// the hash of the pod template it is made from is compared instead
+	currentHash := current.Annotations[PodTemplateHashAnnotation]
+	desiredHash := desired.Annotations[PodTemplateHashAnnotation]
+	if currentHash != desiredHash {
+		klog.Infof("%s pod template require update!", desired.Name)
+		return false
+	}
+
 	return true
 }
 
@@ -567,3 +754,49 @@ func deleteConfigMap(
 		Name:   configMap.Name,
 	}, nil
 }
+
+// maskFileVersionsInUse returns the mask file versions in the mask status
+// of the redshiftsinks using the mask file, including the versions of the
+// topics and of the tables retained for rollback.
+func maskFileVersionsInUse(
+	rsks []tipocav1.RedshiftSink,
+	maskFile string,
+) []string {
+	versions := []string{}
+	add := func(version *string) {
+		if version == nil || *version == "" {
+			return
+		}
+		fileVersion, _ := splitMaskVersion(*version)
+		versions = appendIfMissing(versions, fileVersion)
+	}
+
+	for i := range rsks {
+		rsk := &rsks[i]
+		if rsk.Spec.Batcher.MaskFile != maskFile {
+			continue
+		}
+		for _, release := range rsk.Status.PreviousReleases {
+			add(release.ReleasedVersion)
+		}
+		maskStatus := rsk.Status.MaskStatus
+		if maskStatus == nil {
+			continue
+		}
+		add(maskStatus.CurrentMaskVersion)
+		add(maskStatus.DesiredMaskVersion)
+		for _, topicStatus := range []map[string]tipocav1.TopicMaskStatus{
+			maskStatus.CurrentMaskStatus,
+			maskStatus.DesiredMaskStatus,
+		} {
+			for _, status := range topicStatus {
+				version := status.Version
+				add(&version)
+				add(status.ReleasedVersion)
+			}
+		}
+	}
+	sortStringSlice(versions)
+
+	return versions
+}
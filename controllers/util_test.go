@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	tipocav1 "github.com/practo/tipoca-stream/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMaskVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		fileVersion  string
+		saltVersion  string
+		version      string
+		shortVersion string
+		groupID      string
+		loaderPrefix string
+	}{
+		{
+			name:         "test1: salt not versioned",
+			fileVersion:  "6c5571bd12ae",
+			saltVersion:  "",
+			version:      "6c5571bd12ae",
+			shortVersion: "6c5571",
+			groupID:      "customers-6c5571",
+			loaderPrefix: "loader-6c5571-",
+		},
+		{
+			name:         "test2: salt versioned",
+			fileVersion:  "6c5571bd12ae",
+			saltVersion:  "v2",
+			version:      "6c5571bd12ae-v2",
+			shortVersion: "6c5571-v2",
+			groupID:      "customers-6c5571-v2",
+			loaderPrefix: "loader-6c5571-v2-",
+		},
+		{
+			name:         "test3: empty version",
+			fileVersion:  "",
+			saltVersion:  "",
+			version:      "",
+			shortVersion: "",
+			groupID:      "customers-",
+			loaderPrefix: "loader--",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			version := maskVersion(tc.fileVersion, tc.saltVersion)
+			if version != tc.version {
+				t.Errorf("expected version: %v, got: %v\n", tc.version, version)
+			}
+
+			fileVersion, saltVersion := splitMaskVersion(version)
+			if fileVersion != tc.fileVersion || saltVersion != tc.saltVersion {
+				t.Errorf("expected: %v %v, got: %v %v\n",
+					tc.fileVersion, tc.saltVersion, fileVersion, saltVersion)
+			}
+
+			shortVersion := shortMaskVersion(version)
+			if shortVersion != tc.shortVersion {
+				t.Errorf("expected short version: %v, got: %v\n",
+					tc.shortVersion, shortVersion)
+			}
+
+			groupID := groupIDFromTopicVersion("db.inventory.customers", version)
+			if groupID != tc.groupID {
+				t.Errorf("expected groupID: %v, got: %v\n", tc.groupID, groupID)
+			}
+
+			loaderPrefix := loaderPrefixFromVersion("loader-", version)
+			if loaderPrefix != tc.loaderPrefix {
+				t.Errorf("expected loader prefix: %v, got: %v\n",
+					tc.loaderPrefix, loaderPrefix)
+			}
+		})
+	}
+}
+
+func TestMaskSaltByVersion(t *testing.T) {
+	t.Parallel()
+
+	secret := map[string]string{
+		"maskSalt":    "salt",
+		"maskSalt-v2": "salt2",
+	}
+
+	salt, err := maskSaltByVersion(secret, "")
+	if err != nil || salt != "salt" {
+		t.Errorf("expected salt: salt, got: %v, err: %v\n", salt, err)
+	}
+
+	salt, err = maskSaltByVersion(secret, "v2")
+	if err != nil || salt != "salt2" {
+		t.Errorf("expected salt: salt2, got: %v, err: %v\n", salt, err)
+	}
+
+	_, err = maskSaltByVersion(secret, "v3")
+	if err == nil {
+		t.Errorf("expected error for missing salt version\n")
+	}
+}
+
+func testDeployment(
+	podTemplate *tipocav1.RedshiftPodTemplateSpec,
+	image string,
+) (*appsv1.Deployment, error) {
+	hash, err := podTemplateHash(podTemplate, image)
+	if err != nil {
+		return nil, err
+	}
+	replicas := int32(1)
+
+	return deploymentFromSpec(
+		deploymentSpec{
+			name:        "inventory-batcher",
+			namespace:   "ts-redshiftsink-latest",
+			labels:      map[string]string{InstanceLabel: "redshiftbatcher"},
+			replicas:    &replicas,
+			podTemplate: podTemplate,
+			image:       image,
+		},
+		configMapSpec{name: "inventory-batcher", volumeName: "inventory-batcher"},
+		hash,
+	), nil
+}
+
+func TestApplyPodTemplate(t *testing.T) {
+	t.Parallel()
+
+	podTemplate := &tipocav1.RedshiftPodTemplateSpec{
+		Annotations: map[string]string{"prometheus.io/scrape": "true"},
+		Labels: map[string]string{
+			"team":        "data",
+			InstanceLabel: "overridden",
+		},
+		ImagePullPolicy:    corev1.PullIfNotPresent,
+		ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "registry"}},
+		Env:                []corev1.EnvVar{{Name: "AWS_REGION", Value: "ap-south-1"}},
+		NodeSelector:       map[string]string{"role": "sink"},
+		PriorityClassName:  "high",
+		ServiceAccountName: "redshiftsink",
+	}
+	d, err := testDeployment(podTemplate, "practodev/redshiftbatcher:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := d.Spec.Template
+	container := pod.Spec.Containers[0]
+
+	expectedLabels := map[string]string{
+		"team":        "data",
+		InstanceLabel: "redshiftbatcher",
+	}
+	if !reflect.DeepEqual(pod.Labels, expectedLabels) {
+		t.Errorf("expected labels: %v, got: %v", expectedLabels, pod.Labels)
+	}
+	if !reflect.DeepEqual(d.Spec.Selector.MatchLabels,
+		map[string]string{InstanceLabel: "redshiftbatcher"}) {
+		t.Errorf("selector changed: %v", d.Spec.Selector.MatchLabels)
+	}
+	if !reflect.DeepEqual(pod.Annotations, podTemplate.Annotations) {
+		t.Errorf("expected annotations: %v, got: %v",
+			podTemplate.Annotations, pod.Annotations)
+	}
+	if container.ImagePullPolicy != corev1.PullIfNotPresent {
+		t.Errorf("expected pull policy: %v, got: %v",
+			corev1.PullIfNotPresent, container.ImagePullPolicy)
+	}
+	if !reflect.DeepEqual(container.Env, podTemplate.Env) {
+		t.Errorf("expected env: %v, got: %v", podTemplate.Env, container.Env)
+	}
+	if !reflect.DeepEqual(pod.Spec.ImagePullSecrets, podTemplate.ImagePullSecrets) ||
+		!reflect.DeepEqual(pod.Spec.NodeSelector, podTemplate.NodeSelector) ||
+		pod.Spec.PriorityClassName != "high" ||
+		pod.Spec.ServiceAccountName != "redshiftsink" {
+		t.Errorf("pod spec not customized: %+v", pod.Spec)
+	}
+
+	d, err = testDeployment(nil, "practodev/redshiftbatcher:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Spec.Template.Spec.Containers[0].ImagePullPolicy != corev1.PullAlways {
+		t.Errorf("expected default pull policy: %v", corev1.PullAlways)
+	}
+}
+
+func TestDeploymentSpecEqualPodTemplate(t *testing.T) {
+	t.Parallel()
+
+	image := "practodev/redshiftbatcher:latest"
+	base := &tipocav1.RedshiftPodTemplateSpec{
+		Resources: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(podTemplate *tipocav1.RedshiftPodTemplateSpec)
+		equal  bool
+	}{
+		{
+			name:   "test1: same",
+			mutate: func(podTemplate *tipocav1.RedshiftPodTemplateSpec) {},
+			equal:  true,
+		},
+		{
+			name: "test2: service account",
+			mutate: func(podTemplate *tipocav1.RedshiftPodTemplateSpec) {
+				podTemplate.ServiceAccountName = "redshiftsink"
+			},
+			equal: false,
+		},
+		{
+			name: "test3: affinity",
+			mutate: func(podTemplate *tipocav1.RedshiftPodTemplateSpec) {
+				podTemplate.Affinity = &corev1.Affinity{
+					NodeAffinity: &corev1.NodeAffinity{},
+				}
+			},
+			equal: false,
+		},
+		{
+			name: "test4: pod annotation",
+			mutate: func(podTemplate *tipocav1.RedshiftPodTemplateSpec) {
+				podTemplate.Annotations = map[string]string{
+					"eks.amazonaws.com/role-arn": "arn:aws:iam::1:role/sink",
+				}
+			},
+			equal: false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			current, err := testDeployment(base.DeepCopy(), image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			podTemplate := base.DeepCopy()
+			tc.mutate(podTemplate)
+			desired, err := testDeployment(podTemplate, image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deploymentSpecEqual(current, desired) != tc.equal {
+				t.Errorf("expected equal: %v", tc.equal)
+			}
+		})
+	}
+}
+
+func TestMaskFileVersionsInUse(t *testing.T) {
+	t.Parallel()
+
+	maskFile := "configmap://masks/inventory.yaml"
+	current := "a1b2c3-v1"
+	desired := "d4e5f6-v1"
+	released := "f7a8b9-v1"
+	other := "0a0b0c"
+	rsks := []tipocav1.RedshiftSink{
+		{
+			Spec: tipocav1.RedshiftSinkSpec{
+				Batcher: tipocav1.RedshiftBatcherSpec{MaskFile: maskFile},
+			},
+			Status: tipocav1.RedshiftSinkStatus{
+				MaskStatus: &tipocav1.MaskStatus{
+					CurrentMaskVersion: &current,
+					DesiredMaskVersion: &desired,
+					CurrentMaskStatus: map[string]tipocav1.TopicMaskStatus{
+						"db.inventory.orders": {Version: desired},
+					},
+				},
+				PreviousReleases: map[string]tipocav1.PreviousRelease{
+					"db.inventory.orders": {ReleasedVersion: &released},
+				},
+			},
+		},
+		{
+			Spec: tipocav1.RedshiftSinkSpec{
+				Batcher: tipocav1.RedshiftBatcherSpec{
+					MaskFile: "configmap://other/inventory.yaml"},
+			},
+			Status: tipocav1.RedshiftSinkStatus{
+				MaskStatus: &tipocav1.MaskStatus{
+					CurrentMaskVersion: &other,
+				},
+			},
+		},
+	}
+
+	expected := []string{"a1b2c3", "d4e5f6", "f7a8b9"}
+	got := maskFileVersionsInUse(rsks, maskFile)
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+}
@@ -24,6 +24,7 @@ require (
 	github.com/spf13/viper v1.7.1
 	github.com/whilp/git-urls v1.0.0
 	github.com/xdg-go/scram v1.0.2
+	golang.org/x/crypto v0.0.0-20210920023735-84f357641f63
 	gopkg.in/yaml.v2 v2.4.0
 	k8s.io/api v0.19.2
 	k8s.io/apimachinery v0.19.2
@@ -93,7 +94,6 @@ require (
 	github.com/xanzy/ssh-agent v0.3.0 // indirect
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
 	github.com/xdg-go/stringprep v1.0.2 // indirect
-	golang.org/x/crypto v0.0.0-20210920023735-84f357641f63 // indirect
 	golang.org/x/net v0.0.0-20210917221730-978cfadd31cf // indirect
 	golang.org/x/oauth2 v0.0.0-20191202225959-858c2ad4c8b6 // indirect
 	golang.org/x/sync v0.0.0-20201207232520-09787c993a3a // indirect
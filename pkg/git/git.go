@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/whilp/git-urls"
 	"io"
@@ -20,29 +21,37 @@ type GitInterface interface {
 }
 
 type Git struct {
-	dir         string
-	repoURL     string
-	accessToken string
+	dir     string
+	repoURL string
+	auth    transport.AuthMethod
 
 	// repo is initilized on Clone
 	repo *git.Repository
 }
 
 func New(dir string, repoURL string, accessToken string) GitInterface {
+	return NewWithAuth(dir, repoURL, &http.BasicAuth{
+		Username: "ts", // not used, but requires to be not empty
+		Password: accessToken,
+	})
+}
+
+// NewWithAuth is New with the auth method of the remote, auth can be nil
+// for public repositories.
+func NewWithAuth(
+	dir string, repoURL string, auth transport.AuthMethod) GitInterface {
+
 	return &Git{
-		dir:         dir,
-		repoURL:     repoURL,
-		accessToken: accessToken,
+		dir:     dir,
+		repoURL: repoURL,
+		auth:    auth,
 	}
 }
 
 func (g *Git) Clone() error {
 	repo, err := git.PlainClone(g.dir, false, &git.CloneOptions{
-		URL: g.repoURL,
-		Auth: &http.BasicAuth{
-			Username: "ts", // not used, but requires to be not empty
-			Password: g.accessToken,
-		},
+		URL:  g.repoURL,
+		Auth: g.auth,
 	})
 
 	if err != nil {
@@ -60,10 +69,7 @@ func (g *Git) Pull() error {
 	}
 
 	err = tree.Pull(&git.PullOptions{
-		Auth: &http.BasicAuth{
-			Username: "ts", // not used, but requires to be not empty
-			Password: g.accessToken,
-		},
+		Auth: g.auth,
 	})
 
 	// ignore already up to date
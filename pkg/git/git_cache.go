@@ -1,6 +1,8 @@
 package git
 
 import (
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	klog "github.com/practo/klog/v2"
 	"io/ioutil"
 	"os"
@@ -9,9 +11,13 @@ import (
 	"time"
 )
 
+// DefaultCacheValidity is the duration after which the repo is pulled again
+const DefaultCacheValidity = time.Second * time.Duration(30)
+
 type GitCacheInterface interface {
 	GetFileVersion(filePath string) (string, error)
 	GetFileLocalPath(filepath string) string
+	Invalidate()
 }
 
 type GitCache struct {
@@ -29,14 +35,31 @@ type GitCache struct {
 }
 
 func NewGitCache(repoURL string, accessToken string) (GitCacheInterface, error) {
+	return NewGitCacheWithAuth(repoURL, &http.BasicAuth{
+		Username: "ts", // not used, but requires to be not empty
+		Password: accessToken,
+	}, DefaultCacheValidity)
+}
+
+// NewGitCacheWithAuth is NewGitCache with the auth method of the remote
+// and the duration for which the fetched versions are valid.
+func NewGitCacheWithAuth(
+	repoURL string,
+	auth transport.AuthMethod,
+	cacheValidity time.Duration,
+) (
+	GitCacheInterface,
+	error,
+) {
+
 	cloneDir, err := ioutil.TempDir("", "gitcache")
 	if err != nil {
 		return nil, err
 	}
 
 	return &GitCache{
-		client:           New(cloneDir, repoURL, accessToken),
-		cacheValidity:    time.Second * time.Duration(30),
+		client:           NewWithAuth(cloneDir, repoURL, auth),
+		cacheValidity:    cacheValidity,
 		lastCacheRefresh: nil,
 		cloneDir:         cloneDir,
 		fileVersion:      make(map[string]string),
@@ -106,6 +129,15 @@ func (g *GitCache) GetFileVersion(filePath string) (string, error) {
 	return commits[0], nil
 }
 
+// Invalidate makes the next GetFileVersion pull the repo, it is used
+// when the repo is known to have changed before the cache expires.
+func (g *GitCache) Invalidate() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.lastCacheRefresh = nil
+}
+
 // GetFileLocalPath takes the relative path of the file from repo
 // and returns the local path the file should be present if downloaded
 func (g *GitCache) GetFileLocalPath(filePath string) string {
@@ -22,6 +22,11 @@ type Client interface {
 	// the current offset. If group is not found it returns -1.
 	CurrentOffset(id string, topic string, partition int32) (int64, error)
 
+	// OffsetTimestamp returns the timestamp of the message at the offset of
+	// the topic partition. It returns nil if the message has no timestamp,
+	// timestamps are available from Kafka version 0.10.
+	OffsetTimestamp(topic string, partition int32, offset int64) (*time.Time, error)
+
 	// List the consumer groups available in the cluster.
 	ListConsumerGroups() (map[string]string, error)
 
@@ -31,6 +36,7 @@ type Client interface {
 
 type kafkaClient struct {
 	client               sarama.Client
+	offsetClient         sarama.Client
 	clusterAdmin         sarama.ClusterAdmin
 	cacheValidity        time.Duration
 	lastTopicRefreshTime *int64
@@ -70,6 +76,16 @@ func NewClient(
 		return nil, fmt.Errorf("Error creating client: %v\n", err)
 	}
 
+	// offsetClient fetches the timestamps at the offsets, its fetch is
+	// kept small as only the first message of the fetch is needed
+	oc := *c
+	oc.Consumer.Fetch.Default = offsetTimestampFetchBytes
+	oc.Consumer.Return.Errors = true
+	offsetClient, err := sarama.NewClient(brokers, &oc)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating offset client: %v\n", err)
+	}
+
 	clusterAdmin, err := sarama.NewClusterAdmin(brokers, c)
 	if err != nil {
 		return nil, fmt.Errorf("Error creating admin client: %v\n", err)
@@ -77,6 +93,7 @@ func NewClient(
 
 	return &kafkaClient{
 		client:               client,
+		offsetClient:         offsetClient,
 		clusterAdmin:         clusterAdmin,
 		cacheValidity:        time.Second * time.Duration(30),
 		lastTopicRefreshTime: nil,
@@ -240,6 +257,73 @@ func (t *kafkaClient) CurrentOffset(
 	return currentOffset, nil
 }
 
+// offsetTimestampTimeout is the maximum time to wait for the message
+// at the offset while fetching its timestamp
+const offsetTimestampTimeout = 10 * time.Second
+
+// offsetTimestampFetchBytes is the default number of bytes fetched while
+// fetching the timestamp at an offset, sarama grows the fetch when the
+// message at the offset is larger
+const offsetTimestampFetchBytes = 64 * 1024
+
+func (t *kafkaClient) OffsetTimestamp(
+	topic string,
+	partition int32,
+	offset int64,
+) (
+	*time.Time,
+	error,
+) {
+	consumer, err := sarama.NewConsumerFromClient(t.offsetClient)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating consumer, err: %v", err)
+	}
+	defer consumer.Close()
+
+	partitionConsumer, err := consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Error consuming %s at offset: %d, err: %v", topic, offset, err)
+	}
+	defer closePartitionConsumer(partitionConsumer)
+
+	select {
+	case message := <-partitionConsumer.Messages():
+		if message == nil || message.Timestamp.IsZero() {
+			return nil, nil
+		}
+		return &message.Timestamp, nil
+	case err := <-partitionConsumer.Errors():
+		return nil, fmt.Errorf(
+			"Error fetching %s at offset: %d, err: %v", topic, offset, err)
+	case <-time.After(offsetTimestampTimeout):
+		return nil, fmt.Errorf(
+			"Timed out fetching %s at offset: %d", topic, offset)
+	}
+}
+
+// closePartitionConsumer closes the partition consumer and drains the
+// messages and errors it has buffered, the consumer does not shut down
+// until they are drained.
+func closePartitionConsumer(partitionConsumer sarama.PartitionConsumer) {
+	partitionConsumer.AsyncClose()
+
+	messages := partitionConsumer.Messages()
+	errors := partitionConsumer.Errors()
+	for messages != nil || errors != nil {
+		select {
+		case _, ok := <-messages:
+			if !ok {
+				messages = nil
+			}
+		case _, ok := <-errors:
+			if !ok {
+				errors = nil
+			}
+		}
+	}
+}
+
 func (t *kafkaClient) ListConsumerGroups() (map[string]string, error) {
 	return t.clusterAdmin.ListConsumerGroups()
 }
@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestOffsetTimestamp(t *testing.T) {
+	t.Parallel()
+
+	topic := "db.inventory.orders"
+	timestamp := time.Unix(1609459200, 0)
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	fetchResponse := &sarama.FetchResponse{Version: 4}
+	fetchResponse.AddRecordWithTimestamp(
+		topic, 0, nil, sarama.StringEncoder("order"), 5, timestamp)
+	fetchResponse.AddRecordWithTimestamp(
+		topic, 0, nil, sarama.StringEncoder("order"), 6, timestamp)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset(topic, 0, sarama.OffsetOldest, 0).
+			SetOffset(topic, 0, sarama.OffsetNewest, 10),
+		"FetchRequest": sarama.NewMockWrapper(fetchResponse),
+	})
+
+	client, err := NewClient([]string{broker.Addr()}, "1.0.0", TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.OffsetTimestamp(topic, 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || !got.Equal(timestamp) {
+		t.Errorf("expected timestamp: %v, got: %v", timestamp, got)
+	}
+}
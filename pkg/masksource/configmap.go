@@ -0,0 +1,203 @@
+package masksource
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SnapshotLabel is set on the snapshots with the name of the configmap
+const SnapshotLabel = "practo.dev/mask-snapshot-of"
+
+// configMapSource is the mask file kept as a key of a configmap. The
+// version is the hash of the content. A configmap keeps only the latest
+// content, so every version is snapshotted in an immutable configmap which
+// is read by Download and mounted by the batchers.
+type configMapSource struct {
+	namespace string
+	name      string
+	key       string
+	client    client.Client
+}
+
+// ParseConfigMap returns the name and the key of the configmap mask file
+func ParseConfigMap(maskFile string) (string, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(maskFile, configMapScheme), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf(
+			"expected configmap://name/key, got: %s", maskFile)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// SnapshotName is the name of the configmap keeping the version
+func SnapshotName(name string, version string) string {
+	if len(version) > 10 {
+		version = version[:10]
+	}
+
+	return name + "-mask-" + version
+}
+
+func newConfigMapSource(maskFile string, config Config) (Source, error) {
+	name, key, err := ParseConfigMap(maskFile)
+	if err != nil {
+		return nil, err
+	}
+	if config.Client == nil || config.Namespace == "" {
+		return nil, fmt.Errorf(
+			"configmap mask file: %s is supported only in the operator",
+			maskFile)
+	}
+
+	return &configMapSource{
+		namespace: config.Namespace,
+		name:      name,
+		key:       key,
+		client:    config.Client,
+	}, nil
+}
+
+func (c *configMapSource) get(name string) (string, error) {
+	configMap := &corev1.ConfigMap{}
+	err := c.client.Get(
+		context.Background(),
+		types.NamespacedName{Namespace: c.namespace, Name: name},
+		configMap,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	data, ok := configMap.Data[c.key]
+	if !ok {
+		return "", fmt.Errorf(
+			"key: %s not found in configmap: %s/%s", c.key, c.namespace, name)
+	}
+
+	return data, nil
+}
+
+// Version returns the hash of the content and snapshots it, the snapshot
+// is created only when it is not found.
+func (c *configMapSource) Version() (string, error) {
+	data, err := c.get(c.name)
+	if err != nil {
+		return "", err
+	}
+	version := fmt.Sprintf("%x", sha1.Sum([]byte(data)))
+
+	err = c.client.Get(
+		context.Background(),
+		types.NamespacedName{
+			Namespace: c.namespace,
+			Name:      SnapshotName(c.name, version),
+		},
+		&corev1.ConfigMap{},
+	)
+	if err == nil {
+		return version, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf(
+			"Error getting snapshot: %s, err: %v",
+			SnapshotName(c.name, version), err)
+	}
+
+	immutable := true
+	snapshot := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SnapshotName(c.name, version),
+			Namespace: c.namespace,
+			Labels:    map[string]string{SnapshotLabel: c.name},
+		},
+		Immutable: &immutable,
+		Data:      map[string]string{c.key: data},
+	}
+	err = c.client.Create(context.Background(), snapshot)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf(
+			"Error creating snapshot: %s, err: %v", snapshot.Name, err)
+	}
+	if err == nil {
+		klog.V(2).Infof(
+			"Created mask file snapshot: %s/%s", c.namespace, snapshot.Name)
+	}
+
+	return version, nil
+}
+
+// Prune deletes the snapshots of the configmap except the ones of the
+// versions given, the versions in use are given to it.
+func (c *configMapSource) Prune(versions []string) error {
+	keep := make(map[string]bool)
+	for _, version := range versions {
+		keep[SnapshotName(c.name, version)] = true
+	}
+
+	snapshots := &corev1.ConfigMapList{}
+	err := c.client.List(
+		context.Background(),
+		snapshots,
+		client.InNamespace(c.namespace),
+		client.MatchingLabels{SnapshotLabel: c.name},
+	)
+	if err != nil {
+		return fmt.Errorf("Error listing snapshots of: %s, err: %v", c.name, err)
+	}
+
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		if keep[snapshot.Name] {
+			continue
+		}
+		err := c.client.Delete(context.Background(), snapshot)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf(
+				"Error deleting snapshot: %s, err: %v", snapshot.Name, err)
+		}
+		klog.V(2).Infof(
+			"Deleted mask file snapshot: %s/%s", c.namespace, snapshot.Name)
+	}
+
+	return nil
+}
+
+func (c *configMapSource) Download(dir string, version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf(
+			"maskFileVersion is mandatory if maskFile is not local file.",
+		)
+	}
+
+	data, err := c.get(SnapshotName(c.name, version))
+	if err != nil {
+		return "", err
+	}
+
+	destFile := destPath(dir, c.key)
+	err = ioutil.WriteFile(destFile, []byte(data), 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return destFile, nil
+}
+
+func (c *configMapSource) FileURL(version string) string {
+	return ""
+}
+
+func (c *configMapSource) DiffURL(from string, to string) string {
+	return ""
+}
@@ -0,0 +1,91 @@
+package masksource
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// countingClient counts the creates
+type countingClient struct {
+	client.Client
+	creates int
+}
+
+func (c *countingClient) Create(
+	ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.creates++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestConfigMapSourceSnapshots(t *testing.T) {
+	t.Parallel()
+
+	maskConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "masks", Namespace: "ns"},
+		Data:       map[string]string{"inventory.yaml": "non_pii_keys: {}\n"},
+	}
+	fakeClient := &countingClient{
+		Client: fake.NewClientBuilder().WithObjects(maskConfigMap).Build(),
+	}
+	source, err := New(
+		"configmap://masks/inventory.yaml",
+		Config{Namespace: "ns", Client: fakeClient},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var version string
+	for i := 0; i < 2; i++ {
+		version, err = source.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fakeClient.creates != 1 {
+		t.Errorf("expected the snapshot created once, creates: %d",
+			fakeClient.creates)
+	}
+
+	for _, old := range []string{"v1", "v2"} {
+		err := fakeClient.Create(context.Background(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SnapshotName("masks", old),
+				Namespace: "ns",
+				Labels:    map[string]string{SnapshotLabel: "masks"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = source.(Pruner).Prune([]string{version, "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	err = fakeClient.List(
+		context.Background(), configMaps, client.InNamespace("ns"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, configMap := range configMaps.Items {
+		got = append(got, configMap.Name)
+	}
+	sort.Strings(got)
+	expected := []string{"masks", SnapshotName("masks", version), "masks-mask-v1"}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected configmaps: %v, got: %v", expected, got)
+	}
+}
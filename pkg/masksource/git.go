@@ -0,0 +1,308 @@
+package masksource
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/practo/klog/v2"
+	"github.com/practo/tipoca-stream/pkg/git"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	providerGithub    = "github"
+	providerGitlab    = "gitlab"
+	providerBitbucket = "bitbucket"
+	providerGit       = "git"
+)
+
+// fileSource is the mask file kept in the local file system, the version
+// is the hash of its content.
+type fileSource struct {
+	path string
+}
+
+func (f *fileSource) Version() (string, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha1.Sum(data)), nil
+}
+
+func (f *fileSource) Download(dir string, version string) (string, error) {
+	klog.V(5).Info("Mask file is of file type, nothing to download")
+	return f.path, nil
+}
+
+func (f *fileSource) FileURL(version string) string {
+	return ""
+}
+
+func (f *fileSource) DiffURL(from string, to string) string {
+	return ""
+}
+
+// gitSource is the mask file kept in a git repository, the version is the
+// hash of the last commit which changed the file.
+type gitSource struct {
+	provider string
+	// host and repo are used to make the links
+	host     string
+	repo     string
+	repoURL  string
+	filePath string
+	auth     transport.AuthMethod
+	interval time.Duration
+
+	// mutex protects the cache which is made on first use
+	mutex sync.Mutex
+	cache git.GitCacheInterface
+}
+
+func gitProvider(host string) string {
+	switch {
+	case host == "github.com":
+		return providerGithub
+	case host == "bitbucket.org":
+		return providerBitbucket
+	case strings.Contains(host, "gitlab"):
+		return providerGitlab
+	default:
+		return providerGit
+	}
+}
+
+// splitRepoPath splits the path of the url into the repo and the file.
+// "//" separates them for all the providers, gitlab also supports "/-/".
+// The first two parts of the path are the repo for the known providers.
+func splitRepoPath(provider, path string) (string, string, string, error) {
+	path = strings.TrimPrefix(path, "/")
+	for _, separator := range []string{"//", "/-/"} {
+		if separator == "/-/" && provider != providerGitlab {
+			continue
+		}
+		i := strings.Index(path, separator)
+		if i != -1 {
+			return path[:i], path[i+len(separator):], separator, nil
+		}
+	}
+	if provider == providerGit {
+		return "", "", "", fmt.Errorf(
+			"separate the repo and the file with '//' in: %s", path)
+	}
+
+	parts := strings.SplitN(path, "/", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return "", "", "", fmt.Errorf("file path not found in: %s", path)
+	}
+
+	return parts[0] + "/" + parts[1], parts[2], "/", nil
+}
+
+func newGitSource(maskFile string, config Config) (Source, error) {
+	url, err := git.ParseURL(maskFile)
+	if err != nil {
+		return nil, err
+	}
+	if url.Scheme == "file" {
+		return &fileSource{path: url.Path}, nil
+	}
+
+	provider := gitProvider(url.Host)
+	repo, filePath, separator, err := splitRepoPath(provider, url.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := config.GitPollInterval
+	if interval == 0 {
+		interval = git.DefaultCacheValidity
+	}
+
+	var auth transport.AuthMethod
+	switch url.Scheme {
+	case "https", "http":
+		if config.GitAccessToken != "" {
+			auth = &http.BasicAuth{
+				Username: gitUsername(provider),
+				Password: config.GitAccessToken,
+			}
+		}
+	case "ssh", "git+ssh":
+		auth, err = sshAuth(url.User.Username(), config)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("scheme: %s not supported", url.Scheme)
+	}
+
+	return &gitSource{
+		provider: provider,
+		host:     url.Host,
+		repo:     strings.TrimSuffix(repo, ".git"),
+		repoURL:  strings.TrimSuffix(maskFile, separator+filePath),
+		filePath: filePath,
+		auth:     auth,
+		interval: interval,
+	}, nil
+}
+
+// gitUsername is the username used with the access token, github ignores
+// it but it should not be empty.
+func gitUsername(provider string) string {
+	switch provider {
+	case providerGitlab:
+		return "oauth2"
+	case providerBitbucket:
+		return "x-token-auth"
+	default:
+		return "ts"
+	}
+}
+
+func sshAuth(user string, config Config) (transport.AuthMethod, error) {
+	if config.GitSSHKey == "" {
+		return nil, fmt.Errorf("gitSSHKey is required for ssh git urls")
+	}
+	if user == "" {
+		user = "git"
+	}
+	auth, err := ssh.NewPublicKeys(user, []byte(config.GitSSHKey), "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid gitSSHKey, err: %v", err)
+	}
+	if config.GitSSHKnownHosts == "" {
+		return auth, nil
+	}
+
+	knownHostsFile, err := ioutil.TempFile("", "known_hosts")
+	if err != nil {
+		return nil, err
+	}
+	// the known hosts are read when the callback is made
+	defer os.Remove(knownHostsFile.Name())
+	defer knownHostsFile.Close()
+	_, err = knownHostsFile.WriteString(config.GitSSHKnownHosts)
+	if err != nil {
+		return nil, err
+	}
+	auth.HostKeyCallback, err = knownhosts.New(knownHostsFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("invalid gitSSHKnownHosts, err: %v", err)
+	}
+
+	return auth, nil
+}
+
+func (g *gitSource) Version() (string, error) {
+	g.mutex.Lock()
+	if g.cache == nil {
+		cache, err := git.NewGitCacheWithAuth(
+			g.repoURL, g.auth, g.interval)
+		if err != nil {
+			g.mutex.Unlock()
+			return "", err
+		}
+		g.cache = cache
+	}
+	g.mutex.Unlock()
+
+	return g.cache.GetFileVersion(g.filePath)
+}
+
+// Invalidate makes the next Version pull the repository
+func (g *gitSource) Invalidate() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.cache != nil {
+		g.cache.Invalidate()
+	}
+}
+
+func (g *gitSource) Download(dir string, version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf(
+			"maskFileVersion is mandatory if maskFile is not local file.",
+		)
+	}
+
+	cloneDir, err := ioutil.TempDir("", "maskdir")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(cloneDir)
+
+	client := git.NewWithAuth(cloneDir, g.repoURL, g.auth)
+	klog.V(5).Infof("Downloading git repo: %s", g.repoURL)
+	err = client.Clone()
+	if err != nil {
+		return "", err
+	}
+	err = client.Checkout(version)
+	if err != nil {
+		return "", err
+	}
+	klog.V(5).Infof("Downloaded git repo at: %s", cloneDir)
+
+	sourceFile := filepath.Join(cloneDir, g.filePath)
+	destFile := destPath(dir, g.filePath)
+	_, err = git.Copy(sourceFile, destFile)
+	if err != nil {
+		return "", fmt.Errorf(
+			"Error copying! src: %s, dest: %s, err:%v\n",
+			sourceFile, destFile, err)
+	}
+	klog.V(5).Info("Copied the mask file at the read location")
+
+	return destFile, nil
+}
+
+func (g *gitSource) FileURL(version string) string {
+	switch g.provider {
+	case providerGithub:
+		return fmt.Sprintf(
+			"https://github.com/%s/blob/%s/%s", g.repo, version, g.filePath)
+	case providerGitlab:
+		return fmt.Sprintf(
+			"https://%s/%s/-/blob/%s/%s", g.host, g.repo, version, g.filePath)
+	case providerBitbucket:
+		return fmt.Sprintf(
+			"https://bitbucket.org/%s/src/%s/%s", g.repo, version, g.filePath)
+	default:
+		return ""
+	}
+}
+
+func (g *gitSource) DiffURL(from string, to string) string {
+	if from == "" || to == "" {
+		return ""
+	}
+
+	switch g.provider {
+	case providerGithub:
+		return fmt.Sprintf(
+			"https://github.com/%s/compare/%s...%s", g.repo, from, to)
+	case providerGitlab:
+		return fmt.Sprintf(
+			"https://%s/%s/-/compare/%s...%s", g.host, g.repo, from, to)
+	case providerBitbucket:
+		return fmt.Sprintf(
+			"https://bitbucket.org/%s/branches/compare/%s%%0D%s#diff",
+			g.repo, to, from)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,127 @@
+package masksource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// versionIDRegex matches the version ids which can be used in the names of
+// the consumer groups and the topics. "-" is not allowed as it separates
+// the mask salt version from the mask file version.
+var versionIDRegex = regexp.MustCompile(`^[A-Za-z0-9._]+$`)
+
+// s3Source is the mask file kept as an s3 object, the version is the
+// version id of the object so versioning should be enabled on the bucket.
+type s3Source struct {
+	bucket string
+	key    string
+	region string
+	client s3iface.S3API
+}
+
+func newS3Source(maskFile string, config Config) (Source, error) {
+	path := strings.TrimPrefix(maskFile, s3Scheme)
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf(
+			"expected s3://bucket/key, got: %s", maskFile)
+	}
+
+	awsConfig := &aws.Config{
+		Region: aws.String(config.S3Region),
+	}
+	if config.S3AccessKeyId != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(
+			config.S3AccessKeyId, config.S3SecretAccessKey, "")
+	}
+	awsConfig = awsConfig.WithCredentialsChainVerboseErrors(true)
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Source{
+		bucket: parts[0],
+		key:    parts[1],
+		region: config.S3Region,
+		client: s3.New(sess),
+	}, nil
+}
+
+func (s *s3Source) Version() (string, error) {
+	head, err := s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	versionID := aws.StringValue(head.VersionId)
+	if versionID == "" || versionID == "null" {
+		return "", fmt.Errorf(
+			"versioning is required on the bucket: %s for the mask file",
+			s.bucket)
+	}
+	if !versionIDRegex.MatchString(versionID) {
+		return "", fmt.Errorf(
+			"version id: %s of the mask file is not supported, expected: %s",
+			versionID, versionIDRegex)
+	}
+
+	return versionID, nil
+}
+
+func (s *s3Source) Download(dir string, version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf(
+			"maskFileVersion is mandatory if maskFile is not local file.",
+		)
+	}
+
+	object, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket:    aws.String(s.bucket),
+		Key:       aws.String(s.key),
+		VersionId: aws.String(version),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer object.Body.Close()
+
+	data, err := ioutil.ReadAll(object.Body)
+	if err != nil {
+		return "", err
+	}
+
+	destFile := destPath(dir, s.key)
+	err = ioutil.WriteFile(destFile, data, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return destFile, nil
+}
+
+func (s *s3Source) FileURL(version string) string {
+	return fmt.Sprintf(
+		"https://s3.console.aws.amazon.com/s3/object/%s?region=%s&prefix=%s&versionId=%s",
+		s.bucket,
+		s.region,
+		url.QueryEscape(s.key),
+		url.QueryEscape(version),
+	)
+}
+
+func (s *s3Source) DiffURL(from string, to string) string {
+	return ""
+}
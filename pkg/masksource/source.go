@@ -0,0 +1,140 @@
+// Package masksource fetches the mask file from the locations it is kept
+// at and gives the versions of its content.
+package masksource
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	s3Scheme        = "s3://"
+	configMapScheme = "configmap://"
+)
+
+// Source is the location of the mask file
+type Source interface {
+	// Version returns the version of the latest content of the mask file
+	Version() (string, error)
+
+	// Download keeps the mask file at the version in the dir and returns
+	// the local path of the file.
+	Download(dir string, version string) (string, error)
+
+	// FileURL returns the link to view the mask file at the version,
+	// empty if the source can not be viewed in the browser.
+	FileURL(version string) string
+
+	// DiffURL returns the link to view the changes between the versions,
+	// empty if the source does not support it.
+	DiffURL(from string, to string) string
+}
+
+// Config has the credentials and clients used by the sources
+type Config struct {
+	GitAccessToken   string
+	GitSSHKey        string
+	GitSSHKnownHosts string
+
+	// GitPollInterval is the interval at which the git repositories are
+	// pulled to find the version, defaults to git.DefaultCacheValidity.
+	GitPollInterval time.Duration
+
+	S3Region          string
+	S3AccessKeyId     string
+	S3SecretAccessKey string
+
+	// Namespace and Client are required for the configmap source,
+	// the configmap is read from the Namespace.
+	Namespace string
+	Client    client.Client
+}
+
+// CredentialsHash returns the hash of the credentials in the config, it
+// changes when the credentials are rotated so that the sources made with
+// the old credentials are not used anymore.
+func (c Config) CredentialsHash() string {
+	credentials := strings.Join([]string{
+		c.GitAccessToken,
+		c.GitSSHKey,
+		c.GitSSHKnownHosts,
+		c.S3Region,
+		c.S3AccessKeyId,
+		c.S3SecretAccessKey,
+	}, "\x00")
+
+	return fmt.Sprintf("%x", sha1.Sum([]byte(credentials)))
+}
+
+// New returns the source of the mask file. maskFile can be:
+//
+//	local path: /usr/inventory.yaml or file:///usr/inventory.yaml
+//	github:     https://github.com/org/repo/path/inventory.yaml
+//	gitlab:     https://gitlab.com/group/repo/path/inventory.yaml
+//	bitbucket:  https://bitbucket.org/workspace/repo/path/inventory.yaml
+//	git:        https://git.example.com/org/repo.git//path/inventory.yaml
+//	            git@git.example.com:org/repo.git//path/inventory.yaml
+//	s3:         s3://bucket/path/inventory.yaml
+//	configmap:  configmap://name/inventory.yaml
+func New(maskFile string, config Config) (Source, error) {
+	switch {
+	case strings.HasPrefix(maskFile, s3Scheme):
+		return newS3Source(maskFile, config)
+	case strings.HasPrefix(maskFile, configMapScheme):
+		return newConfigMapSource(maskFile, config)
+	}
+
+	return newGitSource(maskFile, config)
+}
+
+// Invalidater is implemented by the sources which cache the version
+type Invalidater interface {
+	// Invalidate makes the next Version fetch the latest version
+	Invalidate()
+}
+
+// Pruner is implemented by the sources which keep a copy of every version
+type Pruner interface {
+	// Prune deletes the copies except the ones of the versions given
+	Prune(versions []string) error
+}
+
+// IsS3 tells if the mask file is kept in s3
+func IsS3(maskFile string) bool {
+	return strings.HasPrefix(maskFile, s3Scheme)
+}
+
+// IsConfigMap tells if the mask file is kept in a configmap
+func IsConfigMap(maskFile string) bool {
+	return strings.HasPrefix(maskFile, configMapScheme)
+}
+
+// destPath returns the local path to keep the downloaded file
+func destPath(dir string, file string) string {
+	return filepath.Join(dir, filepath.Base(file))
+}
+
+// shortVersion is the version shown in the links
+func shortVersion(version string) string {
+	if len(version) >= 6 {
+		return version[:6]
+	}
+
+	return version
+}
+
+// VersionLink returns the version as a slack link to the file at
+// the version, just the short version if the source has no links.
+func VersionLink(source Source, version string) string {
+	url := source.FileURL(version)
+	if url == "" {
+		return shortVersion(version)
+	}
+
+	return fmt.Sprintf("<%s | %s>", url, shortVersion(version))
+}
@@ -0,0 +1,248 @@
+package masksource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+func TestGitSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		maskFile string
+		repoURL  string
+		filePath string
+		fileURL  string
+		diffURL  string
+		err      bool
+	}{
+		{
+			name:     "github",
+			maskFile: "https://github.com/practo/tipoca-stream/pkg/database.yaml",
+			repoURL:  "https://github.com/practo/tipoca-stream",
+			filePath: "pkg/database.yaml",
+			fileURL:  "https://github.com/practo/tipoca-stream/blob/v2/pkg/database.yaml",
+			diffURL:  "https://github.com/practo/tipoca-stream/compare/v1...v2",
+		},
+		{
+			name:     "gitlab subgroup",
+			maskFile: "https://gitlab.com/practo/data/masks/-/inventory/database.yaml",
+			repoURL:  "https://gitlab.com/practo/data/masks",
+			filePath: "inventory/database.yaml",
+			fileURL:  "https://gitlab.com/practo/data/masks/-/blob/v2/inventory/database.yaml",
+			diffURL:  "https://gitlab.com/practo/data/masks/-/compare/v1...v2",
+		},
+		{
+			name:     "self hosted gitlab",
+			maskFile: "https://gitlab.practo.com/data/masks/database.yaml",
+			repoURL:  "https://gitlab.practo.com/data/masks",
+			filePath: "database.yaml",
+			fileURL:  "https://gitlab.practo.com/data/masks/-/blob/v2/database.yaml",
+			diffURL:  "https://gitlab.practo.com/data/masks/-/compare/v1...v2",
+		},
+		{
+			name:     "bitbucket",
+			maskFile: "https://bitbucket.org/practo/masks/inventory/database.yaml",
+			repoURL:  "https://bitbucket.org/practo/masks",
+			filePath: "inventory/database.yaml",
+			fileURL:  "https://bitbucket.org/practo/masks/src/v2/inventory/database.yaml",
+			diffURL:  "https://bitbucket.org/practo/masks/branches/compare/v2%0Dv1#diff",
+		},
+		{
+			name:     "generic https git",
+			maskFile: "https://git.practo.com/data/masks.git//inventory/database.yaml",
+			repoURL:  "https://git.practo.com/data/masks.git",
+			filePath: "inventory/database.yaml",
+		},
+		{
+			name:     "generic git without separator",
+			maskFile: "https://git.practo.com/data/masks/database.yaml",
+			err:      true,
+		},
+		{
+			name:     "ssh git requires the key",
+			maskFile: "git@git.practo.com:data/masks.git//database.yaml",
+			err:      true,
+		},
+		{
+			name:     "github without file",
+			maskFile: "https://github.com/practo/tipoca-stream",
+			err:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			source, err := New(tc.maskFile, Config{})
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := source.(*gitSource)
+			if g.repoURL != tc.repoURL {
+				t.Errorf("repoURL expected: %v, got: %v\n", tc.repoURL, g.repoURL)
+			}
+			if g.filePath != tc.filePath {
+				t.Errorf("filePath expected: %v, got: %v\n", tc.filePath, g.filePath)
+			}
+			if fileURL := source.FileURL("v2"); fileURL != tc.fileURL {
+				t.Errorf("fileURL expected: %v, got: %v\n", tc.fileURL, fileURL)
+			}
+			if diffURL := source.DiffURL("v1", "v2"); diffURL != tc.diffURL {
+				t.Errorf("diffURL expected: %v, got: %v\n", tc.diffURL, diffURL)
+			}
+			if diffURL := source.DiffURL("", "v2"); diffURL != "" {
+				t.Errorf("diffURL expected empty for first version, got: %v\n", diffURL)
+			}
+		})
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "masksource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	maskFile := filepath.Join(dir, "database.yaml")
+	err = ioutil.WriteFile(maskFile, []byte("non_pii_keys: {}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := New(maskFile, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := source.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "4db89bd9ca30dc4ee5144736b2dfe9237d800b8b" {
+		t.Errorf("unexpected version: %v\n", version)
+	}
+	if link := VersionLink(source, version); link != "4db89b" {
+		t.Errorf("expected version without link, got: %v\n", link)
+	}
+	path, err := source.Download(dir, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != maskFile {
+		t.Errorf("expected: %v, got: %v\n", maskFile, path)
+	}
+}
+
+func TestParseSources(t *testing.T) {
+	t.Parallel()
+
+	name, key, err := ParseConfigMap("configmap://masks/inventory.yaml")
+	if err != nil || name != "masks" || key != "inventory.yaml" {
+		t.Errorf("unexpected name: %v, key: %v, err: %v", name, key, err)
+	}
+	_, err = New("configmap://masks/inventory.yaml", Config{})
+	if err == nil {
+		t.Errorf("expected error without client")
+	}
+	_, err = New("s3://bucket", Config{})
+	if err == nil {
+		t.Errorf("expected error without key")
+	}
+	if SnapshotName("masks", "e5e7e5c4de6d2bd37dfc") != "masks-mask-e5e7e5c4de" {
+		t.Errorf("unexpected snapshot name")
+	}
+}
+
+type fakeS3Client struct {
+	s3iface.S3API
+	versionID string
+}
+
+func (f *fakeS3Client) HeadObject(
+	input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	return &s3.HeadObjectOutput{VersionId: aws.String(f.versionID)}, nil
+}
+
+func TestS3SourceVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		versionID string
+		err       bool
+	}{
+		{
+			name:      "version id",
+			versionID: "3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY_",
+		},
+		{
+			name:      "versioning disabled",
+			versionID: "null",
+			err:       true,
+		},
+		{
+			name:      "version id not usable in names",
+			versionID: "3HL4kqtJ-cpXroDTDmJ+rmSpXd3dIbrHY",
+			err:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			source := &s3Source{
+				bucket: "bucket",
+				key:    "database.yaml",
+				client: &fakeS3Client{versionID: tc.versionID},
+			}
+			version, err := source.Version()
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got version: %v", version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != tc.versionID {
+				t.Errorf("expected: %v, got: %v", tc.versionID, version)
+			}
+		})
+	}
+}
+
+func TestCredentialsHash(t *testing.T) {
+	t.Parallel()
+
+	config := Config{GitAccessToken: "token", S3Region: "ap-south-1"}
+	if config.CredentialsHash() != config.CredentialsHash() {
+		t.Errorf("expected the same hash for the same credentials")
+	}
+	rotated := config
+	rotated.GitAccessToken = "rotated"
+	if config.CredentialsHash() == rotated.CredentialsHash() {
+		t.Errorf("expected the hash to change when the credentials change")
+	}
+	moved := Config{GitAccessToken: "tokenap-south-1"}
+	if config.CredentialsHash() == moved.CredentialsHash() {
+		t.Errorf("expected the hash to separate the credentials")
+	}
+}
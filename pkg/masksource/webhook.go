@@ -0,0 +1,209 @@
+package masksource
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/practo/tipoca-stream/pkg/git"
+)
+
+const (
+	// maxWebhookBody is the largest payload sent by github
+	maxWebhookBody = 25 << 20
+
+	githubEventHeader     = "X-GitHub-Event"
+	githubSignatureHeader = "X-Hub-Signature-256"
+	gitlabEventHeader     = "X-Gitlab-Event"
+	gitlabTokenHeader     = "X-Gitlab-Token"
+)
+
+// ErrWebhookSignature is returned when the webhook is not signed
+// with the secret
+var ErrWebhookSignature = errors.New("webhook signature verification failed")
+
+// Push is a push to the default branch of a git repository
+type Push struct {
+	Host string
+	Repo string
+
+	// Files are the files changed by the push, AllFiles is set when the
+	// payload does not have all the commits of the push.
+	Files    []string
+	AllFiles bool
+}
+
+type pushCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+type githubPush struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		HTMLURL       string `json:"html_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+	Commits []pushCommit `json:"commits"`
+}
+
+type gitlabPush struct {
+	Ref     string `json:"ref"`
+	Project struct {
+		WebURL        string `json:"web_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"project"`
+	Commits           []pushCommit `json:"commits"`
+	TotalCommitsCount int          `json:"total_commits_count"`
+}
+
+// ParseWebhook verifies the github or gitlab push webhook with the secret
+// and returns the push. It returns nil for the events other than the pushes
+// to the default branch, as the mask file versions are read from it.
+func ParseWebhook(req *http.Request, secret string) (*Push, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, req.Body, maxWebhookBody))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading webhook, err: %v", err)
+	}
+
+	switch {
+	case req.Header.Get(githubEventHeader) != "":
+		if !validGithubSignature(
+			body, req.Header.Get(githubSignatureHeader), secret) {
+			return nil, ErrWebhookSignature
+		}
+		if req.Header.Get(githubEventHeader) != "push" {
+			return nil, nil
+		}
+		return parseGithubPush(req.Header.Get("Content-Type"), body)
+	case req.Header.Get(gitlabEventHeader) != "":
+		token := req.Header.Get(gitlabTokenHeader)
+		if secret == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return nil, ErrWebhookSignature
+		}
+		if req.Header.Get(gitlabEventHeader) != "Push Hook" {
+			return nil, nil
+		}
+		return parseGitlabPush(body)
+	}
+
+	return nil, fmt.Errorf("webhook is neither from github nor gitlab")
+}
+
+// validGithubSignature checks the hmac sha256 of the body set by github
+func validGithubSignature(body []byte, signature, secret string) bool {
+	if secret == "" || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func parseGithubPush(contentType string, body []byte) (*Push, error) {
+	// the signature is of the raw body, so the form is parsed after it
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(values.Get("payload"))
+	}
+
+	var payload githubPush
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing github push, err: %v", err)
+	}
+	if payload.Ref != "refs/heads/"+payload.Repository.DefaultBranch {
+		return nil, nil
+	}
+
+	return newPush(payload.Repository.HTMLURL, payload.Commits, false)
+}
+
+func parseGitlabPush(body []byte) (*Push, error) {
+	var payload gitlabPush
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing gitlab push, err: %v", err)
+	}
+	if payload.Ref != "refs/heads/"+payload.Project.DefaultBranch {
+		return nil, nil
+	}
+
+	// gitlab sends only the first 20 commits of the push
+	return newPush(
+		payload.Project.WebURL,
+		payload.Commits,
+		payload.TotalCommitsCount > len(payload.Commits),
+	)
+}
+
+func newPush(repoURL string, commits []pushCommit, allFiles bool) (*Push, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid repository url: %s in push", repoURL)
+	}
+
+	push := &Push{
+		Host:     u.Hostname(),
+		Repo:     strings.Trim(u.Path, "/"),
+		AllFiles: allFiles,
+	}
+	for _, commit := range commits {
+		push.Files = append(push.Files, commit.Added...)
+		push.Files = append(push.Files, commit.Modified...)
+		push.Files = append(push.Files, commit.Removed...)
+	}
+
+	return push, nil
+}
+
+// Touched tells if the push changed the mask file. It is false for the
+// mask files which are not kept in git.
+func Touched(maskFile string, push *Push) bool {
+	if strings.HasPrefix(maskFile, s3Scheme) ||
+		strings.HasPrefix(maskFile, configMapScheme) {
+		return false
+	}
+	u, err := git.ParseURL(maskFile)
+	if err != nil || u.Scheme == "file" {
+		return false
+	}
+	if !strings.EqualFold(u.Hostname(), push.Host) {
+		return false
+	}
+	repo, filePath, _, err := splitRepoPath(gitProvider(u.Host), u.Path)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(strings.TrimSuffix(repo, ".git"), push.Repo) {
+		return false
+	}
+	if push.AllFiles {
+		return true
+	}
+	for _, file := range push.Files {
+		if file == filePath {
+			return true
+		}
+	}
+
+	return false
+}
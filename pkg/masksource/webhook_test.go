@@ -0,0 +1,234 @@
+package masksource
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const (
+	githubPushBody = `{
+  "ref": "refs/heads/master",
+  "repository": {
+    "html_url": "https://github.com/practo/masks",
+    "default_branch": "master"
+  },
+  "commits": [
+    {"added": ["inventory.yaml"], "modified": [], "removed": []},
+    {"added": [], "modified": ["orders/database.yaml"], "removed": ["old.yaml"]}
+  ]
+}`
+	githubBranchPushBody = `{
+  "ref": "refs/heads/feature",
+  "repository": {
+    "html_url": "https://github.com/practo/masks",
+    "default_branch": "master"
+  },
+  "commits": [{"modified": ["inventory.yaml"]}]
+}`
+	gitlabPushBody = `{
+  "ref": "refs/heads/main",
+  "project": {
+    "web_url": "https://gitlab.practo.com/data/masks/inventory",
+    "default_branch": "main"
+  },
+  "commits": [{"modified": ["database.yaml"]}],
+  "total_commits_count": 25
+}`
+)
+
+func githubSignature(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseWebhook(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		push    *Push
+		err     error
+	}{
+		{
+			name: "github push",
+			headers: map[string]string{
+				githubEventHeader:     "push",
+				githubSignatureHeader: githubSignature(githubPushBody, "secret"),
+			},
+			body: githubPushBody,
+			push: &Push{
+				Host: "github.com",
+				Repo: "practo/masks",
+				Files: []string{
+					"inventory.yaml", "orders/database.yaml", "old.yaml",
+				},
+			},
+		},
+		{
+			name: "github wrong signature",
+			headers: map[string]string{
+				githubEventHeader:     "push",
+				githubSignatureHeader: githubSignature(githubPushBody, "other"),
+			},
+			body: githubPushBody,
+			err:  ErrWebhookSignature,
+		},
+		{
+			name: "github unsigned",
+			headers: map[string]string{
+				githubEventHeader: "push",
+			},
+			body: githubPushBody,
+			err:  ErrWebhookSignature,
+		},
+		{
+			name: "github ping",
+			headers: map[string]string{
+				githubEventHeader:     "ping",
+				githubSignatureHeader: githubSignature(`{}`, "secret"),
+			},
+			body: `{}`,
+		},
+		{
+			name: "github push to other branch",
+			headers: map[string]string{
+				githubEventHeader:     "push",
+				githubSignatureHeader: githubSignature(githubBranchPushBody, "secret"),
+			},
+			body: githubBranchPushBody,
+		},
+		{
+			name: "gitlab push with more commits than sent",
+			headers: map[string]string{
+				gitlabEventHeader: "Push Hook",
+				gitlabTokenHeader: "secret",
+			},
+			body: gitlabPushBody,
+			push: &Push{
+				Host:     "gitlab.practo.com",
+				Repo:     "data/masks/inventory",
+				Files:    []string{"database.yaml"},
+				AllFiles: true,
+			},
+		},
+		{
+			name: "gitlab wrong token",
+			headers: map[string]string{
+				gitlabEventHeader: "Push Hook",
+				gitlabTokenHeader: "other",
+			},
+			body: gitlabPushBody,
+			err:  ErrWebhookSignature,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(
+				"POST", "/mask-webhook", bytes.NewBufferString(tc.body))
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			push, err := ParseWebhook(req, "secret")
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("expected err: %v, got: %v\n", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(push, tc.push) {
+				t.Errorf("expected: %+v, got: %+v\n", tc.push, push)
+			}
+		})
+	}
+}
+
+func TestTouched(t *testing.T) {
+	t.Parallel()
+
+	github := &Push{
+		Host:  "github.com",
+		Repo:  "practo/masks",
+		Files: []string{"orders/database.yaml"},
+	}
+	gitlab := &Push{
+		Host:     "gitlab.practo.com",
+		Repo:     "data/masks/inventory",
+		AllFiles: true,
+	}
+
+	tests := []struct {
+		name     string
+		maskFile string
+		push     *Push
+		touched  bool
+	}{
+		{
+			name:     "changed file",
+			maskFile: "https://github.com/practo/masks/orders/database.yaml",
+			push:     github,
+			touched:  true,
+		},
+		{
+			name:     "ssh url of the changed file",
+			maskFile: "git@github.com:practo/masks.git//orders/database.yaml",
+			push:     github,
+			touched:  true,
+		},
+		{
+			name:     "other file",
+			maskFile: "https://github.com/practo/masks/inventory.yaml",
+			push:     github,
+			touched:  false,
+		},
+		{
+			name:     "other repo",
+			maskFile: "https://github.com/practo/tipoca-stream/orders/database.yaml",
+			push:     github,
+			touched:  false,
+		},
+		{
+			name:     "all files of the repo",
+			maskFile: "https://gitlab.practo.com/data/masks/inventory/-/database.yaml",
+			push:     gitlab,
+			touched:  true,
+		},
+		{
+			name:     "s3",
+			maskFile: "s3://masks/orders/database.yaml",
+			push:     github,
+			touched:  false,
+		},
+		{
+			name:     "local file",
+			maskFile: "/orders/database.yaml",
+			push:     github,
+			touched:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			touched := Touched(tc.maskFile, tc.push)
+			if touched != tc.touched {
+				t.Errorf("expected: %v, got: %v\n", tc.touched, touched)
+			}
+		})
+	}
+}
@@ -31,6 +31,8 @@ const (
 	RedshiftMaskedDataType       = "character varying(50)"
 	RedshiftMobileColType        = "character varying(10)"
 	RedshiftMaskedDataTypeLength = 50
+	RedshiftHashedDataType       = "character varying(64)"
+	RedshiftHashedDataTypeLength = 64
 
 	RedshiftNumeric             = "numeric"
 	RedshiftNumericMaxLength    = 38
@@ -43,6 +45,18 @@ const (
 	RedshiftTime      = "character varying(32)"
 	RedshiftTimeStamp = "timestamp without time zone"
 
+	RedshiftTimeStampTz = "timestamp with time zone"
+
+	// native time types, RedshiftTime is kept as varchar for compatibility
+	RedshiftTimeWithoutTz = "time without time zone"
+	RedshiftTimeTz        = "time with time zone"
+	// RedshiftTimeSeconds stores MySQL TIME range -838:59:59.999999 to
+	// 838:59:59.999999 as signed seconds
+	RedshiftTimeSeconds = "numeric(13,6)"
+
+	// RedshiftGeometry is loaded using hexadecimal EWKB
+	RedshiftGeometry = "geometry"
+
 	// required to support utf8 characters
 	// https://docs.aws.amazon.com/redshift/latest/dg/r_Character_types.html#r_Character_types-varchar-or-character-varying
 	RedshiftToMysqlCharacterRatio = 4.0
@@ -148,6 +162,7 @@ type ColInfo struct {
 	Name         string     `json:"name"`
 	Type         string     `json:"type"`
 	DebeziumType string     `json:"debeziumtype"`
+	LogicalType  string     `json:"logicaltype"`
 	SourceType   SourceType `yaml:"sourceType"`
 	DefaultVal   string     `json:"defaultval"`
 	NotNull      bool       `json:"notnull"`
@@ -525,9 +540,12 @@ func (r *Redshift) UpdateTable(ctx context.Context, inputTable, targetTable Tabl
 // 2. Create table with new schema t1
 // 3. UNLOAD the renamed table data t1_migrating to s3
 // 4. COPY the unloaded data from s3 to the new table t1
+// 5. DROP the renamed table t1_migrating with the views depending on it
+// The dropped views are returned so that they can be recreated on t1
+// using RecreateViews() after the transaction is committed.
 func (r *Redshift) ReplaceTable(
 	ctx context.Context, tx *sql.Tx, unLoadS3Key string, copyS3ManifestKey string,
-	inputTable, targetTable Table) error {
+	inputTable, targetTable Table) ([]View, error) {
 
 	klog.Infof("Strategy3: table-migration starting(slow), table: %s ...\n",
 		inputTable.Name)
@@ -538,15 +556,22 @@ func (r *Redshift) ReplaceTable(
 
 	exist, err := r.TableExist(ctx, targetTable.Meta.Schema, migrationTableName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if exist {
 		err := r.DropTable(ctx, tx, targetTable.Meta.Schema, migrationTableName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
+	// views follow the renamed table, capture them to recreate on the new one
+	views, err := r.CaptureDependentViews(
+		ctx, tx, targetTable.Meta.Schema, targetTable.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	renameSQL := fmt.Sprintf(
 		`ALTER TABLE %s RENAME TO "%s"`,
 		targetTableName,
@@ -555,22 +580,23 @@ func (r *Redshift) ReplaceTable(
 	klog.V(4).Infof("Running: %s", renameSQL)
 	_, err = tx.ExecContext(ctx, renameSQL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = r.CreateTable(ctx, tx, inputTable, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = r.Unload(ctx, tx,
+	err = r.unload(ctx, tx,
 		targetTable.Meta.Schema,
 		migrationTableName,
+		migrationSelectSQL(inputTable, targetTable),
 		unLoadS3Key,
 		false,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = r.Copy(ctx, tx,
@@ -583,14 +609,15 @@ func (r *Redshift) ReplaceTable(
 		true,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Try dropping table and ignore the error if any
-	// as this operation is always performed on start
-	r.DropTable(ctx, tx, targetTable.Meta.Schema, migrationTableName)
+	err = r.DropTableWithCascade(ctx, tx, targetTable.Meta.Schema, migrationTableName)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	return views, nil
 }
 
 func (r *Redshift) RenameTable(
@@ -807,11 +834,114 @@ func (r *Redshift) DropColumn(ctx context.Context, tx *sql.Tx, schema string, ta
 	return r.prepareAndExecute(ctx, tx, command)
 }
 
+// timeMigrationSQL returns the expression which converts the varchar time
+// column into the native time types. The varchar column can have the raw
+// Debezium microseconds (legacy) or the time formatted as [-]HHH:MM:SS.
+// Values which do not fit in the Redshift TIME range become NULL.
+func timeMigrationSQL(column, redshiftType string) string {
+	raw := fmt.Sprintf(`"%s" ~ '^-?[0-9]+$'`, column)
+	formatted := fmt.Sprintf(
+		`"%s" ~ '^-?[0-9]+:[0-9]{2}:[0-9]{2}([.][0-9]+)?$'`, column)
+
+	switch redshiftType {
+	case RedshiftTimeWithoutTz, RedshiftTimeTz:
+		cast := "time"
+		if redshiftType == RedshiftTimeTz {
+			cast = "timetz"
+		}
+		return fmt.Sprintf(
+			`CASE WHEN %s AND "%s"::bigint BETWEEN 0 AND 86400000000 `+
+				`THEN (TIMESTAMP 'epoch' + "%s"::bigint / 1000000.0 * INTERVAL '1 second')::%s `+
+				`WHEN %s AND "%s" ~ '^[0-1][0-9]:|^2[0-3]:|^24:00:00' THEN "%s"::%s `+
+				`ELSE NULL END`,
+			raw, column, column, cast,
+			formatted, column, column, cast,
+		)
+	case RedshiftTimeSeconds:
+		unsigned := fmt.Sprintf(`ltrim("%s", '-')`, column)
+		return fmt.Sprintf(
+			`CASE WHEN %s THEN "%s"::bigint / 1000000.0 `+
+				`WHEN %s THEN (CASE WHEN left("%s", 1) = '-' THEN -1 ELSE 1 END) * `+
+				`(split_part(%s, ':', 1)::bigint * 3600 + `+
+				`split_part(%s, ':', 2)::bigint * 60 + `+
+				`split_part(%s, ':', 3)::numeric(9,6)) `+
+				`ELSE NULL END`,
+			raw, column,
+			formatted, column,
+			unsigned, unsigned, unsigned,
+		)
+	}
+
+	return fmt.Sprintf(`"%s"`, column)
+}
+
+// geometryMigrationSQL returns the expression which keeps only the hex
+// EWKB values of the varchar spatial column, spatial values were not
+// loaded before GEOMETRY support.
+func geometryMigrationSQL(column string) string {
+	return fmt.Sprintf(
+		`CASE WHEN "%s" ~ '^([0-9A-Fa-f]{2})+$' THEN "%s" ELSE NULL END`,
+		column, column,
+	)
+}
+
+// migrationSelectSQL returns the select list to unload the target table
+// for the table migration. Columns are unloaded as they are except for the
+// varchar time and spatial columns migrating to the native types.
+func migrationSelectSQL(inputTable, targetTable Table) string {
+	targetColumns := make(map[string]ColInfo)
+	for _, column := range targetTable.Columns {
+		targetColumns[column.Name] = column
+	}
+
+	convert := false
+	var columns []string
+	for _, inCol := range inputTable.Columns {
+		targetCol, ok := targetColumns[inCol.Name]
+		if ok && targetCol.Type == RedshiftTime && inCol.Type != RedshiftTime {
+			switch inCol.Type {
+			case RedshiftTimeWithoutTz, RedshiftTimeTz, RedshiftTimeSeconds:
+				klog.V(2).Infof(
+					"%s, migrating time column: %s from %s to %s\n",
+					inputTable.Name, inCol.Name, targetCol.Type, inCol.Type,
+				)
+				columns = append(
+					columns, timeMigrationSQL(inCol.Name, inCol.Type))
+				convert = true
+				continue
+			}
+		}
+		if ok && inCol.Type == RedshiftGeometry &&
+			strings.Contains(targetCol.Type, RedshiftString) {
+			klog.V(2).Infof(
+				"%s, migrating spatial column: %s from %s to %s\n",
+				inputTable.Name, inCol.Name, targetCol.Type, inCol.Type,
+			)
+			columns = append(columns, geometryMigrationSQL(inCol.Name))
+			convert = true
+			continue
+		}
+		columns = append(columns, fmt.Sprintf(`"%s"`, inCol.Name))
+	}
+	if !convert {
+		return "*"
+	}
+
+	return strings.Join(columns, ", ")
+}
+
 // Unload copies data present in the table to s3
 // this loads data to s3 and generates a manifest file at s3key + manifest path
 func (r *Redshift) Unload(ctx context.Context, tx *sql.Tx,
 	schema string, table string, s3Key string, removeDuplicate bool) error {
 
+	return r.unload(ctx, tx, schema, table, "*", s3Key, removeDuplicate)
+}
+
+func (r *Redshift) unload(ctx context.Context, tx *sql.Tx,
+	schema string, table string, columns string, s3Key string,
+	removeDuplicate bool) error {
+
 	distinct := ""
 	if removeDuplicate {
 		distinct = "DISTINCT"
@@ -823,8 +953,10 @@ func (r *Redshift) Unload(ctx context.Context, tx *sql.Tx,
 		r.conf.S3SecretAccessKey,
 	)
 	unLoadSQL := fmt.Sprintf(
-		`UNLOAD ('select %s * from "%s"."%s"') TO '%s' %s manifest allowoverwrite addquotes escape delimiter ','`,
+		`UNLOAD ('select %s %s from "%s"."%s"') TO '%s' %s manifest allowoverwrite addquotes escape delimiter ','`,
 		distinct,
+		// quotes in the query needs to be escaped by quotes
+		strings.ReplaceAll(columns, "'", "''"),
 		schema,
 		table,
 		s3Key,
@@ -1258,7 +1390,15 @@ var mysqlToRedshiftTypeMap = map[string]string{
 	"bigint unsigned":             RedshiftNumeric,
 	"float":                       "real",
 	"json":                        RedshiftStringMax,
-	"polygon":                     RedshiftString,
+	"geometry":                    RedshiftGeometry,
+	"geometrycollection":          RedshiftGeometry,
+	"geomcollection":              RedshiftGeometry,
+	"linestring":                  RedshiftGeometry,
+	"multilinestring":             RedshiftGeometry,
+	"multipoint":                  RedshiftGeometry,
+	"multipolygon":                RedshiftGeometry,
+	"point":                       RedshiftGeometry,
+	"polygon":                     RedshiftGeometry,
 }
 
 func applyRange(masked bool, min, max, current int) int {
@@ -1365,6 +1505,22 @@ func applyLength(ratio float64, redshiftType,
 	}
 }
 
+// GetRedshiftStringDataType returns the string type which can keep the
+// source column string with extra length added to it.
+func GetRedshiftStringDataType(sourceColLength string, extraLength int) string {
+	lengthCol := computeLength(
+		sourceColLength,
+		RedshiftStringDefaultLength,
+		false,
+		RedshiftToMysqlCharacterRatio,
+	) + extraLength
+	if lengthCol > RedshiftStringMaxLength {
+		lengthCol = RedshiftStringMaxLength
+	}
+
+	return fmt.Sprintf("%s(%d)", RedshiftString, lengthCol)
+}
+
 // GetRedshiftDataType returns the mapped type for the sqlType's data type
 func GetRedshiftDataType(sqlType, debeziumType, sourceColType,
 	sourceColLength string, sourceColScale string,
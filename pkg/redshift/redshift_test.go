@@ -337,6 +337,26 @@ func TestRedshiftDataTypeGet(t *testing.T) {
 			expectedResult:  "boolean",
 			expectError:     false,
 		},
+		{
+			name:            "test29: POINT",
+			sqlType:         "mysql",
+			debeziumType:    "record",
+			sourceColType:   "POINT",
+			sourceColLength: "",
+			columnMasked:    false,
+			expectedResult:  "geometry",
+			expectError:     false,
+		},
+		{
+			name:            "test30: masked POLYGON",
+			sqlType:         "mysql",
+			debeziumType:    "record",
+			sourceColType:   "POLYGON",
+			sourceColLength: "",
+			columnMasked:    true,
+			expectedResult:  "character varying(50)",
+			expectError:     false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -389,3 +409,74 @@ func TestTableMetadataToMysqlRedshiftMap(t *testing.T) {
 	createTable = strings.TrimSuffix(createTable, ",")
 	createTable = createTable + " );"
 }
+
+func TestMigrationSelectSQL(t *testing.T) {
+	t.Parallel()
+
+	targetTable := Table{
+		Name: "shifts",
+		Columns: []ColInfo{
+			ColInfo{Name: "id", Type: RedshiftInteger},
+			ColInfo{Name: "starts_at", Type: RedshiftTime},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		startsAtType string
+		expected     string
+	}{
+		{
+			name:         "test1: no conversion",
+			startsAtType: RedshiftTime,
+			expected:     "*",
+		},
+		{
+			name:         "test2: varchar to time",
+			startsAtType: RedshiftTimeWithoutTz,
+			expected: `"id", ` + timeMigrationSQL(
+				"starts_at", RedshiftTimeWithoutTz),
+		},
+		{
+			name:         "test3: varchar to seconds",
+			startsAtType: RedshiftTimeSeconds,
+			expected: `"id", ` + timeMigrationSQL(
+				"starts_at", RedshiftTimeSeconds),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			inputTable := Table{
+				Name: "shifts",
+				Columns: []ColInfo{
+					ColInfo{Name: "id", Type: RedshiftInteger},
+					ColInfo{Name: "starts_at", Type: tc.startsAtType},
+				},
+			}
+			got := migrationSelectSQL(inputTable, targetTable)
+			if got != tc.expected {
+				t.Errorf("expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+
+	spatialTarget := Table{
+		Name:    "shops",
+		Columns: []ColInfo{ColInfo{Name: "area", Type: "character varying(256)"}},
+	}
+	spatialInput := Table{
+		Name:    "shops",
+		Columns: []ColInfo{ColInfo{Name: "area", Type: RedshiftGeometry}},
+	}
+	got := migrationSelectSQL(spatialInput, spatialTarget)
+	if got != geometryMigrationSQL("area") {
+		t.Errorf("expected: %v, got: %v\n", geometryMigrationSQL("area"), got)
+	}
+
+	timeSQL := timeMigrationSQL("starts_at", RedshiftTimeTz)
+	if !strings.Contains(timeSQL, "::timetz") {
+		t.Errorf("expected timetz cast, got: %v\n", timeSQL)
+	}
+}
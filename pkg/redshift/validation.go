@@ -0,0 +1,79 @@
+package redshift
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// BeginReadOnly begins a read only transaction, it is used to run
+// the validation queries which should never modify the database.
+func (r *Redshift) BeginReadOnly(ctx context.Context) (*sql.Tx, error) {
+	return r.dbExecCloser.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+}
+
+// QueryInt64 runs the query which returns a single number
+func (r *Redshift) QueryInt64(ctx context.Context, tx *sql.Tx, query string) (int64, error) {
+	var value sql.NullInt64
+	err := tx.QueryRowContext(ctx, query).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("failed sql:%s, err:%v\n", query, err)
+	}
+	if !value.Valid {
+		return 0, fmt.Errorf("failed sql:%s, returned null\n", query)
+	}
+
+	return value.Int64, nil
+}
+
+// RowCount returns the number of rows in the table
+func (r *Redshift) RowCount(ctx context.Context, tx *sql.Tx, schema, table string) (int64, error) {
+	return r.QueryInt64(ctx, tx, rowCountSQL(schema, table))
+}
+
+// DuplicateKeyCount returns the number of keys which are present in more
+// than one row of the table, the key is made of the columns.
+func (r *Redshift) DuplicateKeyCount(ctx context.Context, tx *sql.Tx,
+	schema, table string, columns []string) (int64, error) {
+
+	return r.QueryInt64(ctx, tx, duplicateKeyCountSQL(schema, table, columns))
+}
+
+// NullKeyCount returns the number of rows of the table which have
+// null in any of the columns.
+func (r *Redshift) NullKeyCount(ctx context.Context, tx *sql.Tx,
+	schema, table string, columns []string) (int64, error) {
+
+	return r.QueryInt64(ctx, tx, nullKeyCountSQL(schema, table, columns))
+}
+
+func quoteColumns(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = fmt.Sprintf(`"%s"`, column)
+	}
+
+	return quoted
+}
+
+func rowCountSQL(schema, table string) string {
+	return fmt.Sprintf(`SELECT COUNT(*) FROM "%s"."%s";`, schema, table)
+}
+
+func duplicateKeyCountSQL(schema, table string, columns []string) string {
+	keys := strings.Join(quoteColumns(columns), ", ")
+	return fmt.Sprintf(
+		`SELECT COUNT(*) FROM (SELECT %s FROM "%s"."%s" GROUP BY %s HAVING COUNT(*) > 1);`,
+		keys, schema, table, keys)
+}
+
+func nullKeyCountSQL(schema, table string, columns []string) string {
+	conditions := []string{}
+	for _, column := range quoteColumns(columns) {
+		conditions = append(conditions, column+" IS NULL")
+	}
+	return fmt.Sprintf(
+		`SELECT COUNT(*) FROM "%s"."%s" WHERE %s;`,
+		schema, table, strings.Join(conditions, " OR "))
+}
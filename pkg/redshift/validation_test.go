@@ -0,0 +1,43 @@
+package redshift
+
+import (
+	"testing"
+)
+
+func TestValidationSQL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{
+			name:     "test1: row count",
+			got:      rowCountSQL("inventory", "orders_reload_1a2b3c"),
+			expected: `SELECT COUNT(*) FROM "inventory"."orders_reload_1a2b3c";`,
+		},
+		{
+			name: "test2: duplicate keys",
+			got: duplicateKeyCountSQL(
+				"inventory", "orders", []string{"id", "shard"}),
+			expected: `SELECT COUNT(*) FROM (SELECT "id", "shard" FROM "inventory"."orders" GROUP BY "id", "shard" HAVING COUNT(*) > 1);`,
+		},
+		{
+			name: "test3: null keys",
+			got: nullKeyCountSQL(
+				"inventory", "orders", []string{"id", "shard"}),
+			expected: `SELECT COUNT(*) FROM "inventory"."orders" WHERE "id" IS NULL OR "shard" IS NULL;`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if tc.got != tc.expected {
+				t.Errorf("expected: %v, got: %v\n", tc.expected, tc.got)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package redshift
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	klog "github.com/practo/klog/v2"
+)
+
+const (
+	// returns the regular views depending on the table or view, late
+	// binding views do not have dependencies as they bind the tables by
+	// name, need to pass a schema and table name as the parameters
+	dependentViews = `SELECT DISTINCT pv.schemaname, pv.viewname, pv.definition,
+    array_to_string(v.relacl, '|')
+FROM pg_depend d
+  JOIN pg_rewrite rw ON rw.oid = d.objid
+  JOIN pg_class v ON v.oid = rw.ev_class
+  JOIN pg_namespace vn ON vn.oid = v.relnamespace
+  JOIN pg_class t ON t.oid = d.refobjid
+  JOIN pg_namespace tn ON tn.oid = t.relnamespace
+  JOIN pg_views pv ON pv.schemaname = vn.nspname AND pv.viewname = v.relname
+WHERE v.relkind = 'v'
+    AND v.oid <> t.oid
+    AND tn.nspname = '%s'
+    AND t.relname = '%s'
+ORDER BY pv.schemaname, pv.viewname;`
+)
+
+// aclPrivileges maps the privileges in the access privilege
+// of a view to the privileges used in the GRANT command
+var aclPrivileges = []struct {
+	code      byte
+	privilege string
+}{
+	{'r', "SELECT"},
+	{'a', "INSERT"},
+	{'w', "UPDATE"},
+	{'d', "DELETE"},
+	{'x', "REFERENCES"},
+}
+
+// View is a Redshift view with its definition
+type View struct {
+	Schema     string
+	Name       string
+	Definition string
+	Grants     []Grant
+}
+
+// Grant is the privileges granted on a view to a user, a group or public
+type Grant struct {
+	// Grantee is empty for public
+	Grantee    string
+	Group      bool
+	Privileges []string
+}
+
+// ViewFailure is a view which could not be recreated
+type ViewFailure struct {
+	View View
+	Err  error
+}
+
+func (f ViewFailure) String() string {
+	return fmt.Sprintf("%s.%s: %v", f.View.Schema, f.View.Name, f.Err)
+}
+
+func viewKey(schema, name string) string {
+	return schema + "." + name
+}
+
+// DependentViews returns the regular views which depend on the table
+func (r *Redshift) DependentViews(ctx context.Context, tx *sql.Tx,
+	schema, table string) ([]View, error) {
+
+	q := fmt.Sprintf(dependentViews, schema, table)
+	rows, err := tx.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed sql:%s, err:%v\n", q, err)
+	}
+	defer rows.Close()
+
+	views := []View{}
+	for rows.Next() {
+		var view View
+		var acl sql.NullString
+		err := rows.Scan(&view.Schema, &view.Name, &view.Definition, &acl)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning view, err: %s", err)
+		}
+		if acl.Valid {
+			view.Grants = parseACL(acl.String)
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating views, err: %s", err)
+	}
+
+	return views, nil
+}
+
+// CaptureDependentViews returns the regular views which depend on the
+// table directly or through other views, i.e. the views dropped by a
+// DROP TABLE CASCADE. The views are ordered so that they can be recreated
+// in the order, a view comes after the views it depends on.
+func (r *Redshift) CaptureDependentViews(ctx context.Context, tx *sql.Tx,
+	schema, table string) ([]View, error) {
+
+	views := make(map[string]View)
+	children := make(map[string][]string)
+
+	root := viewKey(schema, table)
+	queue := []View{{Schema: schema, Name: table}}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+
+		dependents, err := r.DependentViews(ctx, tx, parent.Schema, parent.Name)
+		if err != nil {
+			return nil, err
+		}
+		parentKey := viewKey(parent.Schema, parent.Name)
+		for _, view := range dependents {
+			key := viewKey(view.Schema, view.Name)
+			children[parentKey] = append(children[parentKey], key)
+			if _, ok := views[key]; ok {
+				continue
+			}
+			views[key] = view
+			queue = append(queue, view)
+		}
+	}
+
+	return orderViews(root, views, children), nil
+}
+
+// orderViews orders the views by the length of their longest
+// dependency path from the root so that a view comes after
+// all the views it depends on.
+func orderViews(
+	root string,
+	views map[string]View,
+	children map[string][]string,
+) []View {
+	depths := make(map[string]int)
+	var walk func(key string, depth int)
+	walk = func(key string, depth int) {
+		if current, ok := depths[key]; ok && current >= depth {
+			return
+		}
+		depths[key] = depth
+		for _, child := range children[key] {
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 0)
+
+	ordered := []View{}
+	for _, view := range views {
+		ordered = append(ordered, view)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		di := depths[viewKey(ordered[i].Schema, ordered[i].Name)]
+		dj := depths[viewKey(ordered[j].Schema, ordered[j].Name)]
+		if di != dj {
+			return di < dj
+		}
+		return viewKey(ordered[i].Schema, ordered[i].Name) <
+			viewKey(ordered[j].Schema, ordered[j].Name)
+	})
+
+	return ordered
+}
+
+// RecreateViews recreates the views with their grants. Every view is
+// recreated in its own transaction, as a failed statement aborts the
+// transaction, so that one broken view does not stop the others from
+// being recreated. The views which could not be recreated are returned.
+func (r *Redshift) RecreateViews(ctx context.Context, views []View) []ViewFailure {
+	failures := []ViewFailure{}
+	for _, view := range views {
+		err := r.recreateView(ctx, view)
+		if err != nil {
+			klog.Errorf("Error recreating view %s.%s, err: %v",
+				view.Schema, view.Name, err)
+			failures = append(failures, ViewFailure{View: view, Err: err})
+		}
+	}
+
+	return failures
+}
+
+func (r *Redshift) recreateView(ctx context.Context, view View) error {
+	tx, err := r.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Error creating database tx, err: %v", err)
+	}
+
+	for _, statement := range recreateViewSQL(view) {
+		klog.V(4).Infof("Running: %s", statement)
+		_, err := tx.ExecContext(ctx, statement)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceViewLateBinding replaces the view with a late binding view of the
+// same definition. Late binding views bind the tables by name when queried,
+// so they keep working when their tables are renamed or replaced.
+func (r *Redshift) ReplaceViewLateBinding(ctx context.Context, tx *sql.Tx,
+	view View) error {
+
+	replaceSQL := lateBindingViewSQL(view)
+	klog.V(4).Infof("Running: %s", replaceSQL)
+	_, err := tx.ExecContext(ctx, replaceSQL)
+	if err != nil {
+		return fmt.Errorf("Error replacing view %s.%s, err: %v",
+			view.Schema, view.Name, err)
+	}
+
+	return nil
+}
+
+func viewDefinition(view View) string {
+	definition := strings.TrimSpace(view.Definition)
+	return strings.TrimSpace(strings.TrimSuffix(definition, ";"))
+}
+
+func lateBindingViewSQL(view View) string {
+	return fmt.Sprintf(
+		`CREATE OR REPLACE VIEW "%s"."%s" AS %s WITH NO SCHEMA BINDING;`,
+		view.Schema, view.Name, viewDefinition(view))
+}
+
+// recreateViewSQL returns the statements to recreate the view
+// followed by the statements to grant its privileges
+func recreateViewSQL(view View) []string {
+	statements := []string{
+		fmt.Sprintf(`CREATE OR REPLACE VIEW "%s"."%s" AS %s;`,
+			view.Schema, view.Name, viewDefinition(view)),
+	}
+	for _, grant := range view.Grants {
+		grantee := "PUBLIC"
+		if grant.Grantee != "" {
+			grantee = fmt.Sprintf(`"%s"`, grant.Grantee)
+			if grant.Group {
+				grantee = "GROUP " + grantee
+			}
+		}
+		statements = append(statements, fmt.Sprintf(
+			`GRANT %s ON "%s"."%s" TO %s;`,
+			strings.Join(grant.Privileges, ", "),
+			view.Schema,
+			view.Name,
+			grantee,
+		))
+	}
+
+	return statements
+}
+
+// parseACL parses the access privileges of a relation, separated by |,
+// each of them is of the form: grantee=privileges/grantor. Groups are
+// prefixed with "group " and public has an empty grantee.
+func parseACL(acl string) []Grant {
+	grants := []Grant{}
+	for _, item := range strings.Split(acl, "|") {
+		item = strings.Trim(strings.TrimSpace(item), `"`)
+		equal := strings.LastIndex(item, "=")
+		if equal == -1 {
+			continue
+		}
+		grantee := item[:equal]
+		privileges := item[equal+1:]
+		if slash := strings.Index(privileges, "/"); slash != -1 {
+			privileges = privileges[:slash]
+		}
+
+		grant := Grant{Grantee: grantee}
+		if strings.HasPrefix(grantee, "group ") {
+			grant.Grantee = strings.TrimPrefix(grantee, "group ")
+			grant.Group = true
+		}
+		for _, p := range aclPrivileges {
+			if strings.IndexByte(privileges, p.code) != -1 {
+				grant.Privileges = append(grant.Privileges, p.privilege)
+			}
+		}
+		if len(grant.Privileges) == 0 {
+			continue
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants
+}
@@ -0,0 +1,120 @@
+package redshift
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLateBindingViewSQL(t *testing.T) {
+	t.Parallel()
+
+	view := View{
+		Schema:     "reports",
+		Name:       "daily_orders",
+		Definition: " SELECT orders.id FROM inventory.orders;",
+	}
+	expected := `CREATE OR REPLACE VIEW "reports"."daily_orders" AS SELECT orders.id FROM inventory.orders WITH NO SCHEMA BINDING;`
+
+	got := lateBindingViewSQL(view)
+	if got != expected {
+		t.Errorf("expected: %v, got: %v\n", expected, got)
+	}
+}
+
+func TestParseACL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		acl      string
+		expected []Grant
+	}{
+		{
+			name: "test1: owner, user, group and public",
+			acl:  `loader=arwdRxt/loader|analyst=r/loader|"group reporting=r/loader"|=r/loader`,
+			expected: []Grant{
+				{
+					Grantee:    "loader",
+					Privileges: []string{"SELECT", "INSERT", "UPDATE", "DELETE", "REFERENCES"},
+				},
+				{Grantee: "analyst", Privileges: []string{"SELECT"}},
+				{Grantee: "reporting", Group: true, Privileges: []string{"SELECT"}},
+				{Grantee: "", Privileges: []string{"SELECT"}},
+			},
+		},
+		{
+			name:     "test2: unsupported privileges only",
+			acl:      `analyst=Rt/loader`,
+			expected: []Grant{},
+		},
+		{
+			name:     "test3: empty",
+			acl:      "",
+			expected: []Grant{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := parseACL(tc.acl)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected: %+v, got: %+v\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRecreateViewSQL(t *testing.T) {
+	t.Parallel()
+
+	view := View{
+		Schema:     "reports",
+		Name:       "daily_orders",
+		Definition: " SELECT orders.id FROM inventory.orders;",
+		Grants: []Grant{
+			{Grantee: "analyst", Privileges: []string{"SELECT"}},
+			{Grantee: "reporting", Group: true, Privileges: []string{"SELECT", "REFERENCES"}},
+			{Privileges: []string{"SELECT"}},
+		},
+	}
+	expected := []string{
+		`CREATE OR REPLACE VIEW "reports"."daily_orders" AS SELECT orders.id FROM inventory.orders;`,
+		`GRANT SELECT ON "reports"."daily_orders" TO "analyst";`,
+		`GRANT SELECT, REFERENCES ON "reports"."daily_orders" TO GROUP "reporting";`,
+		`GRANT SELECT ON "reports"."daily_orders" TO PUBLIC;`,
+	}
+
+	got := recreateViewSQL(view)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected: %v, got: %v\n", expected, got)
+	}
+}
+
+func TestOrderViews(t *testing.T) {
+	t.Parallel()
+
+	// inventory.orders <- reports.a <- reports.c
+	// inventory.orders <- reports.b <- reports.a
+	// inventory.orders <- reports.c
+	views := map[string]View{
+		"reports.a": {Schema: "reports", Name: "a"},
+		"reports.b": {Schema: "reports", Name: "b"},
+		"reports.c": {Schema: "reports", Name: "c"},
+	}
+	children := map[string][]string{
+		"inventory.orders": {"reports.a", "reports.b", "reports.c"},
+		"reports.a":        {"reports.c"},
+		"reports.b":        {"reports.a"},
+	}
+
+	got := []string{}
+	for _, view := range orderViews("inventory.orders", views, children) {
+		got = append(got, viewKey(view.Schema, view.Name))
+	}
+	expected := []string{"reports.b", "reports.a", "reports.c"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected: %v, got: %v\n", expected, got)
+	}
+}
@@ -48,6 +48,11 @@ type batchProcessor struct {
 	msgMasker transformer.MessageTransformer
 	// maskMessages stores if the masking is enabled
 	maskMessages bool
+	// maskConfig is used to filter the rows based on the row filters
+	// of the table, rows are filtered irrespective of maskMessages.
+	maskConfig masker.MaskConfig
+	// table is the name of the table the topic is for
+	table string
 
 	// signaler is a kafka producer signaling the load the batch uploaded data
 	// TODO: make the producer have interface
@@ -74,6 +79,7 @@ func newBatchProcessor(
 	kafkaConfig kafka.KafkaConfig,
 	saramaConfig kafka.SaramaConfig,
 	maskConfig masker.MaskConfig,
+	timezone transformer.TimezoneConfig,
 	kafkaLoaderTopicPrefix string,
 	maxConcurrency int,
 ) (
@@ -110,6 +116,13 @@ func newBatchProcessor(
 		)
 	}
 
+	messageTransformer, err := debezium.NewMessageTransformer(topic, timezone)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Error making message transformer for topic: %s, err: %v",
+			topic, err)
+	}
+
 	registry := schemaregistry.NewRegistry(viper.GetString("schemaRegistryURL"))
 	// creates the loader schema for value if not present
 	loaderSchemaID, _, err := schemaregistry.CreateSchema(
@@ -142,6 +155,7 @@ func newBatchProcessor(
 	}
 
 	klog.V(2).Infof("%s: autoCommit: %v", topic, saramaConfig.AutoCommit)
+	_, _, table := transformer.ParseTopic(topic)
 
 	return &batchProcessor{
 		topic:              topic,
@@ -151,11 +165,15 @@ func newBatchProcessor(
 		autoCommit:         saramaConfig.AutoCommit,
 		s3sink:             sink,
 		s3BucketDir:        viper.GetString("s3sink.bucketDir"),
-		messageTransformer: debezium.NewMessageTransformer(),
+		messageTransformer: messageTransformer,
 		schemaTransformer: debezium.NewSchemaTransformer(
-			viper.GetString("schemaRegistryURL")),
+			viper.GetString("schemaRegistryURL"),
+			timezone,
+		),
 		msgMasker:      msgMasker,
 		maskMessages:   maskMessages,
+		maskConfig:     maskConfig,
+		table:          table,
 		signaler:       signaler,
 		maxConcurrency: maxConcurrency,
 		loaderSchemaID: loaderSchemaID,
@@ -182,6 +200,7 @@ type response struct {
 	startOffset       int64
 	endOffset         int64
 	messagesProcessed int
+	messagesFiltered  int
 	maskSchema        map[string]serializer.MaskInfo
 	extraMaskSchema   map[string]serializer.ExtraMaskInfo
 	bytesProcessed    int64
@@ -319,7 +338,7 @@ func (b *batchProcessor) processMessage(
 	message *serializer.Message,
 	resp *response,
 	messageID int,
-) (int64, error) {
+) (int64, bool, error) {
 	var bytesProcessed int64
 
 	klog.V(5).Infof(
@@ -339,7 +358,7 @@ func (b *batchProcessor) processMessage(
 			resp.extraMaskSchema,
 		)
 		if err != nil {
-			return bytesProcessed, fmt.Errorf(
+			return bytesProcessed, false, fmt.Errorf(
 				"transforming schema:%d => inputTable failed: %v",
 				resp.batchSchemaID,
 				err,
@@ -357,7 +376,7 @@ func (b *batchProcessor) processMessage(
 	}
 
 	if resp.batchSchemaID != message.SchemaId {
-		return bytesProcessed, fmt.Errorf("%s: schema id mismatch in the batch, %d != %d",
+		return bytesProcessed, false, fmt.Errorf("%s: schema id mismatch in the batch, %d != %d",
 			b.topic,
 			resp.batchSchemaID,
 			message.SchemaId,
@@ -366,15 +385,26 @@ func (b *batchProcessor) processMessage(
 
 	err := b.messageTransformer.Transform(message, resp.batchSchemaTable)
 	if err != nil {
-		return bytesProcessed, fmt.Errorf(
+		return bytesProcessed, false, fmt.Errorf(
 			"Error transforming message:%+v, err:%v", message, err,
 		)
 	}
 
+	// row filters are evaluated on the unmasked values
+	if !b.maskConfig.KeepRow(b.table, message.Value.(map[string]*string)) {
+		klog.V(5).Infof(
+			"%s: batchID:%d id:%d: filtered\n",
+			b.topic, resp.batchID, messageID,
+		)
+		resp.messagesFiltered += 1
+		resp.endOffset = message.Offset
+		return bytesProcessed, true, nil
+	}
+
 	if b.maskMessages {
 		err := b.msgMasker.Transform(message, resp.batchSchemaTable)
 		if err != nil {
-			return bytesProcessed, fmt.Errorf(
+			return bytesProcessed, false, fmt.Errorf(
 				"Error masking message:%+v, err:%v", message, err)
 		}
 	}
@@ -382,7 +412,7 @@ func (b *batchProcessor) processMessage(
 	message.Value = removeEmptyNullValues(message.Value.(map[string]*string))
 	messageValueBytes, err := json.Marshal(message.Value)
 	if err != nil {
-		return bytesProcessed, fmt.Errorf(
+		return bytesProcessed, false, fmt.Errorf(
 			"Error marshalling message.Value, message: %+v", message)
 	}
 
@@ -405,7 +435,7 @@ func (b *batchProcessor) processMessage(
 	)
 	resp.endOffset = message.Offset
 
-	return bytesProcessed, nil
+	return bytesProcessed, false, nil
 }
 
 // processMessages handles the batch procesing and return true if all completes
@@ -422,10 +452,14 @@ func (b *batchProcessor) processMessages(
 		case <-ctx.Done():
 			return totalBytesProcessed, kafka.ErrSaramaSessionContextDone
 		default:
-			bytesProcessed, err := b.processMessage(ctx, message, resp, messageID)
+			bytesProcessed, filtered, err := b.processMessage(
+				ctx, message, resp, messageID)
 			if err != nil {
 				return totalBytesProcessed, err
 			}
+			if filtered {
+				continue
+			}
 			totalBytesProcessed += bytesProcessed
 
 			switch message.Operation {
@@ -468,6 +502,16 @@ func (b *batchProcessor) processBatch(
 		return
 	}
 
+	// nothing to upload and load when all the rows were filtered
+	if resp.messagesFiltered == len(msgBuf) {
+		klog.V(2).Infof(
+			"%s: batchID:%d, startOffset:%d, endOffset:%d: all filtered",
+			b.topic, resp.batchID, resp.startOffset, resp.endOffset,
+		)
+		resp.messagesProcessed = len(msgBuf)
+		return
+	}
+
 	// Upload
 	klog.V(4).Infof("%s: batchId:%d, size:%d: uploading...",
 		b.topic, resp.batchID, len(msgBuf),
@@ -575,10 +619,12 @@ func (b *batchProcessor) Process(
 		// return if there was any error in processing any of the batches
 		var totalBytesProcessed int64 = 0
 		totalMessagesProcessed := 0
+		totalMessagesFiltered := 0
 		var errors error
 		for _, resp := range responses {
 			totalBytesProcessed += resp.bytesProcessed
 			totalMessagesProcessed += resp.messagesProcessed
+			totalMessagesFiltered += resp.messagesFiltered
 			if resp.err != nil {
 				if resp.err == kafka.ErrSaramaSessionContextDone {
 					klog.V(2).Infof(
@@ -628,6 +674,9 @@ func (b *batchProcessor) Process(
 				)
 				return
 			}
+			if resp.messagesFiltered == resp.messagesProcessed {
+				continue
+			}
 			err := b.signalLoad(resp)
 			if err != nil {
 				// send to channel with context check, fix #170
@@ -658,6 +707,7 @@ func (b *batchProcessor) Process(
 		// set cumulative metrics
 		b.metric.setBytesProcessed(totalBytesProcessed)
 		b.metric.setMsgsProcessed(totalMessagesProcessed)
+		b.metric.setMsgsFiltered(totalMessagesFiltered)
 
 		klog.V(2).Infof(
 			"%s: startOffset:%d, endOffset:%d, processed",
@@ -7,6 +7,7 @@ import (
 	"github.com/practo/klog/v2"
 	"github.com/practo/tipoca-stream/pkg/kafka"
 	"github.com/practo/tipoca-stream/pkg/serializer"
+	"github.com/practo/tipoca-stream/pkg/transformer"
 	"github.com/practo/tipoca-stream/pkg/transformer/masker"
 	"github.com/spf13/viper"
 	"sync"
@@ -53,6 +54,10 @@ type BatcherConfig struct {
 	// If this is specified, maxSize specification is not considered.
 	// Default would be specified after MaxSize is gone
 	MaxBytesPerBatch *int64 `yaml:"maxBytesPerBatch,omitempty"`
+
+	// Timezone specifies the timezone of the temporal columns in the source
+	// database and if the timestamp columns should be TIMESTAMPTZ
+	Timezone transformer.TimezoneConfig `yaml:"timezone,omitempty"`
 }
 
 // batcherHandler is the sarama consumer handler
@@ -71,6 +76,7 @@ type batcherHandler struct {
 	kafkaConfig            kafka.KafkaConfig
 	saramaConfig           kafka.SaramaConfig
 	maskConfig             masker.MaskConfig
+	timezone               transformer.TimezoneConfig
 	serializer             serializer.Serializer
 	kafkaLoaderTopicPrefix string
 }
@@ -109,6 +115,7 @@ func NewHandler(
 		kafkaConfig:            kafkaConfig,
 		saramaConfig:           saramaConfig,
 		maskConfig:             maskConfig,
+		timezone:               batcherConfig.Timezone,
 		serializer:             serializer.NewSerializer(viper.GetString("schemaRegistryURL")),
 		kafkaLoaderTopicPrefix: loaderPrefix,
 	}
@@ -159,6 +166,7 @@ func (h *batcherHandler) ConsumeClaim(
 		h.kafkaConfig,
 		h.saramaConfig,
 		h.maskConfig,
+		h.timezone,
 		h.kafkaLoaderTopicPrefix,
 		*h.maxConcurrency,
 	)
@@ -23,11 +23,21 @@ var (
 		},
 		[]string{"consumergroup", "topic", "sinkGroup"},
 	)
+	msgsFilteredMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "rsk",
+			Subsystem: "batcher",
+			Name:      "messages_filtered_total",
+			Help:      "total number of messages filtered by the row filters",
+		},
+		[]string{"consumergroup", "topic", "sinkGroup"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(bytesProcessedMetric)
 	prometheus.MustRegister(msgsProcessedMetric)
+	prometheus.MustRegister(msgsFilteredMetric)
 }
 
 type metricSetter struct {
@@ -51,3 +61,11 @@ func (m metricSetter) setMsgsProcessed(msgs int) {
 		m.sinkGroup,
 	).Observe(float64(msgs))
 }
+
+func (m metricSetter) setMsgsFiltered(msgs int) {
+	msgsFilteredMetric.WithLabelValues(
+		m.consumergroup,
+		m.topic,
+		m.sinkGroup,
+	).Add(float64(msgs))
+}
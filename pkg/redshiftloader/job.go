@@ -182,7 +182,7 @@ func ToMaskSchemaMap(r string) map[string]serializer.MaskInfo {
 
 		info := strings.Split(col, ",")
 		name := info[0]
-		var masked, sortCol, distCol, lengthCol, mobileCol, mappingPIICol, conditionalNonPIICol, dependentNonPIICol, regexPatternBooleanCol bool
+		var masked, sortCol, distCol, lengthCol, mobileCol, mappingPIICol, conditionalNonPIICol, dependentNonPIICol, regexPatternBooleanCol, excludedCol bool
 		if info[1] == "true" {
 			masked = true
 		}
@@ -218,6 +218,23 @@ func ToMaskSchemaMap(r string) map[string]serializer.MaskInfo {
 				regexPatternBooleanCol = true
 			}
 		}
+		if len(info) >= 11 {
+			if info[10] == "true" {
+				excludedCol = true
+			}
+		}
+
+		var maskFunction string
+		if len(info) >= 12 {
+			maskFunction = info[11]
+		}
+
+		var jsonPathCol bool
+		if len(info) >= 13 {
+			if info[12] == "true" {
+				jsonPathCol = true
+			}
+		}
 
 		m[name] = serializer.MaskInfo{
 			Masked:                 masked,
@@ -229,6 +246,9 @@ func ToMaskSchemaMap(r string) map[string]serializer.MaskInfo {
 			ConditionalNonPIICol:   conditionalNonPIICol,
 			DependentNonPIICol:     dependentNonPIICol,
 			RegexPatternBooleanCol: regexPatternBooleanCol,
+			ExcludedCol:            excludedCol,
+			JSONPathCol:            jsonPathCol,
+			MaskFunction:           maskFunction,
 		}
 	}
 
@@ -242,7 +262,7 @@ func ToMaskSchemaString(m map[string]serializer.MaskInfo) string {
 
 	for name, info := range m {
 		col := fmt.Sprintf(
-			"%s,%t,%t,%t,%t,%t,%t,%t,%t,%t",
+			"%s,%t,%t,%t,%t,%t,%t,%t,%t,%t,%t,%s,%t",
 			name,
 			info.Masked,
 			info.SortCol,
@@ -253,6 +273,9 @@ func ToMaskSchemaString(m map[string]serializer.MaskInfo) string {
 			info.ConditionalNonPIICol,
 			info.DependentNonPIICol,
 			info.RegexPatternBooleanCol,
+			info.ExcludedCol,
+			info.MaskFunction,
+			info.JSONPathCol,
 		)
 		r = r + col + "|"
 	}
@@ -296,8 +319,8 @@ func ToExtraMaskSchemaMap(r string) map[string]serializer.ExtraMaskInfo {
 
 		info := strings.Split(col, ",")
 
-		if len(info) != 4 {
-			klog.Fatalf("expecting extra mask schema to be length 3, got:%+v, schema:%v", len(info), r)
+		if len(info) < 4 {
+			klog.Fatalf("expecting extra mask schema to be length 4, got:%+v, schema:%v", len(info), r)
 		}
 
 		var masked bool
@@ -305,10 +328,11 @@ func ToExtraMaskSchemaMap(r string) map[string]serializer.ExtraMaskInfo {
 			masked = true
 		}
 
+		// column type can have commas, eg: numeric(38,2)
 		m[info[0]] = serializer.ExtraMaskInfo{
 			Masked:     masked,
-			ColumnType: info[2],
-			DefaultVal: info[3],
+			ColumnType: strings.Join(info[2:len(info)-1], ","),
+			DefaultVal: info[len(info)-1],
 		}
 	}
 
@@ -11,8 +11,22 @@ func TestToStringMap(t *testing.T) {
 	maskSchema := map[string]serializer.MaskInfo{
 		"kafkaoffset": serializer.MaskInfo{},
 		"id":          serializer.MaskInfo{Masked: true},
+		"email": serializer.MaskInfo{
+			Masked:       true,
+			MaskFunction: "hmac_sha256",
+		},
+		"notes":    serializer.MaskInfo{ExcludedCol: true},
+		"metadata": serializer.MaskInfo{JSONPathCol: true},
+	}
+	extraMaskSchema := map[string]serializer.ExtraMaskInfo{
+		"email_length": serializer.ExtraMaskInfo{
+			ColumnType: "integer",
+			DefaultVal: "0",
+		},
+		"dob_year":          serializer.ExtraMaskInfo{ColumnType: "date"},
+		"salary_round":      serializer.ExtraMaskInfo{ColumnType: "numeric(38,0)"},
+		"latitude_truncate": serializer.ExtraMaskInfo{ColumnType: "numeric(38,2)"},
 	}
-	extraMaskSchema := map[string]serializer.ExtraMaskInfo{}
 
 	job := NewJob(
 		"upstreamTopic",
@@ -11,6 +11,7 @@ import (
 	"github.com/Shopify/sarama"
 	"github.com/practo/klog/v2"
 	"github.com/practo/tipoca-stream/pkg/kafka"
+	"github.com/practo/tipoca-stream/pkg/notify"
 	"github.com/practo/tipoca-stream/pkg/redshift"
 	"github.com/practo/tipoca-stream/pkg/s3sink"
 	"github.com/practo/tipoca-stream/pkg/serializer"
@@ -53,10 +54,6 @@ type loadProcessor struct {
 	// this is helpful to log at the time of shutdown and can help in debugging
 	lastCommittedOffset int64
 
-	// messageTransformer is used to transform debezium events into
-	// redshift COPY commands with some annotations
-	messageTransformer transformer.MessageTransformer
-
 	// schemaTransfomer is used to transform debezium schema
 	// to redshift table
 	schemaTransformer transformer.SchemaTransformer
@@ -94,6 +91,10 @@ type loadProcessor struct {
 	// schemaTargetTable is the cache used to get the targetTable from
 	// schema ID without doing recomputation for the schema id
 	schemaTargetTable map[int]redshift.Table
+
+	// notifier notifies the views which could not be recreated after the
+	// table migration, nil when slack is not configured
+	notifier notify.Notifier
 }
 
 func newLoadProcessor(
@@ -103,6 +104,7 @@ func newLoadProcessor(
 	saramaConfig kafka.SaramaConfig,
 	redshifter *redshift.Redshift,
 	redshiftGroup *string,
+	timezone transformer.TimezoneConfig,
 	metric metricSetter,
 ) (serializer.MessageBatchSyncProcessor, error) {
 	sink, err := s3sink.NewS3Sink(
@@ -117,15 +119,25 @@ func newLoadProcessor(
 
 	klog.V(3).Infof("%s: auto-commit: %v", topic, saramaConfig.AutoCommit)
 
+	var notifier notify.Notifier
+	if viper.GetString("slackBotToken") != "" &&
+		viper.GetString("slackChannelID") != "" {
+		notifier = notify.New(
+			viper.GetString("slackBotToken"),
+			viper.GetString("slackChannelID"),
+		)
+	}
+
 	return &loadProcessor{
-		topic:              topic,
-		partition:          partition,
-		consumerGroupID:    consumerGroupID,
-		autoCommit:         saramaConfig.AutoCommit,
-		s3sink:             sink,
-		messageTransformer: debezium.NewMessageTransformer(),
+		topic:           topic,
+		partition:       partition,
+		consumerGroupID: consumerGroupID,
+		autoCommit:      saramaConfig.AutoCommit,
+		s3sink:          sink,
 		schemaTransformer: debezium.NewSchemaTransformer(
-			viper.GetString("schemaRegistryURL")),
+			viper.GetString("schemaRegistryURL"),
+			timezone,
+		),
 		redshifter:        redshifter,
 		redshiftSchema:    viper.GetString("redshift.schema"),
 		redshiftGroup:     redshiftGroup,
@@ -135,6 +147,7 @@ func newLoadProcessor(
 		redshiftStats:     viper.GetBool("redshift.stats"),
 		metric:            metric,
 		schemaTargetTable: make(map[int]redshift.Table),
+		notifier:          notifier,
 	}, nil
 }
 
@@ -561,7 +574,7 @@ func (b *loadProcessor) migrateTable(
 	unLoadS3Key := b.s3sink.GetKeyURI(s3CopyDir)
 	copyS3ManifestKey := b.s3sink.GetKeyURI(s3CopyDir + "manifest")
 
-	err = b.redshifter.ReplaceTable(
+	views, err := b.redshifter.ReplaceTable(
 		ctx,
 		tx, unLoadS3Key, copyS3ManifestKey,
 		inputTable, targetTable,
@@ -571,16 +584,34 @@ func (b *loadProcessor) migrateTable(
 		return fmt.Errorf("Error migrating table, err:%v\n", err)
 	}
 
+	err = b.commitMigration(ctx, tx, inputTable)
+	if err != nil {
+		return err
+	}
+
+	// the views are not recreated in the migration tx as a failed view
+	// would abort the migration, failures are reported and not retried.
+	// The views are missing till they are recreated.
+	failures := b.redshifter.RecreateViews(ctx, views)
+	b.notifyViewFailures(inputTable, failures)
+
+	return nil
+}
+
+// commitMigration grants the redshiftGroup access to the migrated table
+// and commits the migration, the migration is rolled back if the grant fails
+func (b *loadProcessor) commitMigration(
+	ctx context.Context, tx *sql.Tx, table redshift.Table) error {
 	if b.redshiftGroup != nil {
-		klog.V(2).Infof("%s, granting schema access for table: %v to group: %v", b.topic, inputTable.Name, *b.redshiftGroup)
-		err = b.redshifter.GrantSchemaAccess(ctx, tx, inputTable.Meta.Schema, inputTable.Name, *b.redshiftGroup)
+		klog.V(2).Infof("%s, granting schema access for table: %v to group: %v", b.topic, table.Name, *b.redshiftGroup)
+		err := b.redshifter.GrantSchemaAccess(ctx, tx, table.Meta.Schema, table.Name, *b.redshiftGroup)
 		if err != nil {
+			tx.Rollback()
 			return err
 		}
-		return err
 	}
 
-	err = tx.Commit()
+	err := tx.Commit()
 	if err != nil {
 		return fmt.Errorf("Error committing tx, err:%v\n", err)
 	}
@@ -588,6 +619,35 @@ func (b *loadProcessor) migrateTable(
 	return nil
 }
 
+// notifyViewFailures reports the views dropped by the migration of the
+// table which could not be recreated
+func (b *loadProcessor) notifyViewFailures(
+	table redshift.Table, failures []redshift.ViewFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	failed := []string{}
+	for _, failure := range failures {
+		klog.Errorf("%s, view dropped by the migration could not be recreated, %s",
+			b.topic, failure)
+		failed = append(failed, failure.String())
+	}
+	if b.notifier == nil {
+		return
+	}
+	err := b.notifier.Notify(fmt.Sprintf(
+		"Migrated table *%s.%s*, failed to recreate %d views dropped with it: %s",
+		table.Meta.Schema,
+		table.Name,
+		len(failures),
+		strings.Join(failed, "; "),
+	))
+	if err != nil {
+		klog.Errorf("%s, views notification failed, err: %v", b.topic, err)
+	}
+}
+
 // migrateSchema construct the "inputTable" using schemaId in the message.
 // If it has processed the schemaID before it returns, this is done for reducing queries to redshift.
 // If the schema and table does not exist it creates and returns.
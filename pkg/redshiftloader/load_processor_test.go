@@ -0,0 +1,200 @@
+package redshiftloader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/practo/tipoca-stream/pkg/redshift"
+)
+
+// fakeDB records the statements and the tx ends, the statements
+// containing failOn fail
+type fakeDB struct {
+	log    []string
+	failOn string
+}
+
+func (d *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: d}, nil
+}
+
+func (d *fakeDB) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return &fakeTx{db: c.db}, nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.log = append(s.db.log, s.query)
+	if s.db.failOn != "" && strings.Contains(s.query, s.db.failOn) {
+		return nil, fmt.Errorf("failed: %s", s.query)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (t *fakeTx) Commit() error {
+	t.db.log = append(t.db.log, "COMMIT")
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.db.log = append(t.db.log, "ROLLBACK")
+	return nil
+}
+
+type fakeNotifier struct {
+	messages []string
+}
+
+func (f *fakeNotifier) Notify(message string) error {
+	f.messages = append(f.messages, message)
+	return nil
+}
+
+func TestNotifyViewFailures(t *testing.T) {
+	t.Parallel()
+
+	table := redshift.Table{
+		Name: "orders",
+		Meta: redshift.Meta{Schema: "inventory"},
+	}
+	notifier := &fakeNotifier{}
+	b := &loadProcessor{topic: "db.inventory.orders", notifier: notifier}
+
+	b.notifyViewFailures(table, nil)
+	if len(notifier.messages) != 0 {
+		t.Errorf("expected no notification, got: %v", notifier.messages)
+	}
+
+	b.notifyViewFailures(table, []redshift.ViewFailure{
+		{
+			View: redshift.View{Schema: "inventory", Name: "orders_view"},
+			Err:  fmt.Errorf("column does not exist"),
+		},
+	})
+	if len(notifier.messages) != 1 {
+		t.Fatalf("expected 1 notification, got: %v", notifier.messages)
+	}
+	for _, expected := range []string{
+		"inventory.orders", "inventory.orders_view: column does not exist",
+	} {
+		if !strings.Contains(notifier.messages[0], expected) {
+			t.Errorf("expected %q in notification: %v",
+				expected, notifier.messages[0])
+		}
+	}
+
+	// failures are only logged without slack
+	b.notifier = nil
+	b.notifyViewFailures(table, []redshift.ViewFailure{
+		{View: redshift.View{Schema: "inventory", Name: "orders_view"}},
+	})
+}
+
+func TestCommitMigration(t *testing.T) {
+	t.Parallel()
+
+	group := "analysts"
+	tests := []struct {
+		name          string
+		redshiftGroup *string
+		failOn        string
+		expectedLog   []string
+		expectedErr   bool
+	}{
+		{
+			name:        "without group",
+			expectedLog: []string{"COMMIT"},
+		},
+		{
+			name:          "with group",
+			redshiftGroup: &group,
+			expectedLog: []string{
+				"GRANT SELECT ON TABLE inventory.orders TO GROUP analysts",
+				"GRANT USAGE ON SCHEMA inventory TO GROUP analysts",
+				"COMMIT",
+			},
+		},
+		{
+			name:          "grant fails",
+			redshiftGroup: &group,
+			failOn:        "GRANT SELECT",
+			expectedLog: []string{
+				"GRANT SELECT ON TABLE inventory.orders TO GROUP analysts",
+				"ROLLBACK",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			fake := &fakeDB{failOn: tc.failOn}
+			db := sql.OpenDB(fake)
+			defer db.Close()
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := &loadProcessor{
+				topic:         "db.inventory.orders",
+				redshifter:    &redshift.Redshift{},
+				redshiftGroup: tc.redshiftGroup,
+			}
+			err = b.commitMigration(ctx, tx, redshift.Table{
+				Name: "orders",
+				Meta: redshift.Meta{Schema: "inventory"},
+			})
+			if tc.expectedErr != (err != nil) {
+				t.Errorf("expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(tc.expectedLog, fake.log) {
+				t.Errorf("expected: %v, got: %v", tc.expectedLog, fake.log)
+			}
+		})
+	}
+}
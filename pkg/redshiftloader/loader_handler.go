@@ -41,6 +41,10 @@ type LoaderConfig struct {
 
 	// MaxWaitSeconds after which the bash would be pushed regardless of its size.
 	MaxWaitSeconds *int `yaml:"maxWaitSeconds,omitempty"`
+
+	// Timezone specifies if the timestamp columns should be TIMESTAMPTZ,
+	// it must be the same as the batcher's timezone configuration.
+	Timezone transformer.TimezoneConfig `yaml:"timezone,omitempty"`
 }
 
 // loaderHandler is the sarama consumer handler
@@ -58,6 +62,7 @@ type loaderHandler struct {
 
 	saramaConfig kafka.SaramaConfig
 	serializer   serializer.Serializer
+	timezone     transformer.TimezoneConfig
 
 	redshifter      *redshift.Redshift
 	redshiftSchema  string
@@ -106,6 +111,7 @@ func NewHandler(
 
 		saramaConfig: saramaConfig,
 		serializer:   serializer.NewSerializer(viper.GetString("schemaRegistryURL")),
+		timezone:     loaderConfig.Timezone,
 
 		redshifter:      redshifter,
 		redshiftSchema:  redshiftSchema,
@@ -295,6 +301,7 @@ func (h *loaderHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 		h.saramaConfig,
 		h.redshifter,
 		h.redshiftGroup,
+		h.timezone,
 		metric,
 	)
 
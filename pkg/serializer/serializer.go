@@ -25,6 +25,11 @@ type MaskInfo struct {
 	ConditionalNonPIICol   bool
 	DependentNonPIICol     bool
 	RegexPatternBooleanCol bool
+	ExcludedCol            bool
+	// JSONPathCol is a JSON column with only some of its values masked
+	JSONPathCol bool
+	// MaskFunction is the function used to mask the column
+	MaskFunction string
 }
 
 type ExtraMaskInfo struct {
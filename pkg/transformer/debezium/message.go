@@ -1,11 +1,14 @@
 package debezium
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"github.com/practo/klog/v2"
 	"github.com/practo/tipoca-stream/pkg/redshift"
 	"github.com/practo/tipoca-stream/pkg/serializer"
 	"github.com/practo/tipoca-stream/pkg/transformer"
+	"math/big"
 	"strconv"
 	"strings"
 	"time"
@@ -18,12 +21,98 @@ const (
 	nsMilliInSecond = 1000000
 )
 
-func NewMessageTransformer() transformer.MessageTransformer {
-	return &messageTransformer{}
+// Debezium temporal logical types, the connect.name of the schema field
+// https://debezium.io/documentation/reference/1.2/connectors/mysql.html#mysql-temporal-types
+const (
+	debeziumDate           = "io.debezium.time.Date"
+	debeziumYear           = "io.debezium.time.Year"
+	debeziumTime           = "io.debezium.time.Time"
+	debeziumMicroTime      = "io.debezium.time.MicroTime"
+	debeziumNanoTime       = "io.debezium.time.NanoTime"
+	debeziumTimestamp      = "io.debezium.time.Timestamp"
+	debeziumMicroTimestamp = "io.debezium.time.MicroTimestamp"
+	debeziumNanoTimestamp  = "io.debezium.time.NanoTimestamp"
+	debeziumZonedTimestamp = "io.debezium.time.ZonedTimestamp"
+	connectDate            = "org.apache.kafka.connect.data.Date"
+	connectTime            = "org.apache.kafka.connect.data.Time"
+	connectTimestamp       = "org.apache.kafka.connect.data.Timestamp"
+)
+
+// Decimal logical types used with decimal.handling.mode=precise
+const (
+	connectDecimal               = "org.apache.kafka.connect.data.Decimal"
+	debeziumVariableScaleDecimal = "io.debezium.data.VariableScaleDecimal"
+)
+
+const (
+	// ewkbSRIDFlag is set in the geometry type of EWKB when SRID is present
+	ewkbSRIDFlag = 0x20000000
+)
+
+func NewMessageTransformer(
+	topic string,
+	timezone transformer.TimezoneConfig,
+) (
+	transformer.MessageTransformer,
+	error,
+) {
+	_, database, table := transformer.ParseTopic(topic)
+	location, err := timezone.Location(database, table)
+	if err != nil {
+		return nil, err
+	}
+	err = timezone.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &messageTransformer{
+		database:              database,
+		table:                 table,
+		timezone:              timezone,
+		location:              location,
+		timestampWithTimezone: timezone.TimestampWithTimezone,
+	}, nil
 }
 
 type messageParser struct {
 	message interface{}
+
+	// decimalScales is the scale of the decimal columns, it is required
+	// to decode the decimal bytes
+	decimalScales map[string]int
+}
+
+// value converts the column value into string
+func (d *messageParser) value(column string, v interface{}) string {
+	switch v := v.(type) {
+	case *big.Rat:
+		// decimal with avro logical type
+		scale, ok := d.decimalScales[column]
+		if !ok {
+			scale = ratScale(v)
+		}
+		return v.FloatString(scale)
+	case []byte:
+		scale, ok := d.decimalScales[column]
+		if ok {
+			return decimal(v, scale)
+		}
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// structValue converts the debezium struct into string
+func (d *messageParser) structValue(v map[string]interface{}) (string, bool) {
+	// debezium geometry struct: {wkb, srid}
+	columnValue, ok := geometry(v)
+	if ok {
+		return columnValue, true
+	}
+
+	// debezium variable scale decimal struct: {scale, value}
+	return variableScaleDecimal(v)
 }
 
 // extract extracts out the columns name and value from the debezium message
@@ -39,22 +128,138 @@ func (d *messageParser) extract(key string, message map[string]interface{},
 	// why handled liket this ?: https://github.com/linkedin/goavro/issues/217
 	for _, v := range data {
 		for k2, v2 := range v.(map[string]interface{}) {
+			column := strings.ToLower(k2)
 			switch v2.(type) {
 			case map[string]interface{}:
+				columnValue, ok := d.structValue(v2.(map[string]interface{}))
+				if ok {
+					result[column] = &columnValue
+					continue
+				}
 				for _, v3 := range v2.(map[string]interface{}) {
-					columnValue := fmt.Sprintf("%v", v3)
-					result[strings.ToLower(k2)] = &columnValue
+					// nullable debezium struct
+					v4, ok := v3.(map[string]interface{})
+					if ok {
+						columnValue, ok := d.structValue(v4)
+						if ok {
+							result[column] = &columnValue
+							continue
+						}
+					}
+					columnValue := d.value(column, v3)
+					result[column] = &columnValue
 				}
 			case nil:
-				result[strings.ToLower(k2)] = nil
+				result[column] = nil
 			default:
-				columnValue := fmt.Sprintf("%v", v2)
-				result[strings.ToLower(k2)] = &columnValue
+				columnValue := d.value(column, v2)
+				result[column] = &columnValue
 			}
 		}
 	}
 }
 
+// decimal decodes the unscaled two's complement big endian value
+// into the exact decimal string having scale digits of fraction.
+func decimal(unscaled []byte, scale int) string {
+	n := new(big.Int).SetBytes(unscaled)
+	if len(unscaled) > 0 && unscaled[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(unscaled)*8)))
+	}
+	if scale <= 0 {
+		return n.Mul(
+			n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-scale)), nil),
+		).String()
+	}
+
+	return new(big.Rat).SetFrac(
+		n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil),
+	).FloatString(scale)
+}
+
+// ratScale returns the digits of fraction required to represent
+// the decimal exactly
+func ratScale(r *big.Rat) int {
+	denominator := new(big.Int).Set(r.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	zero, mod := big.NewInt(0), new(big.Int)
+	twos, fives := 0, 0
+	for mod.Mod(denominator, two).Cmp(zero) == 0 {
+		denominator.Quo(denominator, two)
+		twos++
+	}
+	for mod.Mod(denominator, five).Cmp(zero) == 0 {
+		denominator.Quo(denominator, five)
+		fives++
+	}
+	if twos > fives {
+		return twos
+	}
+
+	return fives
+}
+
+// variableScaleDecimal decodes the debezium VariableScaleDecimal struct
+func variableScaleDecimal(value map[string]interface{}) (string, bool) {
+	scale, ok := value["scale"].(int32)
+	if !ok {
+		return "", false
+	}
+	unscaled, ok := value["value"].([]byte)
+	if !ok {
+		return "", false
+	}
+
+	return decimal(unscaled, int(scale)), true
+}
+
+// geometry converts the debezium geometry struct into hex EWKB which
+// can be loaded into the Redshift GEOMETRY column.
+// https://debezium.io/documentation/reference/1.2/connectors/mysql.html#_spatial_data_types
+func geometry(value map[string]interface{}) (string, bool) {
+	wkb, ok := value["wkb"].([]byte)
+	if !ok {
+		return "", false
+	}
+
+	var srid int64
+	switch s := value["srid"].(type) {
+	case int32:
+		srid = int64(s)
+	case map[string]interface{}:
+		// nullable srid
+		if v, ok := s["int"].(int32); ok {
+			srid = int64(v)
+		}
+	}
+
+	return strings.ToUpper(hex.EncodeToString(toEWKB(wkb, srid))), true
+}
+
+// toEWKB adds the SRID to the WKB, WKB is returned as is when
+// SRID is not present or the WKB is invalid.
+func toEWKB(wkb []byte, srid int64) []byte {
+	if srid == 0 || len(wkb) < 5 {
+		return wkb
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if wkb[0] == 0 {
+		order = binary.BigEndian
+	}
+	geometryType := order.Uint32(wkb[1:5])
+	if geometryType&ewkbSRIDFlag != 0 {
+		return wkb
+	}
+
+	ewkb := make([]byte, 9, len(wkb)+4)
+	ewkb[0] = wkb[0]
+	order.PutUint32(ewkb[1:5], geometryType|ewkbSRIDFlag)
+	order.PutUint32(ewkb[5:9], uint32(srid))
+
+	return append(ewkb, wkb[5:]...)
+}
+
 // after extracts out the "after" columns in the debezium message
 func (d *messageParser) after() map[string]*string {
 	result := make(map[string]*string)
@@ -85,7 +290,18 @@ func (d *messageParser) before() map[string]*string {
 	return result
 }
 
-type messageTransformer struct{}
+type messageTransformer struct {
+	database string
+	table    string
+	// timezone is used to find the time type of the TIME columns
+	timezone transformer.TimezoneConfig
+
+	// location is the timezone in which the source writes the temporal
+	// values that do not carry a timezone (MySQL DATETIME)
+	location *time.Location
+	// timestampWithTimezone is set when timestamp columns are TIMESTAMPTZ
+	timestampWithTimezone bool
+}
 
 func (c *messageTransformer) getOperation(message *serializer.Message,
 	beforeLen int, afterLen int) (string, error) {
@@ -138,139 +354,335 @@ func convertDebeziumDate(days int) string {
 	)
 }
 
-func convertDebeziumTimeStamp(timestamp string) string {
-	// strings replace would be faster than formatting time
-	ts := strings.ReplaceAll(timestamp, "T", " ")
-	return strings.ReplaceAll(ts, "Z", "")
+func convertDebeziumYear(year int) string {
+	return fmt.Sprintf("%04d-01-01", year)
 }
 
-func convertDebeziumMilliseconds(ms int, length int) string {
-	ts := FromUnixMilli(int64(ms))
-	ts = ts.UTC()
-
+// formatTimestamp formats the time keeping length digits of fraction
+// fraction: 1988-08-21 14:01:02.23
+func formatTimestamp(ts time.Time, length int) string {
 	result := fmt.Sprintf(
 		"%d-%02d-%02d %02d:%02d:%02d",
 		ts.Year(), ts.Month(), ts.Day(),
 		ts.Hour(), ts.Minute(), ts.Second(),
 	)
 
-	if length == 0 {
+	if length <= 0 {
 		return result
 	}
+	if length > 9 {
+		klog.Warningf("fraction length: %v is more than 9\n", length)
+		length = 9
+	}
 
-	// fraction: 1988-08-21 14:01:02.23
-	ns := strconv.Itoa(ts.Nanosecond())
-	if len(ns) == 0 {
-		return result
+	ns := fmt.Sprintf("%09d", ts.Nanosecond())
+
+	return fmt.Sprintf("%s.%s", result, ns[:length])
+}
+
+// inLocation reinterprets the wall clock of the UTC time ts in the location
+// and returns the same instant in UTC. Debezium sends DATETIME values
+// as if they were in UTC.
+func inLocation(ts time.Time, location *time.Location) time.Time {
+	if location == nil || location == time.UTC {
+		return ts
 	}
-	if len(ns) < length {
-		klog.Warningf(
-			"nano second got: %v is less than length: %v\n", ns, length)
-		length = len(ns)
+
+	return time.Date(
+		ts.Year(), ts.Month(), ts.Day(),
+		ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(),
+		location,
+	).UTC()
+}
+
+func convertDebeziumTimeStamp(timestamp string) (time.Time, error) {
+	ts, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return ts, fmt.Errorf(
+			"Error parsing zoned timestamp: %s, err: %v\n", timestamp, err)
 	}
 
-	return fmt.Sprintf("%s.%s", result, ns[:length])
+	return ts.UTC(), nil
 }
 
-func convertDebeziumMicroseconds(us int, length int) string {
-	ts := FromUnixMicro(int64(us))
-	ts = ts.UTC()
+// isTimeColumn tells if the column is a MySQL TIME column
+func isTimeColumn(logicalType string, sourceType string) bool {
+	switch logicalType {
+	case debeziumTime, debeziumMicroTime, debeziumNanoTime, connectTime:
+		return true
+	}
 
+	return strings.ToUpper(sourceType) == "TIME"
+}
+
+// timeMicroseconds converts the time logical type value into microseconds
+// MySQL TIME can be negative and more than 24 hours, it is a duration.
+func timeMicroseconds(value string, logicalType string) (int64, error) {
+	t, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"Error converting time col val to int, err: %v\n", err)
+	}
+
+	switch logicalType {
+	case debeziumTime, connectTime:
+		return t * millisInSecond, nil
+	case debeziumNanoTime:
+		return t / nsMicroInSecond, nil
+	default:
+		return t, nil
+	}
+}
+
+// formatTime formats the microseconds as [-]HH:MM:SS[.ffffff] keeping
+// length digits of fraction. Hours can be more than 24.
+func formatTime(us int64, length int) string {
+	sign := ""
+	if us < 0 {
+		sign = "-"
+		us = -us
+	}
+	seconds := us / microInSecond
 	result := fmt.Sprintf(
-		"%d-%02d-%02d %02d:%02d:%02d",
-		ts.Year(), ts.Month(), ts.Day(),
-		ts.Hour(), ts.Minute(), ts.Second(),
+		"%s%02d:%02d:%02d",
+		sign, seconds/3600, (seconds/60)%60, seconds%60,
 	)
 
-	if length == 0 {
+	if length <= 0 {
 		return result
 	}
+	if length > 6 {
+		length = 6
+	}
 
-	// fraction: 1988-08-21 14:01:02.23
-	ns := strconv.Itoa(ts.Nanosecond())
-	if len(ns) == 0 {
-		return result
+	fraction := fmt.Sprintf("%06d", us%microInSecond)
+
+	return fmt.Sprintf("%s.%s", result, fraction[:length])
+}
+
+// formatSeconds formats the microseconds as signed seconds
+func formatSeconds(us int64) string {
+	sign := ""
+	if us < 0 {
+		sign = "-"
+		us = -us
+	}
+
+	return fmt.Sprintf(
+		"%s%d.%06d", sign, us/microInSecond, us%microInSecond)
+}
+
+// sourceLength returns the length of the fraction in the source column
+func sourceLength(length string) (int, error) {
+	if length == "" {
+		return 0, nil
 	}
-	if len(ns) < length {
-		klog.Warningf(
-			"nano second got: %v is less than length: %v\n", ns, length)
-		length = len(ns)
+
+	l, err := strconv.Atoi(length)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"Error converting col length to int, err: %v\n", err)
 	}
 
-	return fmt.Sprintf("%s.%s", result, ns[:length])
+	return l, nil
 }
 
-func convertDebeziumMicrosecondsIntoTime(us int) string {
-	ts := FromUnixMicro(int64(us))
-	ts = ts.UTC()
+// timestamp converts the epoch value of the logical type into UTC time,
+// the values which do not carry timezone are interpreted in the source
+// timezone
+func (c *messageTransformer) timestamp(
+	value string, logicalType string) (time.Time, int, error) {
+
+	ts, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf(
+			"Error converting timestamp col val to int, err: %v\n", err)
+	}
+
+	switch logicalType {
+	case debeziumTimestamp:
+		return inLocation(FromUnixMilli(ts).UTC(), c.location), 3, nil
+	case debeziumMicroTimestamp:
+		return inLocation(FromUnixMicro(ts).UTC(), c.location), 6, nil
+	case debeziumNanoTimestamp:
+		return inLocation(time.Unix(0, ts).UTC(), c.location), 9, nil
+	case connectTimestamp:
+		// kafka connect timestamps are always in UTC
+		return FromUnixMilli(ts).UTC(), 3, nil
+	}
 
-	return fmt.Sprintf("%02d:%02d:%02d", ts.Hour(), ts.Minute(), ts.Second())
+	return time.Time{}, 0, fmt.Errorf(
+		"Unhandled timestamp logical type: %v\n", logicalType)
+}
+
+// withTimezone adds the UTC offset to the formatted timestamp
+// if the timestamp columns are TIMESTAMPTZ
+func (c *messageTransformer) withTimezone(timestamp string) string {
+	if c.timestampWithTimezone {
+		return timestamp + "+00"
+	}
+
+	return timestamp
+}
+
+// convertDebeziumTime formats the debezium time value based on the
+// time type of the column.
+func (c *messageTransformer) convertDebeziumTime(
+	value string,
+	logicalType string,
+	length int,
+	timeType string,
+) (
+	string,
+	error,
+) {
+	us, err := timeMicroseconds(value, logicalType)
+	if err != nil {
+		return "", err
+	}
+
+	switch timeType {
+	case transformer.TimeTypeTime:
+		if us < 0 || us > 24*3600*microInSecond {
+			return "", fmt.Errorf(
+				"time: %s is out of Redshift TIME range, "+
+					"use timeType varchar or seconds for the column\n",
+				formatTime(us, length),
+			)
+		}
+		return c.withTimezone(formatTime(us, length)), nil
+	case transformer.TimeTypeSeconds:
+		return formatSeconds(us), nil
+	default:
+		return formatTime(us, length), nil
+	}
+}
+
+// timeType returns the time type of the column if the column is a TIME
+// column which needs the conversion. Masked columns and the columns using
+// the legacy type are not converted.
+func (c *messageTransformer) timeType(column redshift.ColInfo) string {
+	if !isTimeColumn(column.LogicalType, column.SourceType.ColumnType) {
+		return ""
+	}
+
+	switch column.Type {
+	case redshift.RedshiftTime,
+		redshift.RedshiftTimeWithoutTz,
+		redshift.RedshiftTimeTz,
+		redshift.RedshiftTimeSeconds:
+		return c.timezone.ColumnTimeType(c.database, c.table, column.Name)
+	}
+
+	return ""
 }
 
 // convertDebeziumFormattedTime formats the debezium time into redshift time
-// maitaining the precsion
+// maitaining the precsion. The conversion is done based on the debezium
+// logical type, if it is not known source type is used.
 // https://debezium.io/documentation/reference/1.2/connectors/mysql.html#_temporal_values
-func convertDebeziumFormattedTime(
-	value string, sourceType string, sourceLength string) (string, error) {
+func (c *messageTransformer) convertDebeziumFormattedTime(
+	value string,
+	logicalType string,
+	sourceType string,
+	sourceColLength string,
+) (
+	string,
+	error,
+) {
+	length, err := sourceLength(sourceColLength)
+	if err != nil {
+		return "", err
+	}
 
-	switch sourceType {
-	case "DATE":
-		ts, err := strconv.Atoi(value)
+	switch logicalType {
+	case debeziumDate, connectDate:
+		days, err := strconv.Atoi(value)
 		if err != nil {
 			return "", fmt.Errorf(
 				"Error converting date col val to int, err: %v\n", err)
 		}
-		return convertDebeziumDate(ts), nil
-	case "TIMESTAMP":
-		return convertDebeziumTimeStamp(value), nil
-	case "DATETIME":
-		var colLength int
-		if sourceLength == "" {
-			colLength = 0
-		} else {
-			c, err := strconv.Atoi(sourceLength)
-			if err != nil {
-				return "", fmt.Errorf(
-					"Error converting col length to int, err: %v\n", err)
-			}
-			colLength = c
-		}
-
-		ts, err := strconv.Atoi(value)
+		return convertDebeziumDate(days), nil
+	case debeziumYear:
+		year, err := strconv.Atoi(value)
 		if err != nil {
 			return "", fmt.Errorf(
-				"Error converting datetime col val to int, err: %v\n",
-				err)
+				"Error converting year col val to int, err: %v\n", err)
 		}
-
-		var redshiftTime string
-		if colLength <= 3 {
-			redshiftTime = convertDebeziumMilliseconds(ts, colLength)
-		} else {
-			redshiftTime = convertDebeziumMicroseconds(ts, colLength)
+		return convertDebeziumYear(year), nil
+	case debeziumTimestamp,
+		debeziumMicroTimestamp,
+		debeziumNanoTimestamp,
+		connectTimestamp:
+		ts, maxLength, err := c.timestamp(value, logicalType)
+		if err != nil {
+			return "", err
 		}
-
-		return redshiftTime, nil
-	case "TIME":
-		ts, err := strconv.Atoi(value)
+		if length > maxLength {
+			length = maxLength
+		}
+		return c.withTimezone(formatTimestamp(ts, length)), nil
+	case debeziumZonedTimestamp:
+		ts, err := convertDebeziumTimeStamp(value)
 		if err != nil {
-			return "", fmt.Errorf(
-				"Error converting time col val to int, err: %v\n",
-				err)
+			return "", err
+		}
+		// keeps the precision of the source value
+		return c.withTimezone(ts.Format("2006-01-02 15:04:05.999999999")), nil
+	case debeziumTime, debeziumMicroTime, debeziumNanoTime, connectTime:
+		return c.convertDebeziumTime(
+			value, logicalType, length, transformer.TimeTypeVarchar)
+	}
+
+	// logical type is not known, use the source type
+	switch sourceType {
+	case "DATE":
+		return c.convertDebeziumFormattedTime(
+			value, debeziumDate, sourceType, sourceColLength)
+	case "YEAR":
+		return c.convertDebeziumFormattedTime(
+			value, debeziumYear, sourceType, sourceColLength)
+	case "TIMESTAMP":
+		return c.convertDebeziumFormattedTime(
+			value, debeziumZonedTimestamp, sourceType, sourceColLength)
+	case "DATETIME":
+		if length <= 3 {
+			return c.convertDebeziumFormattedTime(
+				value, debeziumTimestamp, sourceType, sourceColLength)
 		}
-		return convertDebeziumMicrosecondsIntoTime(ts), nil
+		return c.convertDebeziumFormattedTime(
+			value, debeziumMicroTimestamp, sourceType, sourceColLength)
+	case "TIME":
+		return c.convertDebeziumFormattedTime(
+			value, debeziumMicroTime, sourceType, sourceColLength)
 	default:
 		return "", fmt.Errorf(
 			"Unhandled source type: %v, value: %v\n", sourceType, value)
 	}
 }
 
+// decimalScales returns the scale of the decimal columns
+func decimalScales(table redshift.Table) map[string]int {
+	scales := make(map[string]int)
+	for _, column := range table.Columns {
+		if column.LogicalType != connectDecimal {
+			continue
+		}
+		// scale is 0 when not known
+		scale, _ := strconv.Atoi(column.SourceType.ColumnScale)
+		scales[column.Name] = scale
+	}
+
+	return scales
+}
+
 // Transform debezium event into a s3 message annotating extra information
 func (c *messageTransformer) Transform(
 	message *serializer.Message, table redshift.Table) error {
 
 	d := &messageParser{
-		message: message.Value,
+		message:       message.Value,
+		decimalScales: decimalScales(table),
 	}
 
 	before := d.before()
@@ -295,12 +707,10 @@ func (c *messageTransformer) Transform(
 	}
 
 	for _, column := range table.Columns {
-		if column.Type == "record" && column.SourceType.ColumnType == "polygon" {
-			empty := ""
-			value[column.Name] = &empty
-			continue
-		}
-		if column.Type != redshift.RedshiftTimeStamp &&
+		timeType := c.timeType(column)
+		if timeType == "" &&
+			column.Type != redshift.RedshiftTimeStamp &&
+			column.Type != redshift.RedshiftTimeStampTz &&
 			column.Type != redshift.RedshiftDate {
 			continue
 		}
@@ -313,13 +723,31 @@ func (c *messageTransformer) Transform(
 			continue
 		}
 
-		formattedTime, err := convertDebeziumFormattedTime(
-			*mstr,
-			column.SourceType.ColumnType,
-			column.SourceType.ColumnLength,
-		)
-		if err != nil {
-			return err
+		var formattedTime string
+		if timeType != "" {
+			length, err := sourceLength(column.SourceType.ColumnLength)
+			if err != nil {
+				return err
+			}
+			logicalType := column.LogicalType
+			if logicalType == "" {
+				logicalType = debeziumMicroTime
+			}
+			formattedTime, err = c.convertDebeziumTime(
+				*mstr, logicalType, length, timeType)
+			if err != nil {
+				return fmt.Errorf("column: %s, %v", column.Name, err)
+			}
+		} else {
+			formattedTime, err = c.convertDebeziumFormattedTime(
+				*mstr,
+				column.LogicalType,
+				column.SourceType.ColumnType,
+				column.SourceType.ColumnLength,
+			)
+			if err != nil {
+				return err
+			}
 		}
 		value[column.Name] = &formattedTime
 	}
@@ -1,7 +1,11 @@
 package debezium
 
 import (
+	"encoding/hex"
+	"github.com/practo/tipoca-stream/pkg/transformer"
+	"math/big"
 	"testing"
+	"time"
 )
 
 func TestConvertDebeziumFormattedTime(t *testing.T) {
@@ -96,8 +100,10 @@ func TestConvertDebeziumFormattedTime(t *testing.T) {
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := convertDebeziumFormattedTime(
+			c := &messageTransformer{}
+			result, err := c.convertDebeziumFormattedTime(
 				tc.value,
+				"",
 				tc.sourceType,
 				tc.sourceLength,
 			)
@@ -114,3 +120,420 @@ func TestConvertDebeziumFormattedTime(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertDebeziumTemporalLogicalTypes(t *testing.T) {
+	t.Parallel()
+
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                  string
+		value                 string
+		logicalType           string
+		sourceType            string
+		sourceLength          string
+		location              *time.Location
+		timestampWithTimezone bool
+		formattedTime         string
+	}{
+		{
+			name:          "test1: Date",
+			value:         "6807",
+			logicalType:   debeziumDate,
+			sourceType:    "DATE",
+			formattedTime: "1988-08-21",
+		},
+		{
+			name:          "test2: Year",
+			value:         "1988",
+			logicalType:   debeziumYear,
+			sourceType:    "YEAR",
+			formattedTime: "1988-01-01",
+		},
+		{
+			name:          "test3: Timestamp in UTC",
+			value:         "588175262230",
+			logicalType:   debeziumTimestamp,
+			sourceType:    "DATETIME",
+			sourceLength:  "2",
+			formattedTime: "1988-08-21 14:01:02.23",
+		},
+		{
+			name:          "test4: Timestamp in IST",
+			value:         "588175262000",
+			logicalType:   debeziumTimestamp,
+			sourceType:    "DATETIME",
+			location:      ist,
+			formattedTime: "1988-08-21 08:31:02",
+		},
+		{
+			name:                  "test5: Timestamp in IST as TIMESTAMPTZ",
+			value:                 "588175262000",
+			logicalType:           debeziumTimestamp,
+			sourceType:            "DATETIME",
+			location:              ist,
+			timestampWithTimezone: true,
+			formattedTime:         "1988-08-21 08:31:02+00",
+		},
+		{
+			name:          "test6: MicroTimestamp",
+			value:         "588175262005000",
+			logicalType:   debeziumMicroTimestamp,
+			sourceType:    "DATETIME",
+			sourceLength:  "6",
+			formattedTime: "1988-08-21 14:01:02.005000",
+		},
+		{
+			name:          "test7: NanoTimestamp",
+			value:         "588175262123456789",
+			logicalType:   debeziumNanoTimestamp,
+			sourceType:    "DATETIME",
+			sourceLength:  "6",
+			formattedTime: "1988-08-21 14:01:02.123456",
+		},
+		{
+			name:          "test8: ZonedTimestamp is not converted to location",
+			value:         "1988-08-21T14:01:02.5Z",
+			logicalType:   debeziumZonedTimestamp,
+			sourceType:    "TIMESTAMP",
+			location:      ist,
+			formattedTime: "1988-08-21 14:01:02.5",
+		},
+		{
+			name:                  "test9: ZonedTimestamp as TIMESTAMPTZ",
+			value:                 "1988-08-21T14:01:02Z",
+			logicalType:           debeziumZonedTimestamp,
+			sourceType:            "TIMESTAMP",
+			timestampWithTimezone: true,
+			formattedTime:         "1988-08-21 14:01:02+00",
+		},
+		{
+			name:          "test10: Time",
+			value:         "40810000",
+			logicalType:   debeziumTime,
+			sourceType:    "TIME",
+			formattedTime: "11:20:10",
+		},
+		{
+			name:          "test11: MicroTime",
+			value:         "40810000000",
+			logicalType:   debeziumMicroTime,
+			sourceType:    "TIME",
+			formattedTime: "11:20:10",
+		},
+		{
+			name:          "test12: YEAR without logical type",
+			value:         "2020",
+			sourceType:    "YEAR",
+			formattedTime: "2020-01-01",
+		},
+		{
+			name:          "test13: DATETIME(3) without logical type in IST",
+			value:         "1602736317708",
+			sourceType:    "DATETIME",
+			sourceLength:  "3",
+			location:      ist,
+			formattedTime: "2020-10-14 23:01:57.708",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := &messageTransformer{
+				location:              tc.location,
+				timestampWithTimezone: tc.timestampWithTimezone,
+			}
+			result, err := c.convertDebeziumFormattedTime(
+				tc.value,
+				tc.logicalType,
+				tc.sourceType,
+				tc.sourceLength,
+			)
+			if err != nil {
+				t.Errorf("Error converting, %v\n", err)
+			}
+			if result != tc.formattedTime {
+				t.Errorf(
+					"expected: %v, got: %v\n",
+					tc.formattedTime,
+					result,
+				)
+			}
+		})
+	}
+}
+
+func TestConvertDebeziumTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                  string
+		value                 string
+		logicalType           string
+		length                int
+		timeType              string
+		timestampWithTimezone bool
+		formattedTime         string
+		expectError           bool
+	}{
+		{
+			name:          "test1: varchar with fraction",
+			value:         "40810123456",
+			logicalType:   debeziumMicroTime,
+			length:        6,
+			timeType:      transformer.TimeTypeVarchar,
+			formattedTime: "11:20:10.123456",
+		},
+		{
+			name:          "test2: varchar over 24 hours",
+			value:         "3023999000000",
+			logicalType:   debeziumMicroTime,
+			timeType:      transformer.TimeTypeVarchar,
+			formattedTime: "839:59:59",
+		},
+		{
+			name:          "test3: varchar negative",
+			value:         "-3600500000",
+			logicalType:   debeziumMicroTime,
+			length:        1,
+			timeType:      transformer.TimeTypeVarchar,
+			formattedTime: "-01:00:00.5",
+		},
+		{
+			name:          "test4: time with fraction",
+			value:         "40810120",
+			logicalType:   debeziumTime,
+			length:        3,
+			timeType:      transformer.TimeTypeTime,
+			formattedTime: "11:20:10.120",
+		},
+		{
+			name:                  "test5: timetz",
+			value:                 "40810000000000",
+			logicalType:           debeziumNanoTime,
+			timeType:              transformer.TimeTypeTime,
+			timestampWithTimezone: true,
+			formattedTime:         "11:20:10+00",
+		},
+		{
+			name:        "test6: time out of range",
+			value:       "-3600000000",
+			logicalType: debeziumMicroTime,
+			timeType:    transformer.TimeTypeTime,
+			expectError: true,
+		},
+		{
+			name:          "test7: seconds",
+			value:         "-3023999000001",
+			logicalType:   debeziumMicroTime,
+			timeType:      transformer.TimeTypeSeconds,
+			formattedTime: "-3023999.000001",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := &messageTransformer{
+				timestampWithTimezone: tc.timestampWithTimezone,
+			}
+			result, err := c.convertDebeziumTime(
+				tc.value,
+				tc.logicalType,
+				tc.length,
+				tc.timeType,
+			)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error, got: %v\n", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Error converting, %v\n", err)
+			}
+			if result != tc.formattedTime {
+				t.Errorf(
+					"expected: %v, got: %v\n",
+					tc.formattedTime,
+					result,
+				)
+			}
+		})
+	}
+}
+
+func TestGeometry(t *testing.T) {
+	t.Parallel()
+
+	// POINT(1 2)
+	pointLE, _ := hex.DecodeString(
+		"0101000000000000000000F03F0000000000000040")
+	pointBE, _ := hex.DecodeString(
+		"00000000013FF00000000000004000000000000000")
+
+	tests := []struct {
+		name     string
+		value    map[string]interface{}
+		expected *string
+	}{
+		{
+			name: "test1: point without srid",
+			value: map[string]interface{}{
+				"io.debezium.data.geometry.Point": map[string]interface{}{
+					"x":    1.0,
+					"y":    2.0,
+					"wkb":  pointLE,
+					"srid": nil,
+				},
+			},
+			expected: stringPtr("0101000000000000000000F03F0000000000000040"),
+		},
+		{
+			name: "test2: nullable geometry with srid",
+			value: map[string]interface{}{
+				"io.debezium.data.geometry.Geometry": map[string]interface{}{
+					"wkb":  pointLE,
+					"srid": map[string]interface{}{"int": int32(4326)},
+				},
+			},
+			expected: stringPtr(
+				"0101000020E6100000000000000000F03F0000000000000040"),
+		},
+		{
+			name: "test3: big endian geometry with srid",
+			value: map[string]interface{}{
+				"wkb":  pointBE,
+				"srid": int32(4326),
+			},
+			expected: stringPtr(
+				"0020000001000010E63FF00000000000004000000000000000"),
+		},
+		{
+			name:     "test4: null geometry",
+			value:    nil,
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var location interface{}
+			if tc.value != nil {
+				location = tc.value
+			}
+			d := &messageParser{
+				message: map[string]interface{}{
+					"after": map[string]interface{}{
+						"Value": map[string]interface{}{
+							"location": location,
+						},
+					},
+				},
+			}
+			result := d.after()["location"]
+			if tc.expected == nil {
+				if result != nil {
+					t.Errorf("expected: nil, got: %v\n", *result)
+				}
+				return
+			}
+			if result == nil || *result != *tc.expected {
+				t.Errorf("expected: %v, got: %v\n", *tc.expected, result)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestDecimal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		column        string
+		value         interface{}
+		decimalScales map[string]int
+		expected      string
+	}{
+		{
+			name:          "test1: decimal bytes",
+			column:        "price",
+			value:         []byte{0x30, 0x39}, // 12345
+			decimalScales: map[string]int{"price": 2},
+			expected:      "123.45",
+		},
+		{
+			name:          "test2: negative decimal bytes",
+			column:        "price",
+			value:         []byte{0xcf, 0xc7}, // -12345
+			decimalScales: map[string]int{"price": 3},
+			expected:      "-12.345",
+		},
+		{
+			name:          "test3: decimal bytes with zero scale",
+			column:        "price",
+			value:         []byte{0x00, 0xff}, // 255
+			decimalScales: map[string]int{"price": 0},
+			expected:      "255",
+		},
+		{
+			name:          "test4: decimal avro logical type",
+			column:        "price",
+			value:         big.NewRat(25, 100),
+			decimalScales: map[string]int{"price": 4},
+			expected:      "0.2500",
+		},
+		{
+			name:     "test5: decimal avro logical type without scale",
+			column:   "price",
+			value:    big.NewRat(-12345, 1000),
+			expected: "-12.345",
+		},
+		{
+			name:   "test6: variable scale decimal",
+			column: "price",
+			value: map[string]interface{}{
+				"io.debezium.data.VariableScaleDecimal": map[string]interface{}{
+					"scale": int32(5),
+					"value": []byte{0x01, 0xe2, 0x40}, // 123456
+				},
+			},
+			expected: "1.23456",
+		},
+		{
+			name:     "test7: bytes which are not decimal",
+			column:   "data",
+			value:    []byte{0x01},
+			expected: "[1]",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d := &messageParser{
+				message: map[string]interface{}{
+					"after": map[string]interface{}{
+						"Value": map[string]interface{}{
+							tc.column: tc.value,
+						},
+					},
+				},
+				decimalScales: tc.decimalScales,
+			}
+			result := d.after()[tc.column]
+			if result == nil || *result != tc.expected {
+				t.Errorf("expected: %v, got: %v\n", tc.expected, result)
+			}
+		})
+	}
+}
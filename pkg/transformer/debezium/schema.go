@@ -28,12 +28,13 @@ type SchemaField struct {
 }
 
 type ColInfo struct {
-	Name       string     `yaml:"name"`
-	Type       string     `yaml:"type"`
-	SourceType SourceType `yaml:"sourceType"`
-	Default    string     `yaml:"default"`
-	NotNull    bool       `yaml:"notnull"`
-	PrimaryKey bool       `yaml:"primarykey"`
+	Name        string     `yaml:"name"`
+	Type        string     `yaml:"type"`
+	LogicalType string     `yaml:"logicalType"`
+	SourceType  SourceType `yaml:"sourceType"`
+	Default     string     `yaml:"default"`
+	NotNull     bool       `yaml:"notnull"`
+	PrimaryKey  bool       `yaml:"primarykey"`
 }
 
 type SourceType struct {
@@ -42,10 +43,14 @@ type SourceType struct {
 	ColumnScale  string `yaml:"columnScale"`
 }
 
-func NewSchemaTransformer(url string) transformer.SchemaTransformer {
+func NewSchemaTransformer(
+	url string,
+	timezone transformer.TimezoneConfig,
+) transformer.SchemaTransformer {
 	return &schemaTransformer{
 		maskConfig: make(map[int]masker.MaskConfig),
 		registry:   schemaregistry.NewRegistry(url),
+		timezone:   timezone,
 	}
 }
 
@@ -61,6 +66,14 @@ func (d *schemaParser) schemaName() string {
 	return strings.Join(namespace[0:len(namespace)-1], d.tableDelim)
 }
 
+func (d *schemaParser) databaseName() string {
+	namespace := strings.Split(d.schema.Namespace, d.tableDelim)
+	if len(namespace) < 2 {
+		return ""
+	}
+	return namespace[len(namespace)-2]
+}
+
 func (d *schemaParser) tableName() string {
 	namespace := strings.Split(d.schema.Namespace, d.tableDelim)
 	return namespace[len(namespace)-1]
@@ -77,6 +90,9 @@ func getSourceType(v interface{}) SourceType {
 	var columnType string
 	var columnLength string
 	var columnScale string
+	// decimal parameters of kafka connect, used when source is not known
+	var decimalPrecision string
+	var decimalScale string
 	fieldsFound := 0
 
 	for key, value := range valueMap {
@@ -99,6 +115,20 @@ func getSourceType(v interface{}) SourceType {
 			columnScale = fmt.Sprintf("%s", value)
 			fieldsFound = fieldsFound + 1
 		}
+
+		if key == "connect.decimal.precision" {
+			decimalPrecision = fmt.Sprintf("%s", value)
+		}
+
+		if key == "scale" {
+			decimalScale = fmt.Sprintf("%s", value)
+		}
+	}
+	if columnLength == "" {
+		columnLength = decimalPrecision
+	}
+	if columnScale == "" {
+		columnScale = decimalScale
 	}
 	if fieldsFound == 0 {
 		klog.Warningf("Source info missing in %+v\n", v)
@@ -154,6 +184,9 @@ func column(v map[string]interface{}) ColInfo {
 								if k3 == "connect.parameters" {
 									column.SourceType = getSourceType(v3)
 								}
+								if k3 == "connect.name" {
+									column.LogicalType = v3.(string)
+								}
 							}
 						// handles ["null", "string"]
 						case string:
@@ -185,6 +218,9 @@ func column(v map[string]interface{}) ColInfo {
 					if k4 == "connect.parameters" {
 						column.SourceType = getSourceType(v4)
 					}
+					if k4 == "connect.name" {
+						column.LogicalType = v4.(string)
+					}
 				}
 			default:
 				klog.Fatalf("Unhandled type for v2=%v\n", v2)
@@ -238,6 +274,9 @@ type schemaTransformer struct {
 	mask       bool
 	maskConfig map[int]masker.MaskConfig
 	registry   schemaregistry.SchemaRegistry
+
+	// timezone decides the redshift types of the temporal columns
+	timezone transformer.TimezoneConfig
 }
 
 // TransformKey is deprecated as it makes expensive GetLatestSchemaWithRetry calls
@@ -339,6 +378,41 @@ func (c *schemaTransformer) TransformValue(
 	)
 }
 
+// sourceColumnType returns the source column type. The decimal logical types
+// are mapped even if the source column type is not propagated. Variable scale
+// decimals are kept as string as their scale is not known.
+func sourceColumnType(column ColInfo) string {
+	if column.SourceType.ColumnType != "" {
+		return column.SourceType.ColumnType
+	}
+
+	switch column.LogicalType {
+	case connectDecimal:
+		return "decimal"
+	case debeziumVariableScaleDecimal:
+		return "varchar"
+	}
+
+	return column.SourceType.ColumnType
+}
+
+// timeDataType returns the redshift type for the TIME column
+func (c *schemaTransformer) timeDataType(
+	database, table, column string) string {
+
+	switch c.timezone.ColumnTimeType(database, table, column) {
+	case transformer.TimeTypeTime:
+		if c.timezone.TimestampWithTimezone {
+			return redshift.RedshiftTimeTz
+		}
+		return redshift.RedshiftTimeWithoutTz
+	case transformer.TimeTypeSeconds:
+		return redshift.RedshiftTimeSeconds
+	default:
+		return redshift.RedshiftTime
+	}
+}
+
 func sortExtraColumns(extraColumns []redshift.ColInfo) {
 	sort.Slice(
 		extraColumns,
@@ -348,6 +422,23 @@ func sortExtraColumns(extraColumns []redshift.ColInfo) {
 	)
 }
 
+// maskedDataType returns the type which fits the output of the mask function
+func maskedDataType(maskFunction, sourceColLength, redshiftDataType string) string {
+	switch maskFunction {
+	case transformer.MaskHMACSHA256, transformer.MaskHMACSHA256Table:
+		return redshift.RedshiftHashedDataType
+	case transformer.MaskKeepEmailDomain:
+		// hashed local part and the @ are added to the domain
+		return redshift.GetRedshiftStringDataType(
+			sourceColLength, redshift.RedshiftHashedDataTypeLength+1)
+	case transformer.MaskKeepLast4, transformer.MaskKeepLength:
+		return redshift.GetRedshiftStringDataType(sourceColLength, 0)
+	default:
+		// sha1 and constant use the masked type, null the unmasked type
+		return redshiftDataType
+	}
+}
+
 func (c *schemaTransformer) transformSchemaValue(jobSchema string,
 	primaryKeys []string,
 	maskSchema map[string]serializer.MaskInfo,
@@ -390,13 +481,26 @@ func (c *schemaTransformer) transformSchemaValue(jobSchema string,
 		distKey := false
 		columnMasked := false
 		useStringMax := false
+		maskFunction := ""
 		if len(maskSchema) != 0 {
 			mschema, ok := maskSchema[strings.ToLower(column.Name)]
+			if ok && mschema.ExcludedCol {
+				if isPrimaryKey(strings.ToLower(column.Name), primaryKeys) {
+					return nil, fmt.Errorf(
+						"primary key column: %s can not be excluded",
+						column.Name,
+					)
+				}
+				continue
+			}
 			if ok {
 				sortKey = mschema.SortCol
 				distKey = mschema.DistCol
 				columnMasked = mschema.Masked
-				if mschema.ConditionalNonPIICol || mschema.DependentNonPIICol {
+				maskFunction = mschema.MaskFunction
+				// masked values in the json can be longer than the source
+				if mschema.ConditionalNonPIICol || mschema.DependentNonPIICol ||
+					mschema.JSONPathCol {
 					useStringMax = true
 				}
 				//deprecated below started --------------------------------------------------------------------
@@ -444,6 +548,20 @@ func (c *schemaTransformer) transformSchemaValue(jobSchema string,
 		// sort to keep the order consistent for the redshift table schema
 		sortExtraColumns(extraColumns)
 
+		// the null mask function loads NULL, the column can not be
+		// NOT NULL and the keys can not be NULL
+		notNull := column.NotNull
+		if columnMasked && maskFunction == transformer.MaskNull {
+			if sortKey || distKey ||
+				isPrimaryKey(strings.ToLower(column.Name), primaryKeys) {
+				return nil, fmt.Errorf(
+					"key column: %s can not be masked with function: %s",
+					column.Name, transformer.MaskNull,
+				)
+			}
+			notNull = false
+		}
+
 		var redshiftDataType string
 		if useStringMax {
 			redshiftDataType = redshift.RedshiftStringMax
@@ -451,14 +569,30 @@ func (c *schemaTransformer) transformSchemaValue(jobSchema string,
 			redshiftDataType, err = redshift.GetRedshiftDataType(
 				d.sqlType(),
 				column.Type,
-				column.SourceType.ColumnType,
+				sourceColumnType(column),
 				column.SourceType.ColumnLength,
 				column.SourceType.ColumnScale,
-				columnMasked,
+				columnMasked && maskFunction != transformer.MaskNull,
 			)
 			if err != nil {
 				return nil, err
 			}
+			if c.timezone.TimestampWithTimezone &&
+				redshiftDataType == redshift.RedshiftTimeStamp {
+				redshiftDataType = redshift.RedshiftTimeStampTz
+			}
+			if redshiftDataType == redshift.RedshiftTime &&
+				isTimeColumn(column.LogicalType, column.SourceType.ColumnType) {
+				redshiftDataType = c.timeDataType(
+					d.databaseName(), d.tableName(), column.Name)
+			}
+			if columnMasked {
+				redshiftDataType = maskedDataType(
+					maskFunction,
+					column.SourceType.ColumnLength,
+					redshiftDataType,
+				)
+			}
 		}
 
 		sortOrdinal := 0
@@ -470,8 +604,9 @@ func (c *schemaTransformer) transformSchemaValue(jobSchema string,
 			Name:         strings.ToLower(column.Name),
 			Type:         redshiftDataType,
 			DebeziumType: column.Type,
+			LogicalType:  column.LogicalType,
 			DefaultVal:   column.Default,
-			NotNull:      column.NotNull,
+			NotNull:      notNull,
 			PrimaryKey:   column.PrimaryKey,
 			SortOrdinal:  sortOrdinal,
 			DistKey:      distKey,
@@ -3,6 +3,7 @@ package debezium
 import (
 	"github.com/practo/tipoca-stream/pkg/redshift"
 	"github.com/practo/tipoca-stream/pkg/serializer"
+	"github.com/practo/tipoca-stream/pkg/transformer"
 	"reflect"
 	"testing"
 )
@@ -73,6 +74,7 @@ func TestSchemaMysqlDataType(t *testing.T) {
 		jobSchema       string
 		maskSchema      map[string]serializer.MaskInfo
 		extraMaskSchema map[string]serializer.ExtraMaskInfo
+		timezone        transformer.TimezoneConfig
 		cName           string
 		cType           string
 	}{
@@ -121,12 +123,78 @@ func TestSchemaMysqlDataType(t *testing.T) {
 			cName:           "gender",
 			cType:           "character varying(65535)",
 		},
+		{
+			name:            "test5: timestamp with timezone",
+			jobSchema:       `{"type":"record","name":"Envelope","namespace":"ts.inventory.shifts","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":{"type":"int","connect.parameters":{"__debezium.source.column.type":"INT","__debezium.source.column.length":"11"}}},{"name":"starts_at","type":["null",{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"TIME"},"connect.name":"io.debezium.time.MicroTime"}],"default":null},{"name":"created_at","type":{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"DATETIME"},"connect.name":"io.debezium.time.Timestamp"}}],"connect.name":"ts.inventory.shifts.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null}],"connect.name":"ts.inventory.shifts.Envelope"}`,
+			maskSchema:      map[string]serializer.MaskInfo{},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{},
+			timezone:        transformer.TimezoneConfig{TimestampWithTimezone: true},
+			cName:           "created_at",
+			cType:           "timestamp with time zone",
+		},
+		{
+			name:            "test6: time legacy",
+			jobSchema:       `{"type":"record","name":"Envelope","namespace":"ts.inventory.shifts","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":{"type":"int","connect.parameters":{"__debezium.source.column.type":"INT","__debezium.source.column.length":"11"}}},{"name":"starts_at","type":["null",{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"TIME"},"connect.name":"io.debezium.time.MicroTime"}],"default":null},{"name":"created_at","type":{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"DATETIME"},"connect.name":"io.debezium.time.Timestamp"}}],"connect.name":"ts.inventory.shifts.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null}],"connect.name":"ts.inventory.shifts.Envelope"}`,
+			maskSchema:      map[string]serializer.MaskInfo{},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{},
+			timezone:        transformer.TimezoneConfig{},
+			cName:           "starts_at",
+			cType:           "character varying(32)",
+		},
+		{
+			name:            "test7: time native",
+			jobSchema:       `{"type":"record","name":"Envelope","namespace":"ts.inventory.shifts","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":{"type":"int","connect.parameters":{"__debezium.source.column.type":"INT","__debezium.source.column.length":"11"}}},{"name":"starts_at","type":["null",{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"TIME"},"connect.name":"io.debezium.time.MicroTime"}],"default":null},{"name":"created_at","type":{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"DATETIME"},"connect.name":"io.debezium.time.Timestamp"}}],"connect.name":"ts.inventory.shifts.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null}],"connect.name":"ts.inventory.shifts.Envelope"}`,
+			maskSchema:      map[string]serializer.MaskInfo{},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{},
+			timezone:        transformer.TimezoneConfig{TimeType: "time"},
+			cName:           "starts_at",
+			cType:           "time without time zone",
+		},
+		{
+			name:            "test8: time native with timezone",
+			jobSchema:       `{"type":"record","name":"Envelope","namespace":"ts.inventory.shifts","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":{"type":"int","connect.parameters":{"__debezium.source.column.type":"INT","__debezium.source.column.length":"11"}}},{"name":"starts_at","type":["null",{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"TIME"},"connect.name":"io.debezium.time.MicroTime"}],"default":null},{"name":"created_at","type":{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"DATETIME"},"connect.name":"io.debezium.time.Timestamp"}}],"connect.name":"ts.inventory.shifts.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null}],"connect.name":"ts.inventory.shifts.Envelope"}`,
+			maskSchema:      map[string]serializer.MaskInfo{},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{},
+			timezone:        transformer.TimezoneConfig{TimeType: "time", TimestampWithTimezone: true},
+			cName:           "starts_at",
+			cType:           "time with time zone",
+		},
+		{
+			name:            "test9: time column override",
+			jobSchema:       `{"type":"record","name":"Envelope","namespace":"ts.inventory.shifts","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":{"type":"int","connect.parameters":{"__debezium.source.column.type":"INT","__debezium.source.column.length":"11"}}},{"name":"starts_at","type":["null",{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"TIME"},"connect.name":"io.debezium.time.MicroTime"}],"default":null},{"name":"created_at","type":{"type":"long","connect.version":1,"connect.parameters":{"__debezium.source.column.type":"DATETIME"},"connect.name":"io.debezium.time.Timestamp"}}],"connect.name":"ts.inventory.shifts.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null}],"connect.name":"ts.inventory.shifts.Envelope"}`,
+			maskSchema:      map[string]serializer.MaskInfo{},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{},
+			timezone: transformer.TimezoneConfig{
+				TimeType: "time",
+				TimeColumns: map[string]string{
+					"inventory.shifts.starts_at": "seconds",
+				},
+			},
+			cName: "starts_at",
+			cType: "numeric(13,6)",
+		},
+		{
+			name:            "test10: precise decimal without source type",
+			jobSchema:       `{"type":"record","name":"Envelope","namespace":"ts.inventory.orders","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":"int"},{"name":"amount","type":["null",{"type":"bytes","scale":4,"precision":12,"connect.version":1,"connect.parameters":{"scale":"4","connect.decimal.precision":"12"},"connect.name":"org.apache.kafka.connect.data.Decimal","logicalType":"decimal"}],"default":null},{"name":"ratio","type":["null",{"type":"record","name":"VariableScaleDecimal","namespace":"io.debezium.data","fields":[{"name":"scale","type":"int"},{"name":"value","type":"bytes"}],"connect.doc":"Variable scaled decimal","connect.version":1,"connect.name":"io.debezium.data.VariableScaleDecimal"}],"default":null}],"connect.name":"ts.inventory.orders.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null}],"connect.name":"ts.inventory.orders.Envelope"}`,
+			maskSchema:      map[string]serializer.MaskInfo{},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{},
+			cName:           "amount",
+			cType:           "numeric(12,4)",
+		},
+		{
+			name:            "test11: variable scale decimal",
+			jobSchema:       `{"type":"record","name":"Envelope","namespace":"ts.inventory.orders","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":"int"},{"name":"amount","type":["null",{"type":"bytes","scale":4,"precision":12,"connect.version":1,"connect.parameters":{"scale":"4","connect.decimal.precision":"12"},"connect.name":"org.apache.kafka.connect.data.Decimal","logicalType":"decimal"}],"default":null},{"name":"ratio","type":["null",{"type":"record","name":"VariableScaleDecimal","namespace":"io.debezium.data","fields":[{"name":"scale","type":"int"},{"name":"value","type":"bytes"}],"connect.doc":"Variable scaled decimal","connect.version":1,"connect.name":"io.debezium.data.VariableScaleDecimal"}],"default":null}],"connect.name":"ts.inventory.orders.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null}],"connect.name":"ts.inventory.orders.Envelope"}`,
+			maskSchema:      map[string]serializer.MaskInfo{},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{},
+			cName:           "ratio",
+			cType:           "character varying(256)",
+		},
 	}
 
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			c := &schemaTransformer{registry: nil}
+			c := &schemaTransformer{registry: nil, timezone: tc.timezone}
 
 			resp, err := c.transformSchemaValue(
 				tc.jobSchema, []string{"id"}, tc.maskSchema, tc.extraMaskSchema)
@@ -156,3 +224,188 @@ func TestSchemaMysqlDataType(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaExcludedColumns(t *testing.T) {
+	t.Parallel()
+
+	jobSchema := `{"type":"record","name":"Envelope","namespace":"inventory.inventory.customers","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":"int"},{"name":"first_name","type":"string"},{"name":"last_name","type":["null","string"],"default":null},{"name":"email","type":"string"}],"connect.name":"inventory.inventory.customers.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null},{"name":"source","type":{"type":"record","name":"Source","namespace":"io.debezium.connector.mysql","fields":[{"name":"version","type":"string"},{"name":"connector","type":"string"},{"name":"name","type":"string"},{"name":"ts_ms","type":"long"},{"name":"snapshot","type":[{"type":"string","connect.version":1,"connect.parameters":{"allowed":"true,last,false"},"connect.default":"false","connect.name":"io.debezium.data.Enum"},"null"],"default":"false"},{"name":"db","type":"string"},{"name":"table","type":["null","string"],"default":null},{"name":"server_id","type":"long"},{"name":"gtid","type":["null","string"],"default":null},{"name":"file","type":"string"},{"name":"pos","type":"long"},{"name":"row","type":"int"},{"name":"thread","type":["null","long"],"default":null},{"name":"query","type":["null","string"],"default":null}],"connect.name":"io.debezium.connector.mysql.Source"}},{"name":"op","type":"string"},{"name":"ts_ms","type":["null","long"],"default":null},{"name":"transaction","type":["null",{"type":"record","name":"ConnectDefault","namespace":"io.confluent.connect.avro","fields":[{"name":"id","type":"string"},{"name":"total_order","type":"long"},{"name":"data_collection_order","type":"long"}]}],"default":null}],"connect.name":"inventory.inventory.customers.Envelope"}`
+
+	tests := []struct {
+		name       string
+		maskSchema map[string]serializer.MaskInfo
+		columns    []string
+		err        bool
+	}{
+		{
+			name: "test1: excluded column is removed",
+			maskSchema: map[string]serializer.MaskInfo{
+				"email": serializer.MaskInfo{Masked: true, ExcludedCol: true},
+			},
+			columns: []string{"id", "first_name", "last_name"},
+			err:     false,
+		},
+		{
+			name: "test2: primary key can not be excluded",
+			maskSchema: map[string]serializer.MaskInfo{
+				"id": serializer.MaskInfo{ExcludedCol: true},
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := &schemaTransformer{registry: nil}
+
+			resp, err := c.transformSchemaValue(
+				jobSchema,
+				[]string{"id"},
+				tc.maskSchema,
+				map[string]serializer.ExtraMaskInfo{},
+			)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got nil\n")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var columns []string
+			for _, column := range resp.(redshift.Table).Columns {
+				columns = append(columns, column.Name)
+			}
+			if !reflect.DeepEqual(columns, tc.columns) {
+				t.Errorf("expected columns: %v, got: %v\n", tc.columns, columns)
+			}
+		})
+	}
+}
+
+func TestSchemaNullMaskFunction(t *testing.T) {
+	t.Parallel()
+
+	jobSchema := `{"type":"record","name":"Envelope","namespace":"inventory.inventory.customers","fields":[{"name":"before","type":["null",{"type":"record","name":"Value","fields":[{"name":"id","type":"int"},{"name":"first_name","type":"string"},{"name":"last_name","type":["null","string"],"default":null},{"name":"email","type":"string"}],"connect.name":"inventory.inventory.customers.Value"}],"default":null},{"name":"after","type":["null","Value"],"default":null},{"name":"source","type":{"type":"record","name":"Source","namespace":"io.debezium.connector.mysql","fields":[{"name":"version","type":"string"},{"name":"connector","type":"string"},{"name":"name","type":"string"},{"name":"ts_ms","type":"long"},{"name":"snapshot","type":[{"type":"string","connect.version":1,"connect.parameters":{"allowed":"true,last,false"},"connect.default":"false","connect.name":"io.debezium.data.Enum"},"null"],"default":"false"},{"name":"db","type":"string"},{"name":"table","type":["null","string"],"default":null},{"name":"server_id","type":"long"},{"name":"gtid","type":["null","string"],"default":null},{"name":"file","type":"string"},{"name":"pos","type":"long"},{"name":"row","type":"int"},{"name":"thread","type":["null","long"],"default":null},{"name":"query","type":["null","string"],"default":null}],"connect.name":"io.debezium.connector.mysql.Source"}},{"name":"op","type":"string"},{"name":"ts_ms","type":["null","long"],"default":null},{"name":"transaction","type":["null",{"type":"record","name":"ConnectDefault","namespace":"io.confluent.connect.avro","fields":[{"name":"id","type":"string"},{"name":"total_order","type":"long"},{"name":"data_collection_order","type":"long"}]}],"default":null}],"connect.name":"inventory.inventory.customers.Envelope"}`
+
+	tests := []struct {
+		name       string
+		maskSchema map[string]serializer.MaskInfo
+		err        bool
+	}{
+		{
+			name: "test1: null masked column is nullable",
+			maskSchema: map[string]serializer.MaskInfo{
+				"email": serializer.MaskInfo{
+					Masked: true, MaskFunction: transformer.MaskNull},
+			},
+		},
+		{
+			name: "test2: primary key can not be null masked",
+			maskSchema: map[string]serializer.MaskInfo{
+				"id": serializer.MaskInfo{
+					Masked: true, MaskFunction: transformer.MaskNull},
+			},
+			err: true,
+		},
+		{
+			name: "test3: sort key can not be null masked",
+			maskSchema: map[string]serializer.MaskInfo{
+				"email": serializer.MaskInfo{
+					Masked: true, SortCol: true, MaskFunction: transformer.MaskNull},
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := &schemaTransformer{registry: nil}
+
+			resp, err := c.transformSchemaValue(
+				jobSchema,
+				[]string{"id"},
+				tc.maskSchema,
+				map[string]serializer.ExtraMaskInfo{},
+			)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got nil\n")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, column := range resp.(redshift.Table).Columns {
+				if tc.maskSchema[column.Name].MaskFunction == transformer.MaskNull &&
+					column.NotNull {
+					t.Errorf("expected column: %s to be nullable\n", column.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskedDataType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		maskFunction     string
+		sourceColLength  string
+		redshiftDataType string
+		result           string
+	}{
+		{
+			name:             "test1: default keeps the masked type",
+			maskFunction:     "",
+			sourceColLength:  "255",
+			redshiftDataType: "character varying(1020)",
+			result:           "character varying(1020)",
+		},
+		{
+			name:             "test2: hmac",
+			maskFunction:     "hmac_sha256_table",
+			sourceColLength:  "255",
+			redshiftDataType: "character varying(1020)",
+			result:           "character varying(64)",
+		},
+		{
+			name:             "test3: keep email domain",
+			maskFunction:     "keep_email_domain",
+			sourceColLength:  "60",
+			redshiftDataType: "character varying(240)",
+			result:           "character varying(305)",
+		},
+		{
+			name:             "test4: keep last4 without source length",
+			maskFunction:     "keep_last4",
+			sourceColLength:  "",
+			redshiftDataType: "character varying(50)",
+			result:           "character varying(256)",
+		},
+		{
+			name:             "test5: null keeps the unmasked type",
+			maskFunction:     "null",
+			sourceColLength:  "",
+			redshiftDataType: "date",
+			result:           "date",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			result := maskedDataType(
+				tc.maskFunction, tc.sourceColLength, tc.redshiftDataType)
+			if result != tc.result {
+				t.Errorf("expected: %v, got: %v\n", tc.result, result)
+			}
+		})
+	}
+}
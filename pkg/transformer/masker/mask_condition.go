@@ -0,0 +1,609 @@
+package masker
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Conditions of the conditional and dependent non pii keys are SQL like
+// expressions on the columns of the row:
+//
+//	status = 'active' AND (age >= 18 OR verified IS NOT NULL)
+//	email ILIKE '%@practo.com' OR email IN ('a@b.com', 'c@d.com')
+//	notes ~ '^[0-9]+$' AND city NOT LIKE 'B_ng%'
+//
+// Predicates:
+//
+//	=, !=, <>, <, <=, >, >=  numeric comparison for number literals and
+//	                         string comparison for string literals
+//	[NOT] LIKE, [NOT] ILIKE  % matches any characters, _ one character,
+//	                         \ escapes them, ILIKE is case insensitive
+//	~, ~*, !~, !~*           regex match, * makes it case insensitive
+//	[NOT] IN (...)           value is one of the literals
+//	IS [NOT] NULL            value is null
+//
+// Predicates can be combined with AND, OR, NOT and parentheses. A predicate
+// on a null value is unknown, as in SQL, and the column is unmasked only when
+// the condition is true.
+
+// truth is the three valued logic of SQL
+type truth int
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+func toTruth(b bool) truth {
+	if b {
+		return truthTrue
+	}
+
+	return truthFalse
+}
+
+func (t truth) not() truth {
+	switch t {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	default:
+		return truthUnknown
+	}
+}
+
+// conditionRow is the row the condition is evaluated on, self is the value
+// of the column being unmasked.
+type conditionRow struct {
+	self    *string
+	columns map[string]*string
+}
+
+// condition is a compiled condition evaluated on the columns of a row
+type condition interface {
+	eval(row conditionRow) truth
+}
+
+type andCondition []condition
+
+func (c andCondition) eval(row conditionRow) truth {
+	result := truthTrue
+	for _, operand := range c {
+		switch operand.eval(row) {
+		case truthFalse:
+			return truthFalse
+		case truthUnknown:
+			result = truthUnknown
+		}
+	}
+
+	return result
+}
+
+type orCondition []condition
+
+func (c orCondition) eval(row conditionRow) truth {
+	result := truthFalse
+	for _, operand := range c {
+		switch operand.eval(row) {
+		case truthTrue:
+			return truthTrue
+		case truthUnknown:
+			result = truthUnknown
+		}
+	}
+
+	return result
+}
+
+type notCondition struct {
+	operand condition
+}
+
+func (c notCondition) eval(row conditionRow) truth {
+	return c.operand.eval(row).not()
+}
+
+// predicate is a condition on the value of a column
+type predicate struct {
+	column string
+	// self is set to use the value of the column being unmasked
+	self bool
+	// match is called only for the non null values
+	match func(value string) bool
+	// isNull is set for IS [NOT] NULL
+	isNull bool
+	negate bool
+}
+
+func (p predicate) eval(row conditionRow) truth {
+	value := row.columns[p.column]
+	if p.self {
+		value = row.self
+	}
+	if p.isNull {
+		return toTruth((value == nil) != p.negate)
+	}
+	if value == nil {
+		return truthUnknown
+	}
+
+	return toTruth(p.match(*value) != p.negate)
+}
+
+// literal is a string or a number in the condition
+type literal struct {
+	value   string
+	number  float64
+	numeric bool
+}
+
+func (l literal) equal(value string) bool {
+	if !l.numeric {
+		return value == l.value
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+	return err == nil && number == l.number
+}
+
+// compare returns the comparison of the value with the literal, false is
+// returned if a number literal is compared with a value which is not.
+func (l literal) compare(value string) (int, bool) {
+	if !l.numeric {
+		return strings.Compare(value, l.value), true
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case number < l.number:
+		return -1, true
+	case number > l.number:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// likeToRegex converts sql LIKE pattern into an anchored regex,
+// backslash escapes the wildcards and itself.
+func likeToRegex(pattern string) string {
+	var regex strings.Builder
+	regex.WriteString("(?s)^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			regex.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			regex.WriteString(".*")
+		case c == '_':
+			regex.WriteString(".")
+		default:
+			regex.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		regex.WriteString(regexp.QuoteMeta(`\`))
+	}
+	regex.WriteString("$")
+
+	return regex.String()
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// keyword tells if the token is the case insensitive keyword
+func (t token) keyword(keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.value, keyword)
+}
+
+var conditionOperators = []string{
+	"<=", ">=", "<>", "!=", "!~*", "!~", "~*", "=", "<", ">", "~",
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ","})
+			i++
+		case c == '\'':
+			// '' is the escaped quote
+			var value strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						value.WriteRune('\'')
+						i += 2
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value.String()})
+		case unicode.IsDigit(c) ||
+			(c == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[start:i])})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) &&
+				(unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: string(runes[start:i])})
+		default:
+			matched := false
+			for _, operator := range conditionOperators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, token{kind: tokenOperator, value: operator})
+					i += len([]rune(operator))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character: %q", c)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// conditionParser is a recursive descent parser of the conditions
+type conditionParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *conditionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// parseCondition compiles the condition expression
+func parseCondition(expression string) (condition, error) {
+	c, err := compileCondition(expression)
+	if err != nil {
+		return nil, fmt.Errorf("condition: %q, %v", expression, err)
+	}
+
+	return c, nil
+}
+
+func compileCondition(expression string) (condition, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &conditionParser{tokens: tokens}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected: %s", t.value)
+	}
+
+	return c, nil
+}
+
+func (p *conditionParser) parseOr() (condition, error) {
+	var operands orCondition
+	for {
+		operand, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+		if !p.peek().keyword("or") {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return operands, nil
+}
+
+func (p *conditionParser) parseAnd() (condition, error) {
+	var operands andCondition
+	for {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+		if !p.peek().keyword("and") {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return operands, nil
+}
+
+func (p *conditionParser) parseNot() (condition, error) {
+	if p.peek().keyword("not") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{operand: operand}, nil
+	}
+	if p.peek().kind == tokenLParen {
+		p.next()
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenRParen {
+			return nil, fmt.Errorf("expected )")
+		}
+		return c, nil
+	}
+
+	return p.parsePredicate()
+}
+
+func (p *conditionParser) parseLiteral() (literal, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literal{value: t.value}, nil
+	case tokenNumber:
+		number, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return literal{}, fmt.Errorf("invalid number: %s", t.value)
+		}
+		return literal{value: t.value, number: number, numeric: true}, nil
+	}
+
+	return literal{}, fmt.Errorf("expected string or number, got: %q", t.value)
+}
+
+func (p *conditionParser) parseString() (string, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return "", fmt.Errorf("expected string, got: %q", t.value)
+	}
+
+	return t.value, nil
+}
+
+func (p *conditionParser) parsePredicate() (condition, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, fmt.Errorf("expected column, got: %q", t.value)
+	}
+	pred := predicate{column: strings.ToLower(t.value)}
+
+	operator := p.next()
+	if operator.keyword("is") {
+		pred.isNull = true
+		if p.peek().keyword("not") {
+			p.next()
+			pred.negate = true
+		}
+		if !p.next().keyword("null") {
+			return nil, fmt.Errorf("expected NULL after IS")
+		}
+		return pred, nil
+	}
+	if operator.keyword("not") {
+		pred.negate = true
+		operator = p.next()
+	}
+
+	switch {
+	case operator.keyword("like"), operator.keyword("ilike"):
+		pattern, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		regex := likeToRegex(pattern)
+		if operator.keyword("ilike") {
+			regex = "(?i)" + regex
+		}
+		compiled := regexp.MustCompile(regex)
+		pred.match = compiled.MatchString
+	case operator.keyword("in"):
+		if p.next().kind != tokenLParen {
+			return nil, fmt.Errorf("expected ( after IN")
+		}
+		var literals []literal
+		for {
+			l, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			literals = append(literals, l)
+			t := p.next()
+			if t.kind == tokenRParen {
+				break
+			}
+			if t.kind != tokenComma {
+				return nil, fmt.Errorf("expected , or ) in IN list")
+			}
+		}
+		pred.match = func(value string) bool {
+			for _, l := range literals {
+				if l.equal(value) {
+					return true
+				}
+			}
+			return false
+		}
+	case pred.negate:
+		return nil, fmt.Errorf(
+			"expected LIKE, ILIKE or IN after NOT, got: %q", operator.value)
+	case operator.kind == tokenOperator && strings.Contains(operator.value, "~"):
+		pattern, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(operator.value, "*") {
+			pattern = "(?i)" + pattern
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("regex: %s compile failed, err: %v", pattern, err)
+		}
+		pred.match = compiled.MatchString
+		pred.negate = strings.HasPrefix(operator.value, "!")
+	case operator.kind == tokenOperator:
+		l, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		pred.match, err = comparison(operator.value, l)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf(
+			"expected operator after %s", pred.column)
+	}
+
+	return pred, nil
+}
+
+func comparison(operator string, l literal) (func(string) bool, error) {
+	compare := func(check func(int) bool) func(string) bool {
+		return func(value string) bool {
+			result, ok := l.compare(value)
+			return ok && check(result)
+		}
+	}
+
+	switch operator {
+	case "=":
+		return l.equal, nil
+	case "!=", "<>":
+		return func(value string) bool {
+			_, ok := l.compare(value)
+			return ok && !l.equal(value)
+		}, nil
+	case "<":
+		return compare(func(r int) bool { return r < 0 }), nil
+	case "<=":
+		return compare(func(r int) bool { return r <= 0 }), nil
+	case ">":
+		return compare(func(r int) bool { return r > 0 }), nil
+	case ">=":
+		return compare(func(r int) bool { return r >= 0 }), nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator: %s", operator)
+}
+
+// conditionalKeyCondition compiles the list of patterns of the conditional
+// non pii keys, the column is unmasked if it is LIKE any of them.
+func conditionalKeyCondition(patterns []interface{}) condition {
+	var operands orCondition
+	for _, patternRaw := range patterns {
+		regex := likeToRegex(fmt.Sprintf("%v", patternRaw))
+		operands = append(operands, predicate{
+			self:  true,
+			match: regexp.MustCompile(regex).MatchString,
+		})
+	}
+
+	return operands
+}
+
+// dependentKeyCondition compiles the map of provider columns and
+// their values of the dependent non pii keys, the column is unmasked if
+// any provider column has any of its values.
+func dependentKeyCondition(
+	providers map[interface{}]interface{}) (condition, error) {
+
+	var operands orCondition
+	for providerRaw, valuesRaw := range providers {
+		provider, ok := providerRaw.(string)
+		if !ok {
+			return nil, fmt.Errorf("provider: %v is not a string", providerRaw)
+		}
+		values, ok := valuesRaw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf(
+				"provider: %v, expected list of values", provider)
+		}
+		set := make(map[string]bool)
+		for _, value := range values {
+			set[fmt.Sprintf("%v", value)] = true
+		}
+		operands = append(operands, predicate{
+			column: strings.ToLower(provider),
+			match:  func(value string) bool { return set[value] },
+		})
+	}
+
+	return operands, nil
+}
@@ -0,0 +1,98 @@
+package masker
+
+import (
+	"testing"
+)
+
+func TestCondition(t *testing.T) {
+	t.Parallel()
+
+	columns := map[string]*string{
+		"status":   stringPtr("active"),
+		"type":     stringPtr("Doctor"),
+		"age":      stringPtr("32"),
+		"amount":   stringPtr("10.50"),
+		"email":    stringPtr("Batman@Practo.com"),
+		"code":     stringPtr("10%_off"),
+		"verified": nil,
+	}
+
+	tests := []struct {
+		name      string
+		condition string
+		result    truth
+	}{
+		{name: "equals", condition: "status = 'active'", result: truthTrue},
+		{name: "not equals", condition: "status <> 'active'", result: truthFalse},
+		{name: "keywords and columns are case insensitive", condition: "STATUS = 'active' and Type = 'Doctor'", result: truthTrue},
+		{name: "string comparison is case sensitive", condition: "type = 'doctor'", result: truthFalse},
+		{name: "numeric equals", condition: "amount = 10.5", result: truthTrue},
+		{name: "numeric greater", condition: "age > 18", result: truthTrue},
+		{name: "numeric less or equal", condition: "age <= 31", result: truthFalse},
+		{name: "numeric on non number", condition: "status > 1", result: truthFalse},
+		{name: "like", condition: "email LIKE '%@Practo.com'", result: truthTrue},
+		{name: "like is case sensitive", condition: "email LIKE '%@practo.com'", result: truthFalse},
+		{name: "ilike", condition: "email ILIKE 'batman@%.COM'", result: truthTrue},
+		{name: "like underscore", condition: "status LIKE 'activ_'", result: truthTrue},
+		{name: "like escapes", condition: `code LIKE '10\%\_off'`, result: truthTrue},
+		{name: "like escaped wildcard is literal", condition: `code LIKE '10\%'`, result: truthFalse},
+		{name: "not like", condition: "status NOT LIKE 'in%'", result: truthTrue},
+		{name: "regex", condition: "email ~ '^[A-Z]'", result: truthTrue},
+		{name: "regex case insensitive", condition: "email ~* '^batman'", result: truthTrue},
+		{name: "not regex", condition: "email !~ '^batman'", result: truthTrue},
+		{name: "in", condition: "type IN ('Patient', 'Doctor')", result: truthTrue},
+		{name: "numeric in", condition: "age IN (30, 32)", result: truthTrue},
+		{name: "not in", condition: "type NOT IN ('Patient', 'Doctor')", result: truthFalse},
+		{name: "is null", condition: "verified IS NULL", result: truthTrue},
+		{name: "is not null", condition: "status IS NOT NULL", result: truthTrue},
+		{name: "missing column is null", condition: "missing IS NULL", result: truthTrue},
+		{name: "comparison with null is unknown", condition: "verified = 'yes'", result: truthUnknown},
+		{name: "not of unknown is unknown", condition: "NOT verified = 'yes'", result: truthUnknown},
+		{name: "or with true is true", condition: "verified = 'yes' OR status = 'active'", result: truthTrue},
+		{name: "and with false is false", condition: "verified = 'yes' AND status = 'inactive'", result: truthFalse},
+		{name: "and binds tighter than or", condition: "status = 'inactive' AND age > 18 OR type = 'Doctor'", result: truthTrue},
+		{name: "parentheses", condition: "status = 'inactive' AND (age > 18 OR type = 'Doctor')", result: truthFalse},
+		{name: "quoted quote", condition: "type != 'Doctor''s'", result: truthTrue},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c, err := parseCondition(tc.condition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := c.eval(conditionRow{columns: columns})
+			if result != tc.result {
+				t.Errorf("expected: %v, got: %v", tc.result, result)
+			}
+		})
+	}
+}
+
+func TestConditionErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, condition := range []string{
+		"",
+		"status",
+		"status = ",
+		"status = active",
+		"status = 'active",
+		"status = 'active' AND",
+		"(status = 'active'",
+		"status IN 'active'",
+		"status IN ('active'",
+		"status IS 'active'",
+		"status NOT = 'active'",
+		"status ~ '('",
+		"status # 'active'",
+		"age > 1.2.3",
+	} {
+		_, err := parseCondition(condition)
+		if err == nil {
+			t.Errorf("condition: %q, expected error", condition)
+		}
+	}
+}
@@ -3,17 +3,33 @@ package masker
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/practo/klog/v2"
-	"github.com/practo/tipoca-stream/pkg/git"
+	"github.com/practo/tipoca-stream/pkg/masksource"
 	"github.com/practo/tipoca-stream/pkg/transformer"
 	"gopkg.in/yaml.v2"
 )
 
+// sections of the mask configuration, keyed by their yaml names
+const (
+	nonPiiKeysSection              = "non_pii_keys"
+	conditionalNonPiiKeysSection   = "conditional_non_pii_keys"
+	dependentNonPiiKeysSection     = "dependent_non_pii_keys"
+	lengthKeysSection              = "length_keys"
+	mobileKeysSection              = "mobile_keys"
+	mappingPIIKeysSection          = "mapping_pii_keys"
+	sortKeysSection                = "sort_keys"
+	distKeysSection                = "dist_keys"
+	regexPatternBooleanKeysSection = "regex_pattern_boolean_keys"
+	excludeColumnsSection          = "exclude_columns"
+	maskFunctionsSection           = "mask_functions"
+	rowFiltersSection              = "row_filters"
+	jsonPathKeysSection            = "json_path_keys"
+	generalizeKeysSection          = "generalize_keys"
+)
+
 var (
 	ignoreColumns = map[string]bool{
 		transformer.TempTablePrimary: true,
@@ -26,9 +42,11 @@ type MaskConfig struct {
 	NonPiiKeys map[string][]string `yaml:"non_pii_keys,omitempty"`
 
 	// ConditionalNonPiiKeys unmasks columns if it matches a list of pattern
+	// or the condition on the columns of the row.
 	ConditionalNonPiiKeys map[string]interface{} `yaml:"conditional_non_pii_keys,omitempty"`
 
 	// DependentNonPiiKeys unmasks columns based on the values of other columns
+	// or the condition on the columns of the row.
 	DependentNonPiiKeys map[string]interface{} `yaml:"dependent_non_pii_keys,omitempty"`
 
 	// LengthKeys creates extra column containing the length of original column
@@ -55,18 +73,167 @@ type MaskConfig struct {
 	// value in the free text column.
 	RegexPatternBooleanKeys map[string]interface{} `yaml:"regex_pattern_boolean_keys,omitempty"`
 
+	// ExcludeColumns are removed from the table, they are never sinked
+	ExcludeColumns map[string][]string `yaml:"exclude_columns,omitempty"`
+
+	// MaskFunctions specifies the function to mask the columns with,
+	// keyed by table and then column. Default is MaskSHA1.
+	MaskFunctions map[string]map[string]MaskFunction `yaml:"mask_functions,omitempty"`
+
+	// RowFilters keeps only the rows of the table which match all the
+	// filters of the table, rest of the rows are not sinked.
+	RowFilters map[string][]RowFilter `yaml:"row_filters,omitempty"`
+
+	// JSONPathKeys keeps the JSON columns unmasked but masks the values
+	// at the paths, the paths are column.$.path, eg: metadata.$.address.phone
+	JSONPathKeys map[string][]string `yaml:"json_path_keys,omitempty"`
+
+	// GeneralizeKeys creates extra typed columns containing the approximate
+	// values of the columns, keyed by table and then column.
+	GeneralizeKeys map[string]map[string]Generalization `yaml:"generalize_keys,omitempty"`
+
 	// regexes cache is used to prevent regex Compile on every message mask run.
 	regexes map[string]*regexp.Regexp
+
+	// keyRegexes are the compiled regex table and column keys
+	keyRegexes map[string]*regexp.Regexp
+	// tableKeys are the table keys of every section sorted by precedence
+	tableKeys map[string][]string
+	// columnKeys are the column keys of every section/table sorted by
+	// precedence
+	columnKeys map[string][]string
+
+	// jsonPaths are the parsed paths of the json path keys by table and
+	// column, jsonPathColumns are the columns of every table
+	jsonPaths       map[string]map[string][]jsonPath
+	jsonPathColumns map[string][]string
+
+	// conditions are the compiled conditions of the conditional and the
+	// dependent non pii keys by section/table/column keys
+	conditions map[string]condition
+}
+
+// RowFilter is a predicate on the value of a column. Only one of
+// Equals, In, Like or Regex should be specified.
+type RowFilter struct {
+	Column string   `yaml:"column"`
+	Equals *string  `yaml:"equals,omitempty"`
+	In     []string `yaml:"in,omitempty"`
+	Like   *string  `yaml:"like,omitempty"`
+	Regex  *string  `yaml:"regex,omitempty"`
+
+	// regex is compiled from Like or Regex when the config is loaded
+	regex *regexp.Regexp
+}
+
+// compile validates the filter and compiles its regex
+func (f *RowFilter) compile() error {
+	f.Column = strings.ToLower(f.Column)
+	if f.Column == "" {
+		return fmt.Errorf("row filter column is empty")
+	}
+
+	predicates := 0
+	if f.Equals != nil {
+		predicates++
+	}
+	if f.In != nil {
+		predicates++
+	}
+	if f.Like != nil {
+		predicates++
+		f.regex = regexp.MustCompile(likeToRegex(*f.Like))
+	}
+	if f.Regex != nil {
+		predicates++
+		regex, err := regexp.Compile(*f.Regex)
+		if err != nil {
+			return fmt.Errorf(
+				"row filter column: %s, regex: %s compile failed, err: %v",
+				f.Column, *f.Regex, err)
+		}
+		f.regex = regex
+	}
+	if predicates != 1 {
+		return fmt.Errorf(
+			"row filter column: %s, requires one of equals, in, like, regex",
+			f.Column)
+	}
+
+	return nil
+}
+
+// match tells if the value matches the filter, null never matches
+func (f RowFilter) match(value *string) bool {
+	if value == nil {
+		return false
+	}
+
+	switch {
+	case f.Equals != nil:
+		return *value == *f.Equals
+	case f.In != nil:
+		for _, in := range f.In {
+			if *value == in {
+				return true
+			}
+		}
+		return false
+	default:
+		return f.regex.MatchString(*value)
+	}
+}
+
+// loweredTables lowers the table keys, the columns of the table keys
+// which are the same once lowered are merged.
+func loweredTables(keys map[string][]string) map[string][]string {
+	if keys == nil {
+		return nil
+	}
+	lowered := make(map[string][]string)
+	for table, columns := range keys {
+		loweredTable := lowerKey(table)
+		lowered[loweredTable] = append(lowered[loweredTable], columns...)
+	}
+
+	return lowered
+}
+
+// loweredColumnTables lowers the table keys of the sections having a
+// columns map per table, the columns maps of the table keys which are
+// the same once lowered are merged.
+func loweredColumnTables(keys map[string]interface{}) map[string]interface{} {
+	if keys == nil {
+		return nil
+	}
+	lowered := make(map[string]interface{})
+	for table, columnsRaw := range keys {
+		loweredTable := lowerKey(table)
+		existing, ok := lowered[loweredTable].(map[interface{}]interface{})
+		columns, ok2 := columnsRaw.(map[interface{}]interface{})
+		if ok && ok2 {
+			for column, value := range columns {
+				existing[column] = value
+			}
+			continue
+		}
+		lowered[loweredTable] = columnsRaw
+	}
+
+	return lowered
 }
 
-func loweredKeys(keys map[string][]string) {
+func loweredKeys(keys map[string][]string) map[string][]string {
+	keys = loweredTables(keys)
 	for table, columns := range keys {
 		var loweredColumns []string
 		for _, column := range columns {
-			loweredColumns = append(loweredColumns, strings.ToLower(column))
+			loweredColumns = append(loweredColumns, lowerKey(column))
 		}
 		keys[table] = loweredColumns
 	}
+
+	return keys
 }
 
 func loweredList(items *[]string) *[]string {
@@ -75,87 +242,35 @@ func loweredList(items *[]string) *[]string {
 	}
 	lower := []string{}
 	for _, item := range *items {
-		lower = append(lower, strings.ToLower(item))
+		lower = append(lower, lowerKey(item))
 	}
 
 	return &lower
 }
 
-func downloadMaskFile(
+func NewMaskConfig(
 	homeDir string,
 	maskFile string,
 	maskFileVersion string,
-	gitToken string) (string, error) {
+	gitToken string) (MaskConfig, error) {
 
-	url, err := git.ParseURL(maskFile)
+	source, err := masksource.New(
+		maskFile, masksource.Config{GitAccessToken: gitToken})
 	if err != nil {
-		return "", err
+		return MaskConfig{}, err
 	}
 
-	switch url.Scheme {
-	case "file":
-		klog.V(5).Info("Mask file is of file type, nothing to download")
-		return maskFile, nil
-	default:
-		if maskFileVersion == "" {
-			return "", fmt.Errorf(
-				"maskFileVersion is mandatory if maskFile is not local file.",
-			)
-		}
-		var repo, configFilePath string
-		switch url.Host {
-		case "github.com":
-			repo, configFilePath = git.ParseGithubURL(url.Path)
-		default:
-			return "", fmt.Errorf("parsing not supported for: %s\n", url.Host)
-		}
-
-		dir, err := ioutil.TempDir("", "maskdir")
-		if err != nil {
-			return "", err
-		}
-		defer os.RemoveAll(dir)
-
-		g := git.New(
-			dir,
-			fmt.Sprintf("%s://%s/%s", url.Scheme, url.Host, repo),
-			gitToken,
-		)
-
-		klog.V(5).Infof("Downloading git repo: %s", repo)
-		err = g.Clone()
-		if err != nil {
-			return "", err
-		}
-		err = g.Checkout(maskFileVersion)
-		if err != nil {
-			return "", err
-		}
-		klog.V(5).Infof("Downloaded git repo at: %s", dir)
-
-		sourceFile := filepath.Join(dir, configFilePath)
-		destFile := filepath.Join(homeDir, filepath.Base(sourceFile))
-		_, err = git.Copy(sourceFile, destFile)
-		if err != nil {
-			return "", fmt.Errorf(
-				"Error copying! src: %s, dest: %s, err:%v\n",
-				sourceFile, destFile, err)
-		}
-		klog.V(5).Info("Copied the mask file at the read location")
-
-		return destFile, nil
-	}
+	return NewMaskConfigFromSource(homeDir, source, maskFileVersion)
 }
 
-func NewMaskConfig(
+// NewMaskConfigFromSource loads the mask file of the source at the version
+func NewMaskConfigFromSource(
 	homeDir string,
-	maskFile string,
-	maskFileVersion string,
-	gitToken string) (MaskConfig, error) {
+	source masksource.Source,
+	maskFileVersion string) (MaskConfig, error) {
 
 	var maskConfig MaskConfig
-	configFilePath, err := downloadMaskFile(
-		homeDir, maskFile, maskFileVersion, gitToken)
+	configFilePath, err := source.Download(homeDir, maskFileVersion)
 	if err != nil {
 		return maskConfig, err
 	}
@@ -172,202 +287,319 @@ func NewMaskConfig(
 		return maskConfig, fmt.Errorf(
 			"Unable to unmarshal: %v, err: %v", configFilePath, err)
 	}
-	klog.V(3).Infof("Loaded mask configuration from: %s\n", maskFile)
-
-	// convert to lower case, redshift works with lowercase
-	loweredKeys(maskConfig.NonPiiKeys)
-	loweredKeys(maskConfig.LengthKeys)
-	loweredKeys(maskConfig.MobileKeys)
-	loweredKeys(maskConfig.MappingPIIKeys)
-	loweredKeys(maskConfig.SortKeys)
-	loweredKeys(maskConfig.DistKeys)
+	klog.V(3).Infof(
+		"Loaded mask configuration version: %s\n", maskFileVersion)
 
-	maskConfig.IncludeTables = loweredList(maskConfig.IncludeTables)
-	maskConfig.regexes = make(map[string]*regexp.Regexp)
+	err = maskConfig.prepare()
+	if err != nil {
+		return maskConfig, err
+	}
 
 	return maskConfig, nil
 }
 
-func (m MaskConfig) LengthKey(table, cName string) bool {
-	columns, ok := m.LengthKeys[table]
-	if !ok {
-		return false
+// prepare lowers the keys, validates and compiles the configuration
+func (m *MaskConfig) prepare() error {
+	// convert to lower case, redshift works with lowercase
+	m.NonPiiKeys = loweredKeys(m.NonPiiKeys)
+	m.LengthKeys = loweredKeys(m.LengthKeys)
+	m.MobileKeys = loweredKeys(m.MobileKeys)
+	m.MappingPIIKeys = loweredKeys(m.MappingPIIKeys)
+	m.SortKeys = loweredKeys(m.SortKeys)
+	m.DistKeys = loweredKeys(m.DistKeys)
+	m.ExcludeColumns = loweredKeys(m.ExcludeColumns)
+	m.ConditionalNonPiiKeys = loweredColumnTables(m.ConditionalNonPiiKeys)
+	m.DependentNonPiiKeys = loweredColumnTables(m.DependentNonPiiKeys)
+	m.RegexPatternBooleanKeys = loweredColumnTables(m.RegexPatternBooleanKeys)
+	// the json paths are case sensitive, only the tables are lowered
+	m.JSONPathKeys = loweredTables(m.JSONPathKeys)
+
+	m.IncludeTables = loweredList(m.IncludeTables)
+	m.regexes = make(map[string]*regexp.Regexp)
+
+	loweredMaskFunctions := make(map[string]map[string]MaskFunction)
+	for table, functions := range m.MaskFunctions {
+		loweredTable := lowerKey(table)
+		loweredFunctions, ok := loweredMaskFunctions[loweredTable]
+		if !ok {
+			loweredFunctions = make(map[string]MaskFunction)
+			loweredMaskFunctions[loweredTable] = loweredFunctions
+		}
+		sortKeys := toSet(m.SortKeys[loweredTable])
+		distKeys := toSet(m.DistKeys[loweredTable])
+		for column, function := range functions {
+			keyColumn := sortKeys[lowerKey(column)] || distKeys[lowerKey(column)]
+			err := function.validate(keyColumn)
+			if err != nil {
+				return fmt.Errorf(
+					"table: %s, column: %s, %v", table, column, err)
+			}
+			loweredFunctions[lowerKey(column)] = function
+		}
+	}
+	if m.MaskFunctions != nil {
+		m.MaskFunctions = loweredMaskFunctions
 	}
 
-	for _, column := range columns {
-		if column == cName {
-			return true
+	loweredGeneralizeKeys := make(map[string]map[string]Generalization)
+	for table, generalizations := range m.GeneralizeKeys {
+		loweredTable := lowerKey(table)
+		loweredGeneralizations, ok := loweredGeneralizeKeys[loweredTable]
+		if !ok {
+			loweredGeneralizations = make(map[string]Generalization)
+			loweredGeneralizeKeys[loweredTable] = loweredGeneralizations
+		}
+		for column, generalization := range generalizations {
+			err := generalization.compile()
+			if err != nil {
+				return fmt.Errorf("%s: table: %s, column: %s, %v",
+					generalizeKeysSection, table, column, err)
+			}
+			loweredGeneralizations[lowerKey(column)] = generalization
 		}
 	}
+	if m.GeneralizeKeys != nil {
+		m.GeneralizeKeys = loweredGeneralizeKeys
+	}
 
-	return false
-}
+	loweredRowFilters := make(map[string][]RowFilter)
+	for table, filters := range m.RowFilters {
+		for i := range filters {
+			err := filters[i].compile()
+			if err != nil {
+				return fmt.Errorf("table: %s, %v", table, err)
+			}
+		}
+		loweredTable := lowerKey(table)
+		loweredRowFilters[loweredTable] = append(
+			loweredRowFilters[loweredTable], filters...)
+	}
+	if m.RowFilters != nil {
+		m.RowFilters = loweredRowFilters
+	}
 
-func (m MaskConfig) MobileKey(table, cName string) bool {
-	columns, ok := m.MobileKeys[table]
-	if !ok {
-		return false
+	err := m.compileJSONPaths()
+	if err != nil {
+		return err
 	}
 
-	for _, column := range columns {
-		if column == cName {
-			return true
-		}
+	err = m.compileKeys()
+	if err != nil {
+		return err
 	}
 
-	return false
+	return m.compileColumnConfigs()
 }
 
-func (m MaskConfig) MappingPIIKey(table, cName string) bool {
-	columns, ok := m.MappingPIIKeys[table]
-	if !ok {
-		return false
+// conditionKey is the key of the compiled condition of the column
+func conditionKey(section, tableKey, columnKey string) string {
+	return section + "/" + tableKey + "/" + columnKey
+}
+
+// compileColumnConfigs validates the columns of conditional, dependent and
+// regex pattern boolean keys and compiles their conditions and regexes, so
+// that a bad config fails at load and not while masking.
+func (m *MaskConfig) compileColumnConfigs() error {
+	compile := func(pattern string) error {
+		if _, ok := m.regexes[pattern]; ok {
+			return nil
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		m.regexes[pattern] = regex
+		return nil
 	}
 
-	for _, column := range columns {
-		if column == cName {
-			return true
+	m.conditions = make(map[string]condition)
+	for table, columnsRaw := range m.ConditionalNonPiiKeys {
+		for column, conditionRaw := range columnsRaw.(map[interface{}]interface{}) {
+			var c condition
+			var err error
+			switch value := conditionRaw.(type) {
+			case []interface{}:
+				c = conditionalKeyCondition(value)
+			case string:
+				c, err = parseCondition(value)
+			default:
+				err = fmt.Errorf("expected list of patterns or a condition")
+			}
+			if err != nil {
+				return fmt.Errorf(
+					"%s: table: %s, column: %v, %v",
+					conditionalNonPiiKeysSection, table, column, err)
+			}
+			m.conditions[conditionKey(
+				conditionalNonPiiKeysSection, table, column.(string))] = c
 		}
 	}
 
-	return false
-}
+	for table, columnsRaw := range m.DependentNonPiiKeys {
+		for column, conditionRaw := range columnsRaw.(map[interface{}]interface{}) {
+			var c condition
+			var err error
+			switch value := conditionRaw.(type) {
+			case map[interface{}]interface{}:
+				c, err = dependentKeyCondition(value)
+			case string:
+				c, err = parseCondition(value)
+			default:
+				err = fmt.Errorf(
+					"expected map of provider columns or a condition")
+			}
+			if err != nil {
+				return fmt.Errorf(
+					"%s: table: %s, column: %v, %v",
+					dependentNonPiiKeysSection, table, column, err)
+			}
+			m.conditions[conditionKey(
+				dependentNonPiiKeysSection, table, column.(string))] = c
+		}
+	}
 
-func (m MaskConfig) hasMappingPIIKey(table string) bool {
-	_, ok := m.MappingPIIKeys[table]
-	if ok {
-		return true
+	for table, columnsRaw := range m.RegexPatternBooleanKeys {
+		for column, regexesRaw := range columnsRaw.(map[interface{}]interface{}) {
+			regexes, ok := regexesRaw.(map[interface{}]interface{})
+			if !ok {
+				return fmt.Errorf(
+					"%s: table: %s, column: %v, expected map of regexes",
+					regexPatternBooleanKeysSection, table, column)
+			}
+			for name, patternRaw := range regexes {
+				_, ok := name.(string)
+				pattern, ok2 := patternRaw.(string)
+				if !ok || !ok2 {
+					return fmt.Errorf(
+						"%s: table: %s, column: %v, regex: %v, expected string",
+						regexPatternBooleanKeysSection, table, column, name)
+				}
+				err := compile(fmt.Sprintf("(?i)%s", pattern))
+				if err != nil {
+					return fmt.Errorf(
+						"%s: table: %s, column: %v, regex: %v, err: %v",
+						regexPatternBooleanKeysSection, table, column, name, err)
+				}
+			}
+		}
 	}
 
-	return false
+	return nil
 }
 
-func (m MaskConfig) SortKey(table, cName string) bool {
-	columns, ok := m.SortKeys[table]
-	if !ok {
-		return false
+// KeepRow tells if the row of the table should be sinked based on the
+// row filters, all the filters of the table must match to keep the row.
+// Filters of the table key with the highest precedence are used.
+func (m MaskConfig) KeepRow(table string, columns map[string]*string) bool {
+	tables := m.matchingTables(rowFiltersSection, table)
+	if len(tables) == 0 {
+		return true
 	}
 
-	for _, column := range columns {
-		if column == cName {
-			return true
+	for _, filter := range m.RowFilters[tables[0]] {
+		if !filter.match(columns[filter.Column]) {
+			return false
 		}
 	}
 
-	return false
+	return true
 }
 
-func (m MaskConfig) DistKey(table, cName string) bool {
-	columns, ok := m.DistKeys[table]
-	if !ok {
-		return false
-	}
-
-	for _, column := range columns {
-		if column == cName {
-			return true
+// MaskFunction returns the mask function of the column, empty function
+// is returned when not specified which means MaskSHA1.
+func (m MaskConfig) MaskFunction(table, cName string) MaskFunction {
+	for _, tableKey := range m.matchingTables(maskFunctionsSection, table) {
+		columnKey, ok := m.matchingColumn(
+			maskFunctionsSection, tableKey, cName)
+		if ok {
+			return m.MaskFunctions[tableKey][columnKey]
 		}
 	}
 
-	return false
+	return MaskFunction{}
 }
 
-func (m MaskConfig) ConditionalNonPiiKey(table, cName string) bool {
-	columnsToCheckRaw, ok := m.ConditionalNonPiiKeys[table]
-	if !ok {
-		return false
-	}
-	columnsToCheck, ok := columnsToCheckRaw.(map[interface{}]interface{})
-	if !ok {
-		klog.Fatalf(
-			"Type assertion error! table: %s, cName: %s\n", table, cName)
-	}
+func (m MaskConfig) ExcludeColumn(table, cName string) bool {
+	return m.hasColumn(excludeColumnsSection, table, cName)
+}
 
-	for columnNameRaw, _ := range columnsToCheck {
-		columnName := columnNameRaw.(string)
-		columnName = strings.ToLower(columnName)
-		if columnName == cName {
-			return true
-		}
-	}
+func (m MaskConfig) LengthKey(table, cName string) bool {
+	return m.hasColumn(lengthKeysSection, table, cName)
+}
 
-	return false
+func (m MaskConfig) MobileKey(table, cName string) bool {
+	return m.hasColumn(mobileKeysSection, table, cName)
 }
 
-func (m MaskConfig) DependentNonPiiKey(table, cName string) bool {
-	columnsToCheckRaw, ok := m.DependentNonPiiKeys[table]
-	if !ok {
-		return false
-	}
-	columnsToCheck, ok := columnsToCheckRaw.(map[interface{}]interface{})
-	if !ok {
-		klog.Fatalf(
-			"Type assertion error! table: %s, cName: %s\n", table, cName)
-	}
+func (m MaskConfig) MappingPIIKey(table, cName string) bool {
+	return m.hasColumn(mappingPIIKeysSection, table, cName)
+}
 
-	for dependentColumnNameRaw, _ := range columnsToCheck {
-		dependentColumnName := dependentColumnNameRaw.(string)
-		dependentColumnName = strings.ToLower(dependentColumnName)
-		if dependentColumnName == cName {
-			return true
-		}
-	}
+func (m MaskConfig) hasMappingPIIKey(table string) bool {
+	return len(m.matchingTables(mappingPIIKeysSection, table)) > 0
+}
 
-	return false
+func (m MaskConfig) SortKey(table, cName string) bool {
+	return m.hasColumn(sortKeysSection, table, cName)
+}
+
+func (m MaskConfig) DistKey(table, cName string) bool {
+	return m.hasColumn(distKeysSection, table, cName)
+}
+
+func (m MaskConfig) ConditionalNonPiiKey(table, cName string) bool {
+	_, ok := m.columnConfig(
+		conditionalNonPiiKeysSection, m.ConditionalNonPiiKeys, table, cName)
+
+	return ok
+}
+
+func (m MaskConfig) DependentNonPiiKey(table, cName string) bool {
+	_, ok := m.columnConfig(
+		dependentNonPiiKeysSection, m.DependentNonPiiKeys, table, cName)
+
+	return ok
 }
 
 // BoolColumns returns extra boolean columns for the parent column(free text col)
 // to make analysis on the data contained in parent column possible using the
 // boolean columns
 func (m MaskConfig) BoolColumns(table, cName string, cValue *string) map[string]*string {
-	columnsToCheckRaw, ok := m.RegexPatternBooleanKeys[table]
+	regexesRaw, ok := m.columnConfig(
+		regexPatternBooleanKeysSection, m.RegexPatternBooleanKeys, table, cName)
 	if !ok {
 		return nil
 	}
 
-	columnsToCheck, ok := columnsToCheckRaw.(map[interface{}]interface{})
+	regexes, ok := regexesRaw.(map[interface{}]interface{})
 	if !ok {
 		klog.Fatalf(
-			"Type assertion error! table: %s, cName: %s\n", table, cName)
+			"Type assertion error! table: %s, cName: %s\n",
+			table, cName)
 	}
 
 	boolColumns := make(map[string]*string)
-	for freeTextColumnNameRaw, regexesRaw := range columnsToCheck {
-		freeTextColumnName := freeTextColumnNameRaw.(string)
-		freeTextColumnName = strings.ToLower(freeTextColumnName) // favourite_quote
-		if freeTextColumnName != cName {
-			continue
-		}
+	for regexNameRaw, patternRaw := range regexes {
+		regexName := regexNameRaw.(string)
+		regexName = strings.ToLower(regexName)
+		caseInsensitivePattern := fmt.Sprintf("(?i)%s", patternRaw.(string))
 
-		regexes, ok := regexesRaw.(map[interface{}]interface{})
+		var err error
+		regex, ok := m.regexes[caseInsensitivePattern]
 		if !ok {
-			klog.Fatalf(
-				"Type assertion error! table: %s, cName: %s\n",
-				table, cName)
-		}
-
-		for regexNameRaw, patternRaw := range regexes {
-			regexName := regexNameRaw.(string)
-			regexName = strings.ToLower(regexName)
-			caseInsensitivePattern := fmt.Sprintf("(?i)%s", patternRaw.(string))
-
-			var err error
-			regex, ok := m.regexes[caseInsensitivePattern]
-			if !ok {
-				regex, err = regexp.Compile(caseInsensitivePattern)
-				if err != nil {
-					klog.Fatalf(
-						"Regex: %s compile failed, err:%v\n", caseInsensitivePattern, err)
-				}
-				m.regexes[caseInsensitivePattern] = regex
-			}
-
-			if cValue != nil && regex.MatchString(*cValue) {
-				boolColumns[fmt.Sprintf("%s_%s", freeTextColumnName, regexName)] = stringPtr("true")
-			} else {
-				boolColumns[fmt.Sprintf("%s_%s", freeTextColumnName, regexName)] = stringPtr("false")
+			regex, err = regexp.Compile(caseInsensitivePattern)
+			if err != nil {
+				klog.Fatalf(
+					"Regex: %s compile failed, err:%v\n", caseInsensitivePattern, err)
 			}
+			m.regexes[caseInsensitivePattern] = regex
+		}
 
+		// the free text column is cName, the key can be a pattern
+		if cValue != nil && regex.MatchString(*cValue) {
+			boolColumns[fmt.Sprintf("%s_%s", cName, regexName)] = stringPtr("true")
+		} else {
+			boolColumns[fmt.Sprintf("%s_%s", cName, regexName)] = stringPtr("false")
 		}
 	}
 
@@ -386,8 +618,8 @@ func (m MaskConfig) PerformUnMasking(table, cName string, cValue *string,
 	}
 
 	if m.unMaskNonPiiKeys(table, cName) ||
-		m.unMaskConditionalNonPiiKeys(table, cName, cValue) ||
-		m.unMaskDependentNonPiiKeys(table, cName, allColumns) {
+		m.unMaskConditionalNonPiiKeys(table, cName, cValue, allColumns) ||
+		m.unMaskDependentNonPiiKeys(table, cName, cValue, allColumns) {
 
 		return true
 	}
@@ -396,121 +628,40 @@ func (m MaskConfig) PerformUnMasking(table, cName string, cValue *string,
 }
 
 func (m MaskConfig) unMaskNonPiiKeys(table, cName string) bool {
-	columnsToUnmask, ok := m.NonPiiKeys[table]
-	if !ok {
-		return false
-	}
-
-	for _, c := range columnsToUnmask {
-		if c == cName {
-			return true
-		}
-	}
-
-	return false
+	return m.hasColumn(nonPiiKeysSection, table, cName)
 }
 
+// unMaskConditionalNonPiiKeys unmasks the column if its condition is true
 func (m MaskConfig) unMaskConditionalNonPiiKeys(
-	table, cName string, cValue *string) bool {
-	if cValue == nil {
-		return false
-	}
-
-	columnsToCheckRaw, ok := m.ConditionalNonPiiKeys[table]
-	if !ok {
-		return false
-	}
-
-	columnsToCheck, ok := columnsToCheckRaw.(map[interface{}]interface{})
-	if !ok {
-		klog.Fatalf(
-			"Type assertion error! table: %s, cName: %s\n", table, cName)
-	}
-
-	for columnNameRaw, patternsR := range columnsToCheck {
-		columnName := columnNameRaw.(string)
-		columnName = strings.ToLower(columnName)
-		if columnName != cName {
-			continue
-		}
-
-		patternsRaw := patternsR.([]interface{})
+	table, cName string, cValue *string,
+	allColumns map[string]*string) bool {
 
-		var err error
-		for _, patternRaw := range patternsRaw {
-			pattern := fmt.Sprintf("%v", patternRaw)
-			// replace sql patterns with regex patterns
-			// TODO: cover all cases :pray
-			pattern = strings.ReplaceAll(pattern, "%", ".*")
-			pattern = "^" + pattern + "$"
-			regex, ok := m.regexes[pattern]
-			if !ok {
-				regex, err = regexp.Compile(pattern)
-				if err != nil {
-					klog.Fatalf(
-						"Regex: %s compile failed, err:%v\n", pattern, err)
-				}
-				m.regexes[pattern] = regex
-			}
+	return m.evalCondition(
+		conditionalNonPiiKeysSection, table, cName, cValue, allColumns)
+}
 
-			if regex.MatchString(*cValue) {
-				return true
-			}
-		}
-	}
+// unMaskDependentNonPiiKeys unmasks the column if its condition on the
+// provider columns is true
+func (m MaskConfig) unMaskDependentNonPiiKeys(
+	table, cName string, cValue *string,
+	allColumns map[string]*string) bool {
 
-	return false
+	return m.evalCondition(
+		dependentNonPiiKeysSection, table, cName, cValue, allColumns)
 }
 
-func (m MaskConfig) unMaskDependentNonPiiKeys(
-	table, cName string, allColumns map[string]*string) bool {
+func (m MaskConfig) evalCondition(
+	section, table, cName string, cValue *string,
+	allColumns map[string]*string) bool {
 
-	// if table not in config, no unmasking required
-	columnsToCheckRaw, ok := m.DependentNonPiiKeys[table]
+	tableKey, columnKey, ok := m.columnConfigKeys(section, table, cName)
 	if !ok {
 		return false
 	}
-
-	columnsToCheck, ok := columnsToCheckRaw.(map[interface{}]interface{})
+	c, ok := m.conditions[conditionKey(section, tableKey, columnKey)]
 	if !ok {
-		klog.Fatalf(
-			"Type assertion error! table: %s, cName: %s\n", table, cName)
-	}
-
-	for dependentColumnNameRaw, providerColumnRaw := range columnsToCheck {
-		dependentColumnName := dependentColumnNameRaw.(string)
-		dependentColumnName = strings.ToLower(dependentColumnName)
-		if dependentColumnName != cName {
-			continue
-		}
-
-		providerColumn, ok := providerColumnRaw.(map[interface{}]interface{})
-		if !ok {
-			klog.Fatalf(
-				"Type assertion error! table: %s, cName: %s\n",
-				table, cName)
-		}
-
-		for providerColumnNameRaw, valuesRaw := range providerColumn {
-			providerColumnName := providerColumnNameRaw.(string)
-			providerColumnName = strings.ToLower(providerColumnName)
-			values := valuesRaw.([]interface{})
-			for _, valueRaw := range values {
-
-				value := fmt.Sprintf("%v", valueRaw)
-				pcValue, ok := allColumns[providerColumnName]
-				if !ok {
-					continue
-				}
-				if pcValue == nil {
-					continue
-				}
-				if ok && value == *pcValue {
-					return true
-				}
-			}
-		}
+		return false
 	}
 
-	return false
+	return c.eval(conditionRow{self: cValue, columns: allColumns}) == truthTrue
 }
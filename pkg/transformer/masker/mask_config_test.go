@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"gopkg.in/yaml.v2"
 )
 
 func testMasked(t *testing.T, topic, table, cName, cValue string,
@@ -150,3 +152,247 @@ func TestMaskConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestRowFilters(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMaskConfig("/", filepath.Join(dir, "database.yaml"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		table   string
+		columns map[string]*string
+		keep    bool
+	}{
+		{
+			name:  "test1: table without filters keeps all rows",
+			table: "customers",
+			columns: map[string]*string{
+				"id": stringPtr("1"),
+			},
+			keep: true,
+		},
+		{
+			name:  "test2: in and like match",
+			table: "orders",
+			columns: map[string]*string{
+				"country_code": stringPtr("91"),
+				"status":       stringPtr("paid_online"),
+			},
+			keep: true,
+		},
+		{
+			name:  "test3: in does not match",
+			table: "orders",
+			columns: map[string]*string{
+				"country_code": stringPtr("44"),
+				"status":       stringPtr("paid_online"),
+			},
+			keep: false,
+		},
+		{
+			name:  "test4: like underscore matches exactly one character",
+			table: "orders",
+			columns: map[string]*string{
+				"country_code": stringPtr("1"),
+				"status":       stringPtr("paid"),
+			},
+			keep: false,
+		},
+		{
+			name:  "test5: like is anchored",
+			table: "orders",
+			columns: map[string]*string{
+				"country_code": stringPtr("1"),
+				"status":       stringPtr("unpaid_online"),
+			},
+			keep: false,
+		},
+		{
+			name:  "test6: equals and regex match",
+			table: "leads",
+			columns: map[string]*string{
+				"source": stringPtr("web"),
+				"email":  stringPtr("lead@practo.com"),
+			},
+			keep: true,
+		},
+		{
+			name:  "test7: regex does not match",
+			table: "leads",
+			columns: map[string]*string{
+				"source": stringPtr("web"),
+				"email":  stringPtr("lead@example.com"),
+			},
+			keep: false,
+		},
+		{
+			name:  "test8: null value does not match",
+			table: "leads",
+			columns: map[string]*string{
+				"source": nil,
+				"email":  stringPtr("lead@practo.com"),
+			},
+			keep: false,
+		},
+		{
+			name:  "test9: missing column does not match",
+			table: "leads",
+			columns: map[string]*string{
+				"email": stringPtr("lead@practo.com"),
+			},
+			keep: false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			keep := m.KeepRow(tc.table, tc.columns)
+			if keep != tc.keep {
+				t.Errorf("expected keep: %v, got: %v\n", tc.keep, keep)
+			}
+		})
+	}
+}
+
+func TestRowFiltersTableCase(t *testing.T) {
+	t.Parallel()
+
+	m := &MaskConfig{
+		RowFilters: map[string][]RowFilter{
+			"Customers": {
+				{Column: "Country_Code", Equals: stringPtr("91")},
+			},
+		},
+	}
+	err := m.prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.KeepRow("customers", map[string]*string{
+		"country_code": stringPtr("44"),
+	}) {
+		t.Errorf("expected the row filter of Customers to drop the row")
+	}
+	if !m.KeepRow("customers", map[string]*string{
+		"country_code": stringPtr("91"),
+	}) {
+		t.Errorf("expected the row filter of Customers to keep the row")
+	}
+}
+
+func TestTableKeysCase(t *testing.T) {
+	t.Parallel()
+
+	config := `
+non_pii_keys:
+  Customers:
+    - id
+  customers:
+    - Name
+sort_keys:
+  Customers:
+    - id
+conditional_non_pii_keys:
+  Customers:
+    city:
+      - "bangalore"
+json_path_keys:
+  Customers:
+    - metadata.$.Phone
+mask_functions:
+  Customers:
+    email:
+      function: keep_email_domain
+generalize_keys:
+  Customers:
+    created_at:
+      function: month
+`
+	var m MaskConfig
+	err := yaml.Unmarshal([]byte(config), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.unMaskNonPiiKeys("customers", "id") ||
+		!m.unMaskNonPiiKeys("customers", "name") {
+		t.Errorf("expected the non pii keys of Customers and customers to be merged")
+	}
+	if !m.SortKey("customers", "id") {
+		t.Errorf("expected the sort key of Customers")
+	}
+	if !m.ConditionalNonPiiKey("customers", "city") {
+		t.Errorf("expected the conditional non pii key of Customers")
+	}
+	if !m.JSONPathKey("customers", "metadata") {
+		t.Errorf("expected the json path key of Customers")
+	}
+	if m.MaskFunction("customers", "email").Function != "keep_email_domain" {
+		t.Errorf("expected the mask function of Customers")
+	}
+	if _, ok := m.Generalization("customers", "created_at"); !ok {
+		t.Errorf("expected the generalization of Customers")
+	}
+}
+
+func TestRowFilterCompile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		filter RowFilter
+		err    bool
+	}{
+		{
+			name:   "test1: one predicate is valid",
+			filter: RowFilter{Column: "id", Equals: stringPtr("1")},
+			err:    false,
+		},
+		{
+			name:   "test2: no predicate is invalid",
+			filter: RowFilter{Column: "id"},
+			err:    true,
+		},
+		{
+			name: "test3: more than one predicate is invalid",
+			filter: RowFilter{
+				Column: "id", Equals: stringPtr("1"), In: []string{"1"},
+			},
+			err: true,
+		},
+		{
+			name:   "test4: invalid regex",
+			filter: RowFilter{Column: "id", Regex: stringPtr("(")},
+			err:    true,
+		},
+		{
+			name:   "test5: empty column is invalid",
+			filter: RowFilter{Equals: stringPtr("1")},
+			err:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.filter.compile()
+			if (err != nil) != tc.err {
+				t.Errorf("expected err: %v, got: %v\n", tc.err, err)
+			}
+		})
+	}
+}
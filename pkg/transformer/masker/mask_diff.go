@@ -15,6 +15,8 @@ type MaskDiffer struct {
 
 	// modified stores the list of tables that has been modified.
 	// i.e present in both current and desired but different
+	// The tables are the table keys of the config and can be patterns,
+	// use MatchKey to find the tables modified by them.
 	modified map[string]bool
 }
 
@@ -90,6 +92,92 @@ func (m *MaskDiffer) diffMapSlice(
 	}
 }
 
+// diffRowFilters marks the table modified if its filters were added,
+// changed or removed, as the rows sinked for the table change.
+func (m *MaskDiffer) diffRowFilters(
+	m1 map[string][]RowFilter, m2 map[string][]RowFilter) {
+
+	if reflect.DeepEqual(m1, m2) {
+		return
+	}
+
+	for _, tables := range []map[string][]RowFilter{m1, m2} {
+		for table := range tables {
+			if m.tableModified(table) {
+				continue
+			}
+			if !reflect.DeepEqual(m1[table], m2[table]) {
+				m.setModified(table)
+			}
+		}
+	}
+}
+
+// diffJSONPathKeys marks the table modified if its json paths were added,
+// changed or removed, as the values masked in the JSON columns change.
+func (m *MaskDiffer) diffJSONPathKeys(
+	m1 map[string][]string, m2 map[string][]string) {
+
+	if reflect.DeepEqual(m1, m2) {
+		return
+	}
+
+	for _, tables := range []map[string][]string{m1, m2} {
+		for table := range tables {
+			if m.tableModified(table) {
+				continue
+			}
+			if !reflect.DeepEqual(m1[table], m2[table]) {
+				m.setModified(table)
+			}
+		}
+	}
+}
+
+// diffMaskFunctions marks the table modified if its mask functions were
+// added, changed or removed.
+func (m *MaskDiffer) diffMaskFunctions(
+	m1 map[string]map[string]MaskFunction,
+	m2 map[string]map[string]MaskFunction) {
+
+	if reflect.DeepEqual(m1, m2) {
+		return
+	}
+
+	for _, tables := range []map[string]map[string]MaskFunction{m1, m2} {
+		for table := range tables {
+			if m.tableModified(table) {
+				continue
+			}
+			if !reflect.DeepEqual(m1[table], m2[table]) {
+				m.setModified(table)
+			}
+		}
+	}
+}
+
+// diffGeneralizeKeys marks the table modified if its generalizations were
+// added, changed or removed, as the extra columns of the table change.
+func (m *MaskDiffer) diffGeneralizeKeys(
+	m1 map[string]map[string]Generalization,
+	m2 map[string]map[string]Generalization) {
+
+	if reflect.DeepEqual(m1, m2) {
+		return
+	}
+
+	for _, tables := range []map[string]map[string]Generalization{m1, m2} {
+		for table := range tables {
+			if m.tableModified(table) {
+				continue
+			}
+			if !reflect.DeepEqual(m1[table], m2[table]) {
+				m.setModified(table)
+			}
+		}
+	}
+}
+
 // Diff does the diff between current and desired config and stores the result
 // in modified, removed and added.
 func (m *MaskDiffer) Diff() {
@@ -103,10 +191,15 @@ func (m *MaskDiffer) Diff() {
 	m.diffMapSlice(m.current.MappingPIIKeys, m.desired.MappingPIIKeys)
 	m.diffMapSlice(m.current.SortKeys, m.desired.SortKeys)
 	m.diffMapSlice(m.current.DistKeys, m.desired.DistKeys)
+	m.diffMapSlice(m.current.ExcludeColumns, m.desired.ExcludeColumns)
 	m.diffMapInterface(
 		m.current.ConditionalNonPiiKeys, m.desired.ConditionalNonPiiKeys)
 	m.diffMapInterface(
 		m.current.DependentNonPiiKeys, m.desired.DependentNonPiiKeys)
 	m.diffMapInterface(
 		m.current.RegexPatternBooleanKeys, m.desired.RegexPatternBooleanKeys)
+	m.diffMaskFunctions(m.current.MaskFunctions, m.desired.MaskFunctions)
+	m.diffRowFilters(m.current.RowFilters, m.desired.RowFilters)
+	m.diffJSONPathKeys(m.current.JSONPathKeys, m.desired.JSONPathKeys)
+	m.diffGeneralizeKeys(m.current.GeneralizeKeys, m.desired.GeneralizeKeys)
 }
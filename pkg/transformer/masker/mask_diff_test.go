@@ -42,9 +42,52 @@ func TestMaskDiff(t *testing.T) {
 		"justifications": true,
 		"establishments": true,
 		"customers":      true,
-		"addedNewTable":  true,
+		"addednewtable":  true,
+		"leads":          true,
+		"patients":       true,
+		"visits":         true,
 	}
 	if !reflect.DeepEqual(gotDiff, expected) {
 		t.Errorf("expected :%v, got: %+v", expected, gotDiff)
 	}
 }
+
+func TestMaskDiffPatternKeys(t *testing.T) {
+	current := MaskConfig{
+		NonPiiKeys: map[string][]string{
+			"*":        []string{"id"},
+			"orders_*": []string{"amount"},
+		},
+	}
+	desired := MaskConfig{
+		NonPiiKeys: map[string][]string{
+			"*":        []string{"id"},
+			"orders_*": []string{"amount", "currency"},
+		},
+	}
+
+	differ := NewMaskDiffer(current, desired)
+	differ.Diff()
+	gotDiff := differ.ModifiedTables()
+	expected := map[string]bool{
+		"orders_*": true,
+	}
+	if !reflect.DeepEqual(gotDiff, expected) {
+		t.Errorf("expected :%v, got: %+v", expected, gotDiff)
+	}
+
+	for table, modified := range map[string]bool{
+		"orders_2019": true,
+		"orders_2020": true,
+		"customers":   false,
+	} {
+		got := false
+		for key := range gotDiff {
+			got = got || MatchKey(key, table)
+		}
+		if got != modified {
+			t.Errorf("table: %s, expected modified: %v, got: %v",
+				table, modified, got)
+		}
+	}
+}
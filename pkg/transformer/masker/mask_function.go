@@ -0,0 +1,123 @@
+package masker
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"github.com/practo/tipoca-stream/pkg/redshift"
+	"github.com/practo/tipoca-stream/pkg/transformer"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	keepLastCharacters = 4
+	maskCharacter      = "*"
+)
+
+// MaskFunction specifies the function used to mask the column
+type MaskFunction struct {
+	Function string `yaml:"function"`
+	// Value is the constant used by the constant mask function
+	Value string `yaml:"value,omitempty"`
+}
+
+// validate validates the mask function, keyColumn tells if the column is
+// a sort key or dist key, they can not be masked to NULL
+func (f MaskFunction) validate(keyColumn bool) error {
+	if keyColumn && f.Function == transformer.MaskNull {
+		return fmt.Errorf(
+			"function: %s can not be used on a sort key or dist key",
+			transformer.MaskNull)
+	}
+
+	switch f.Function {
+	case transformer.MaskSHA1,
+		transformer.MaskHMACSHA256,
+		transformer.MaskHMACSHA256Table,
+		transformer.MaskKeepEmailDomain,
+		transformer.MaskKeepLast4,
+		transformer.MaskKeepLength,
+		transformer.MaskNull:
+		if f.Value != "" {
+			return fmt.Errorf(
+				"value is supported only for function: %s",
+				transformer.MaskConstant)
+		}
+	case transformer.MaskConstant:
+		if strings.TrimSpace(f.Value) == "" {
+			return fmt.Errorf("constant value is empty")
+		}
+		if len(f.Value) > redshift.RedshiftMaskedDataTypeLength {
+			return fmt.Errorf(
+				"constant value is longer than %d bytes",
+				redshift.RedshiftMaskedDataTypeLength)
+		}
+	default:
+		return fmt.Errorf("unsupported mask function: %s", f.Function)
+	}
+
+	return nil
+}
+
+// hmacSHA256 returns the hex of HMAC-SHA256 of the data
+func hmacSHA256(data string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+// tableKey derives the key for the table from the salt
+func tableKey(salt, database, table string) []byte {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(database + "." + table))
+
+	return mac.Sum(nil)
+}
+
+// keepEmailDomain masks the local part of the email and keeps the domain,
+// values which are not email are masked completely.
+func keepEmailDomain(data string, key []byte) string {
+	at := strings.LastIndex(data, "@")
+	if at == -1 {
+		return hmacSHA256(data, key)
+	}
+
+	return hmacSHA256(data[:at], key) + data[at:]
+}
+
+// keepLast replaces all but the last n characters with the mask character,
+// values having n or less characters are masked completely.
+func keepLast(data string, n int) string {
+	length := utf8.RuneCountInString(data)
+	if length <= n {
+		return strings.Repeat(maskCharacter, length)
+	}
+
+	runes := []rune(data)
+	return strings.Repeat(maskCharacter, length-n) + string(runes[length-n:])
+}
+
+// mask masks the value using the mask function of the column
+func (m *masker) mask(cName string, data string) *string {
+	function := m.config.MaskFunction(m.table, cName)
+	switch function.Function {
+	case transformer.MaskHMACSHA256:
+		return stringPtr(hmacSHA256(data, []byte(m.salt)))
+	case transformer.MaskHMACSHA256Table:
+		return stringPtr(hmacSHA256(data, m.tableKey))
+	case transformer.MaskKeepEmailDomain:
+		return stringPtr(keepEmailDomain(data, []byte(m.salt)))
+	case transformer.MaskKeepLast4:
+		return stringPtr(keepLast(data, keepLastCharacters))
+	case transformer.MaskKeepLength:
+		return stringPtr(keepLast(data, 0))
+	case transformer.MaskNull:
+		return nil
+	case transformer.MaskConstant:
+		return stringPtr(function.Value)
+	default:
+		return Mask(data, m.salt)
+	}
+}
@@ -0,0 +1,124 @@
+package masker
+
+import (
+	"testing"
+)
+
+func TestMaskFunctionValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		function  MaskFunction
+		keyColumn bool
+		err       bool
+	}{
+		{
+			name:     "test1: supported function",
+			function: MaskFunction{Function: "hmac_sha256"},
+			err:      false,
+		},
+		{
+			name:     "test2: unsupported function",
+			function: MaskFunction{Function: "md5"},
+			err:      true,
+		},
+		{
+			name:     "test3: constant with value",
+			function: MaskFunction{Function: "constant", Value: "REDACTED"},
+			err:      false,
+		},
+		{
+			name:     "test4: constant without value",
+			function: MaskFunction{Function: "constant"},
+			err:      true,
+		},
+		{
+			name: "test5: constant longer than masked type",
+			function: MaskFunction{
+				Function: "constant",
+				Value:    "this constant is longer than the fifty bytes allowed",
+			},
+			err: true,
+		},
+		{
+			name:     "test6: value for non constant function",
+			function: MaskFunction{Function: "null", Value: "REDACTED"},
+			err:      true,
+		},
+		{
+			name:      "test7: null on a key column",
+			function:  MaskFunction{Function: "null"},
+			keyColumn: true,
+			err:       true,
+		},
+		{
+			name:      "test8: non null function on a key column",
+			function:  MaskFunction{Function: "keep_last4"},
+			keyColumn: true,
+			err:       false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.function.validate(tc.keyColumn)
+			if (err != nil) != tc.err {
+				t.Errorf("expected err: %v, got: %v\n", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		data   string
+		n      int
+		result string
+	}{
+		{
+			name:   "test1: keeps last 4",
+			data:   "9876543210",
+			n:      4,
+			result: "******3210",
+		},
+		{
+			name:   "test2: short value is masked completely",
+			data:   "3210",
+			n:      4,
+			result: "****",
+		},
+		{
+			name:   "test3: multi byte characters",
+			data:   "नमस्ते दुनिया",
+			n:      4,
+			result: "*********निया",
+		},
+		{
+			name:   "test4: keep length",
+			data:   "Batman",
+			n:      0,
+			result: "******",
+		},
+		{
+			name:   "test5: empty value",
+			data:   "",
+			n:      0,
+			result: "",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			result := keepLast(tc.data, tc.n)
+			if result != tc.result {
+				t.Errorf("expected: %v, got: %v\n", tc.result, result)
+			}
+		})
+	}
+}
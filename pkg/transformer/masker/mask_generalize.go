@@ -0,0 +1,223 @@
+package masker
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/practo/tipoca-stream/pkg/redshift"
+	"github.com/practo/tipoca-stream/pkg/transformer"
+)
+
+// Debezium temporal logical types of the date columns, masked columns
+// reach the masker with the raw debezium values.
+const (
+	debeziumDate           = "io.debezium.time.Date"
+	debeziumYear           = "io.debezium.time.Year"
+	debeziumTimestamp      = "io.debezium.time.Timestamp"
+	debeziumMicroTimestamp = "io.debezium.time.MicroTimestamp"
+	debeziumNanoTimestamp  = "io.debezium.time.NanoTimestamp"
+	connectDate            = "org.apache.kafka.connect.data.Date"
+	connectTimestamp       = "org.apache.kafka.connect.data.Timestamp"
+)
+
+var (
+	// generalizeSizeRegex is a positive decimal number like 10 or 0.5
+	generalizeSizeRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+	// formattedDateRegex is the prefix of the formatted dates and timestamps
+	formattedDateRegex = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}`)
+)
+
+// Generalization specifies the approximate value of the column kept in the
+// extra column <column>_<function>.
+type Generalization struct {
+	Function string `yaml:"function"`
+	// Size is the width of the buckets of bucket and the multiple
+	// rounded to by round
+	Size string `yaml:"size,omitempty"`
+	// Decimals are the decimal places kept by truncate
+	Decimals int `yaml:"decimals,omitempty"`
+
+	// size and scale are derived from Size and Decimals at load
+	size  *big.Rat
+	scale int
+}
+
+// compile validates the generalization and parses its size
+func (g *Generalization) compile() error {
+	switch g.Function {
+	case transformer.GeneralizeYear, transformer.GeneralizeMonth:
+		if g.Size != "" || g.Decimals != 0 {
+			return fmt.Errorf(
+				"size and decimals are not supported by function: %s",
+				g.Function)
+		}
+	case transformer.GeneralizeBucket, transformer.GeneralizeRound:
+		if g.Decimals != 0 {
+			return fmt.Errorf(
+				"decimals are not supported by function: %s", g.Function)
+		}
+		if !generalizeSizeRegex.MatchString(g.Size) {
+			return fmt.Errorf(
+				"size: %q of function: %s is not a positive number",
+				g.Size, g.Function)
+		}
+		g.size, _ = new(big.Rat).SetString(g.Size)
+		if g.size.Sign() <= 0 {
+			return fmt.Errorf(
+				"size: %q of function: %s is not a positive number",
+				g.Size, g.Function)
+		}
+		if i := strings.Index(g.Size, "."); i != -1 {
+			g.scale = len(g.Size) - i - 1
+		}
+	case transformer.GeneralizeTruncate:
+		if g.Size != "" {
+			return fmt.Errorf(
+				"size is not supported by function: %s", g.Function)
+		}
+		g.scale = g.Decimals
+	default:
+		return fmt.Errorf("unsupported generalize function: %s", g.Function)
+	}
+
+	if g.scale < 0 || g.scale > redshift.RedshiftNumericMaxScale {
+		return fmt.Errorf(
+			"decimals: %d of function: %s should be between 0 and %d",
+			g.scale, g.Function, redshift.RedshiftNumericMaxScale)
+	}
+
+	return nil
+}
+
+// ColumnName is the name of the extra column keeping the generalized value
+func (g Generalization) ColumnName(cName string) string {
+	return strings.ToLower(cName + "_" + g.Function)
+}
+
+// ColumnType is the redshift type of the extra column, dates stay dates
+// and numbers are kept as exact numerics with the scale of the function.
+func (g Generalization) ColumnType() string {
+	switch g.Function {
+	case transformer.GeneralizeYear, transformer.GeneralizeMonth:
+		return redshift.RedshiftDate
+	}
+
+	return fmt.Sprintf(
+		"%s(%d,%d)",
+		redshift.RedshiftNumeric, redshift.RedshiftNumericMaxLength, g.scale)
+}
+
+// Generalize returns the generalized value of the column, nil is returned
+// for the values which are not dates or numbers as per the function.
+func (g Generalization) Generalize(value string, column redshift.ColInfo) *string {
+	value = strings.TrimSpace(value)
+
+	switch g.Function {
+	case transformer.GeneralizeYear, transformer.GeneralizeMonth:
+		date, ok := parseDate(value, column)
+		if !ok {
+			return nil
+		}
+		month := time.January
+		if g.Function == transformer.GeneralizeMonth {
+			month = date.Month()
+		}
+		return stringPtr(
+			time.Date(date.Year(), month, 1, 0, 0, 0, 0, time.UTC).
+				Format("2006-01-02"))
+	}
+
+	number, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil
+	}
+
+	var generalized *big.Rat
+	switch g.Function {
+	case transformer.GeneralizeBucket:
+		quotient := new(big.Rat).Quo(number, g.size)
+		generalized = new(big.Rat).SetInt(floor(quotient))
+		generalized.Mul(generalized, g.size)
+	case transformer.GeneralizeRound:
+		// half is rounded away from zero
+		quotient := new(big.Rat).Quo(number, g.size)
+		negative := quotient.Sign() < 0
+		quotient.Abs(quotient)
+		quotient.Add(quotient, big.NewRat(1, 2))
+		rounded := floor(quotient)
+		if negative {
+			rounded.Neg(rounded)
+		}
+		generalized = new(big.Rat).SetInt(rounded)
+		generalized.Mul(generalized, g.size)
+	case transformer.GeneralizeTruncate:
+		unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(g.scale)), nil)
+		shifted := new(big.Rat).Mul(number, new(big.Rat).SetInt(unit))
+		truncated := new(big.Int).Quo(shifted.Num(), shifted.Denom())
+		generalized = new(big.Rat).SetFrac(truncated, unit)
+	}
+
+	return stringPtr(generalized.FloatString(g.scale))
+}
+
+// floor returns the largest integer less than or equal to r
+func floor(r *big.Rat) *big.Int {
+	// Div is euclidean, the denominator of a Rat is always positive
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+// parseDate parses the date of the formatted or the raw debezium value.
+// Raw timestamps are read in the timezone of the source.
+func parseDate(value string, column redshift.ColInfo) (time.Time, bool) {
+	if formattedDateRegex.MatchString(value) {
+		date, err := time.Parse("2006-01-02", value[:10])
+		return date, err == nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	logicalType := column.LogicalType
+	if logicalType == "" {
+		// logical type is not known, use the source type
+		switch column.SourceType.ColumnType {
+		case "DATE":
+			logicalType = debeziumDate
+		case "YEAR":
+			logicalType = debeziumYear
+		}
+	}
+
+	switch logicalType {
+	case debeziumDate, connectDate:
+		return time.Unix(0, 0).UTC().AddDate(0, 0, int(n)), true
+	case debeziumYear:
+		return time.Date(int(n), time.January, 1, 0, 0, 0, 0, time.UTC), true
+	case debeziumTimestamp, connectTimestamp:
+		return time.UnixMilli(n).UTC(), true
+	case debeziumMicroTimestamp:
+		return time.UnixMicro(n).UTC(), true
+	case debeziumNanoTimestamp:
+		return time.Unix(0, n).UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+// Generalization returns the generalization of the column if the column
+// is listed in the generalize keys.
+func (m MaskConfig) Generalization(table, cName string) (Generalization, bool) {
+	tableKey, columnKey, ok := m.columnConfigKeys(
+		generalizeKeysSection, table, cName)
+	if !ok {
+		return Generalization{}, false
+	}
+
+	return m.GeneralizeKeys[tableKey][columnKey], true
+}
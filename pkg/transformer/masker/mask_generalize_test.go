@@ -0,0 +1,227 @@
+package masker
+
+import (
+	"testing"
+
+	"github.com/practo/tipoca-stream/pkg/redshift"
+)
+
+func TestGeneralize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		generalization Generalization
+		column         redshift.ColInfo
+		value          string
+		columnType     string
+		result         *string
+	}{
+		{
+			name:           "year of formatted date",
+			generalization: Generalization{Function: "year"},
+			value:          "1988-09-21",
+			columnType:     "date",
+			result:         stringPtr("1988-01-01"),
+		},
+		{
+			name:           "month of zoned timestamp",
+			generalization: Generalization{Function: "month"},
+			value:          "2021-03-31T23:59:59Z",
+			columnType:     "date",
+			result:         stringPtr("2021-03-01"),
+		},
+		{
+			name:           "month of raw date before epoch",
+			generalization: Generalization{Function: "month"},
+			column:         redshift.ColInfo{LogicalType: debeziumDate},
+			value:          "-1",
+			columnType:     "date",
+			result:         stringPtr("1969-12-01"),
+		},
+		{
+			name:           "year of raw micro timestamp",
+			generalization: Generalization{Function: "year"},
+			column:         redshift.ColInfo{LogicalType: debeziumMicroTimestamp},
+			value:          "1609459200000000",
+			columnType:     "date",
+			result:         stringPtr("2021-01-01"),
+		},
+		{
+			name:           "year of raw year",
+			generalization: Generalization{Function: "year"},
+			column: redshift.ColInfo{
+				SourceType: redshift.SourceType{ColumnType: "YEAR"},
+			},
+			value:      "1999",
+			columnType: "date",
+			result:     stringPtr("1999-01-01"),
+		},
+		{
+			name:           "raw value of unknown type",
+			generalization: Generalization{Function: "year"},
+			value:          "1999",
+			columnType:     "date",
+			result:         nil,
+		},
+		{
+			name:           "date of not a date",
+			generalization: Generalization{Function: "year"},
+			value:          "yesterday",
+			columnType:     "date",
+			result:         nil,
+		},
+		{
+			name:           "bucket",
+			generalization: Generalization{Function: "bucket", Size: "10"},
+			value:          "39",
+			columnType:     "numeric(38,0)",
+			result:         stringPtr("30"),
+		},
+		{
+			name:           "bucket of negative",
+			generalization: Generalization{Function: "bucket", Size: "10"},
+			value:          "-1",
+			columnType:     "numeric(38,0)",
+			result:         stringPtr("-10"),
+		},
+		{
+			name:           "bucket of decimal size is exact",
+			generalization: Generalization{Function: "bucket", Size: "0.1"},
+			value:          "0.3",
+			columnType:     "numeric(38,1)",
+			result:         stringPtr("0.3"),
+		},
+		{
+			name:           "round half away from zero",
+			generalization: Generalization{Function: "round", Size: "1000"},
+			value:          "123500",
+			columnType:     "numeric(38,0)",
+			result:         stringPtr("124000"),
+		},
+		{
+			name:           "round negative half away from zero",
+			generalization: Generalization{Function: "round", Size: "1000"},
+			value:          "-123500.00",
+			columnType:     "numeric(38,0)",
+			result:         stringPtr("-124000"),
+		},
+		{
+			name:           "round to decimal size",
+			generalization: Generalization{Function: "round", Size: "0.25"},
+			value:          "10.6",
+			columnType:     "numeric(38,2)",
+			result:         stringPtr("10.50"),
+		},
+		{
+			name:           "truncate",
+			generalization: Generalization{Function: "truncate", Decimals: 2},
+			value:          "12.97923",
+			columnType:     "numeric(38,2)",
+			result:         stringPtr("12.97"),
+		},
+		{
+			name:           "truncate negative towards zero",
+			generalization: Generalization{Function: "truncate", Decimals: 2},
+			value:          "-77.59499",
+			columnType:     "numeric(38,2)",
+			result:         stringPtr("-77.59"),
+		},
+		{
+			name:           "truncate to integer",
+			generalization: Generalization{Function: "truncate"},
+			value:          "12.97923",
+			columnType:     "numeric(38,0)",
+			result:         stringPtr("12"),
+		},
+		{
+			name:           "number of not a number",
+			generalization: Generalization{Function: "bucket", Size: "10"},
+			value:          "ten",
+			columnType:     "numeric(38,0)",
+			result:         nil,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.generalization.compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			columnType := tc.generalization.ColumnType()
+			if columnType != tc.columnType {
+				t.Errorf("expected type: %v, got: %v\n", tc.columnType, columnType)
+			}
+			result := tc.generalization.Generalize(tc.value, tc.column)
+			if result == nil || tc.result == nil {
+				if result != tc.result {
+					t.Errorf("expected: %v, got: %v\n", tc.result, result)
+				}
+				return
+			}
+			if *result != *tc.result {
+				t.Errorf("expected: %v, got: %v\n", *tc.result, *result)
+			}
+		})
+	}
+}
+
+func TestGeneralizationErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		generalization Generalization
+	}{
+		{
+			name:           "unknown function",
+			generalization: Generalization{Function: "decade"},
+		},
+		{
+			name:           "size for a date",
+			generalization: Generalization{Function: "year", Size: "10"},
+		},
+		{
+			name:           "bucket without size",
+			generalization: Generalization{Function: "bucket"},
+		},
+		{
+			name:           "zero size",
+			generalization: Generalization{Function: "round", Size: "0.0"},
+		},
+		{
+			name:           "negative size",
+			generalization: Generalization{Function: "round", Size: "-10"},
+		},
+		{
+			name:           "fraction size",
+			generalization: Generalization{Function: "bucket", Size: "1/3"},
+		},
+		{
+			name:           "truncate with size",
+			generalization: Generalization{Function: "truncate", Size: "10"},
+		},
+		{
+			name:           "negative decimals",
+			generalization: Generalization{Function: "truncate", Decimals: -1},
+		},
+		{
+			name:           "too many decimals",
+			generalization: Generalization{Function: "truncate", Decimals: 38},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.generalization.compile()
+			if err == nil {
+				t.Errorf("expected error for: %+v\n", tc.generalization)
+			}
+		})
+	}
+}
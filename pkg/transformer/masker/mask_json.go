@@ -0,0 +1,214 @@
+package masker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// json_path_keys keeps the JSON columns unmasked and masks only the values
+// at the paths. A path is the column followed by the JSONPath of the value:
+//
+//	metadata.$.address.phone    key phone of the object address
+//	metadata.$.phones[*]        all the items of the array phones
+//	metadata.$.documents[0].id  key id of the first item of documents
+//	metadata.$.*.phone          key phone of all the objects in metadata
+const (
+	jsonPathRoot     = "$"
+	jsonPathWildcard = "*"
+)
+
+// jsonPathStep is a key of an object or an index of an array
+type jsonPathStep struct {
+	key string
+	// index is -1 for all the items of the array
+	index int
+	array bool
+}
+
+type jsonPath []jsonPathStep
+
+// splitJSONPathKey splits the key into the column and its path
+func splitJSONPathKey(key string) (string, string, error) {
+	i := strings.Index(key, "."+jsonPathRoot)
+	if i <= 0 {
+		return "", "", fmt.Errorf(
+			"json path key: %s, expected column.$.path", key)
+	}
+
+	return lowerKey(key[:i]), key[i+1:], nil
+}
+
+// parseJSONPath parses the JSONPath, only the child and the index
+// operators are supported.
+func parseJSONPath(raw string) (jsonPath, error) {
+	if !strings.HasPrefix(raw, jsonPathRoot) {
+		return nil, fmt.Errorf("json path: %s does not start with $", raw)
+	}
+
+	var path jsonPath
+	rest := raw[len(jsonPathRoot):]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("json path: %s has an empty key", raw)
+			}
+			path = append(path, jsonPathStep{key: key})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("json path: %s has unclosed [", raw)
+			}
+			index := rest[1:end]
+			if index == jsonPathWildcard {
+				path = append(path, jsonPathStep{index: -1, array: true})
+			} else {
+				i, err := strconv.Atoi(index)
+				if err != nil || i < 0 {
+					return nil, fmt.Errorf(
+						"json path: %s has invalid index: %s", raw, index)
+				}
+				path = append(path, jsonPathStep{index: i, array: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("json path: %s is not valid at: %s", raw, rest)
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("json path: %s masks the whole column", raw)
+	}
+
+	return path, nil
+}
+
+// compileJSONPaths parses the json path keys of every table into the
+// paths of the columns.
+func (m *MaskConfig) compileJSONPaths() error {
+	m.jsonPaths = make(map[string]map[string][]jsonPath)
+	m.jsonPathColumns = make(map[string][]string)
+
+	for table, keys := range m.JSONPathKeys {
+		paths := make(map[string][]jsonPath)
+		for _, key := range keys {
+			column, rawPath, err := splitJSONPathKey(key)
+			if err != nil {
+				return fmt.Errorf("%s: table: %s, %v", jsonPathKeysSection, table, err)
+			}
+			path, err := parseJSONPath(rawPath)
+			if err != nil {
+				return fmt.Errorf("%s: table: %s, %v", jsonPathKeysSection, table, err)
+			}
+			if _, ok := paths[column]; !ok {
+				m.jsonPathColumns[table] = append(m.jsonPathColumns[table], column)
+			}
+			paths[column] = append(paths[column], path)
+		}
+		m.jsonPaths[table] = paths
+	}
+
+	return nil
+}
+
+// columnJSONPaths returns the paths to mask in the JSON column, paths of
+// all the table keys matching the table are used.
+func (m MaskConfig) columnJSONPaths(table, cName string) []jsonPath {
+	var paths []jsonPath
+	for _, tableKey := range m.matchingTables(jsonPathKeysSection, table) {
+		columnKey, ok := m.matchingColumn(jsonPathKeysSection, tableKey, cName)
+		if ok {
+			paths = append(paths, m.jsonPaths[tableKey][columnKey]...)
+		}
+	}
+
+	return paths
+}
+
+func (m MaskConfig) JSONPathKey(table, cName string) bool {
+	return m.hasColumn(jsonPathKeysSection, table, cName)
+}
+
+// maskJSONPaths replaces the values at the path in the document with the
+// values returned by mask
+func maskJSONPaths(document interface{}, path jsonPath,
+	mask func(value interface{}) interface{}) interface{} {
+
+	if len(path) == 0 {
+		return mask(document)
+	}
+
+	step, rest := path[0], path[1:]
+	switch node := document.(type) {
+	case map[string]interface{}:
+		if step.array {
+			return node
+		}
+		for key, value := range node {
+			if step.key == jsonPathWildcard || step.key == key {
+				node[key] = maskJSONPaths(value, rest, mask)
+			}
+		}
+	case []interface{}:
+		if !step.array {
+			return node
+		}
+		for i, value := range node {
+			if step.index == -1 || step.index == i {
+				node[i] = maskJSONPaths(value, rest, mask)
+			}
+		}
+	}
+
+	return document
+}
+
+// maskJSON masks the values at the paths of the JSON column using the mask
+// function of the column. Values which are not JSON documents are masked
+// completely as their PII can not be located.
+func (m *masker) maskJSON(cName string, data string, paths []jsonPath) *string {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	err := decoder.Decode(&document)
+	if err != nil || decoder.More() {
+		return m.mask(cName, data)
+	}
+
+	mask := func(value interface{}) interface{} {
+		if value == nil {
+			return nil
+		}
+		raw, ok := value.(string)
+		if !ok {
+			encoded, _ := json.Marshal(value)
+			raw = string(encoded)
+		}
+		masked := m.mask(cName, raw)
+		if masked == nil {
+			return nil
+		}
+		return *masked
+	}
+	for _, path := range paths {
+		document = maskJSONPaths(document, path, mask)
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(document)
+	if err != nil {
+		return m.mask(cName, data)
+	}
+
+	return stringPtr(strings.TrimSuffix(buffer.String(), "\n"))
+}
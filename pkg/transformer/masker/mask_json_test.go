@@ -0,0 +1,141 @@
+package masker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPathKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		key    string
+		column string
+		path   jsonPath
+		err    bool
+	}{
+		{
+			name:   "object keys",
+			key:    "Metadata.$.address.phone",
+			column: "metadata",
+			path: jsonPath{
+				jsonPathStep{key: "address"},
+				jsonPathStep{key: "phone"},
+			},
+		},
+		{
+			name:   "array items",
+			key:    "metadata.$.documents[*].number",
+			column: "metadata",
+			path: jsonPath{
+				jsonPathStep{key: "documents"},
+				jsonPathStep{index: -1, array: true},
+				jsonPathStep{key: "number"},
+			},
+		},
+		{
+			name:   "array index and wildcard key",
+			key:    "metadata.$.*.phones[1]",
+			column: "metadata",
+			path: jsonPath{
+				jsonPathStep{key: "*"},
+				jsonPathStep{key: "phones"},
+				jsonPathStep{index: 1, array: true},
+			},
+		},
+		{
+			name: "whole column",
+			key:  "metadata.$",
+			err:  true,
+		},
+		{
+			name: "no column",
+			key:  "$.address",
+			err:  true,
+		},
+		{
+			name: "no path",
+			key:  "metadata.address",
+			err:  true,
+		},
+		{
+			name: "invalid index",
+			key:  "metadata.$.phones[-1]",
+			err:  true,
+		},
+		{
+			name: "unclosed index",
+			key:  "metadata.$.phones[1",
+			err:  true,
+		},
+		{
+			name: "empty key",
+			key:  "metadata.$.address..phone",
+			err:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			column, rawPath, err := splitJSONPathKey(tc.key)
+			var path jsonPath
+			if err == nil {
+				path, err = parseJSONPath(rawPath)
+			}
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got path: %+v", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if column != tc.column {
+				t.Errorf("expected column: %v, got: %v", tc.column, column)
+			}
+			if !reflect.DeepEqual(path, tc.path) {
+				t.Errorf("expected path: %+v, got: %+v", tc.path, path)
+			}
+		})
+	}
+}
+
+func TestJSONPathKeysPatterns(t *testing.T) {
+	t.Parallel()
+
+	m := MaskConfig{
+		JSONPathKeys: map[string][]string{
+			"*":        []string{"metadata.$.phone"},
+			"orders_*": []string{"metadata.$.address", "extra.$.email"},
+		},
+	}
+	err := m.prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		table  string
+		column string
+		paths  int
+	}{
+		{table: "orders_2020", column: "metadata", paths: 2},
+		{table: "orders_2020", column: "extra", paths: 1},
+		{table: "customers", column: "metadata", paths: 1},
+		{table: "customers", column: "extra", paths: 0},
+	} {
+		paths := m.columnJSONPaths(tc.table, tc.column)
+		if len(paths) != tc.paths {
+			t.Errorf("table: %s, column: %s, expected paths: %d, got: %+v",
+				tc.table, tc.column, tc.paths, paths)
+		}
+		if m.JSONPathKey(tc.table, tc.column) != (tc.paths > 0) {
+			t.Errorf("table: %s, column: %s, expected json path key: %v",
+				tc.table, tc.column, tc.paths > 0)
+		}
+	}
+}
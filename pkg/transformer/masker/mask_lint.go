@@ -0,0 +1,272 @@
+package masker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/practo/tipoca-stream/pkg/masksource"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// LintError makes the mask file unusable
+	LintError = "error"
+	// LintWarning is a likely mistake in the mask file
+	LintWarning = "warning"
+)
+
+// LintProblem is a problem found in the mask file by Lint
+type LintProblem struct {
+	Severity string
+	Section  string
+	Table    string
+	Column   string
+	Message  string
+}
+
+func (p LintProblem) Error() string {
+	message := p.Severity + ":"
+	if p.Section != "" {
+		message += " " + p.Section + ":"
+	}
+	if p.Table != "" {
+		message += " table: " + p.Table + ","
+	}
+	if p.Column != "" {
+		message += " column: " + p.Column + ","
+	}
+
+	return message + " " + p.Message
+}
+
+// HasLintErrors tells if any of the problems is an error
+func HasLintErrors(problems []LintProblem) bool {
+	for _, problem := range problems {
+		if problem.Severity == LintError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conflictingSections are the sections which should not list the
+// same column of a table
+var conflictingSections = []struct {
+	section1 string
+	section2 string
+	severity string
+	message  string
+}{
+	{
+		section1: nonPiiKeysSection,
+		section2: excludeColumnsSection,
+		severity: LintError,
+		message:  "column is unmasked and excluded",
+	},
+	{
+		section1: nonPiiKeysSection,
+		section2: conditionalNonPiiKeysSection,
+		severity: LintWarning,
+		message:  "column is unmasked, condition is never used",
+	},
+	{
+		section1: nonPiiKeysSection,
+		section2: dependentNonPiiKeysSection,
+		severity: LintWarning,
+		message:  "column is unmasked, dependency is never used",
+	},
+	{
+		section1: nonPiiKeysSection,
+		section2: maskFunctionsSection,
+		severity: LintWarning,
+		message:  "column is unmasked, mask function is never used",
+	},
+	{
+		section1: excludeColumnsSection,
+		section2: jsonPathKeysSection,
+		severity: LintWarning,
+		message:  "column is excluded, json paths are never used",
+	},
+	{
+		section1: excludeColumnsSection,
+		section2: sortKeysSection,
+		severity: LintError,
+		message:  "excluded column cannot be a sort key",
+	},
+	{
+		section1: excludeColumnsSection,
+		section2: distKeysSection,
+		severity: LintError,
+		message:  "excluded column cannot be a dist key",
+	},
+	{
+		section1: excludeColumnsSection,
+		section2: maskFunctionsSection,
+		severity: LintWarning,
+		message:  "column is excluded, mask function is never used",
+	},
+}
+
+// Lint validates the mask file strictly and returns the problems found.
+// Unknown sections and fields are errors. When tables are given, they are
+// the tables present in Kafka and the table keys of the mask file are
+// checked against them.
+func Lint(data []byte, tables []string) []LintProblem {
+	var m MaskConfig
+	err := yaml.UnmarshalStrict(data, &m)
+	if err != nil {
+		return []LintProblem{{Severity: LintError, Message: err.Error()}}
+	}
+	err = m.prepare()
+	if err != nil {
+		return []LintProblem{{Severity: LintError, Message: err.Error()}}
+	}
+
+	var problems []LintProblem
+	problems = append(problems, m.lintConflicts()...)
+	problems = append(problems, m.lintDistKeys()...)
+	if len(tables) > 0 {
+		problems = append(problems, m.lintTables(tables)...)
+	}
+
+	return problems
+}
+
+// LintMaskFile downloads the mask file at the version and lints it
+func LintMaskFile(
+	homeDir string,
+	maskFile string,
+	maskFileVersion string,
+	gitToken string,
+	tables []string) ([]LintProblem, error) {
+
+	source, err := masksource.New(
+		maskFile, masksource.Config{GitAccessToken: gitToken})
+	if err != nil {
+		return nil, err
+	}
+
+	return LintMaskSource(homeDir, source, maskFileVersion, tables)
+}
+
+// LintMaskSource downloads the mask file of the source at the version
+// and lints it
+func LintMaskSource(
+	homeDir string,
+	source masksource.Source,
+	maskFileVersion string,
+	tables []string) ([]LintProblem, error) {
+
+	configFilePath, err := source.Download(homeDir, maskFileVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Unable to read file: %s, err: %v", configFilePath, err)
+	}
+
+	return Lint(data, tables), nil
+}
+
+// sortedTableKeys returns the table keys of the section in a stable
+// order for the problems to be reported in the same order every time.
+func (m MaskConfig) sortedTableKeys(section string) []string {
+	tables := append([]string{}, m.tableKeys[section]...)
+	sort.Strings(tables)
+
+	return tables
+}
+
+func (m MaskConfig) lintConflicts() []LintProblem {
+	var problems []LintProblem
+	for _, conflict := range conflictingSections {
+		for _, table := range m.sortedTableKeys(conflict.section1) {
+			columns2 := toSet(m.columnKeys[conflict.section2+"/"+table])
+			for _, column := range m.columnKeys[conflict.section1+"/"+table] {
+				if !columns2[column] {
+					continue
+				}
+				problems = append(problems, LintProblem{
+					Severity: conflict.severity,
+					Section:  conflict.section1 + "," + conflict.section2,
+					Table:    table,
+					Column:   column,
+					Message:  conflict.message,
+				})
+			}
+		}
+	}
+
+	return problems
+}
+
+func (m MaskConfig) lintDistKeys() []LintProblem {
+	var problems []LintProblem
+	for _, table := range m.sortedTableKeys(distKeysSection) {
+		if len(m.DistKeys[table]) > 1 {
+			problems = append(problems, LintProblem{
+				Severity: LintError,
+				Section:  distKeysSection,
+				Table:    table,
+				Message:  "redshift supports only one dist key per table",
+			})
+		}
+	}
+
+	return problems
+}
+
+func (m MaskConfig) lintTables(tables []string) []LintProblem {
+	var problems []LintProblem
+
+	sections := []string{}
+	for section := range m.tableKeys {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	lint := func(section, key string) {
+		for _, table := range tables {
+			if m.match(key, table) {
+				return
+			}
+		}
+		message := "table not found in kafka topics"
+		if isPatternKey(key) {
+			message = "pattern matches no table in kafka topics"
+		}
+		problems = append(problems, LintProblem{
+			Severity: LintWarning,
+			Section:  section,
+			Table:    key,
+			Message:  message,
+		})
+	}
+
+	for _, section := range sections {
+		for _, key := range m.sortedTableKeys(section) {
+			lint(section, key)
+		}
+	}
+	if m.IncludeTables != nil {
+		for _, key := range *m.IncludeTables {
+			lint("include_tables", key)
+		}
+	}
+
+	return problems
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range items {
+		set[item] = true
+	}
+
+	return set
+}
@@ -0,0 +1,177 @@
+package masker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		config   string
+		tables   []string
+		problems []string
+		errors   bool
+	}{
+		{
+			name: "test1: valid config has no problems",
+			config: `
+non_pii_keys:
+  customers:
+    - id
+sort_keys:
+  customers:
+    - id
+`,
+			tables:   []string{"customers"},
+			problems: nil,
+			errors:   false,
+		},
+		{
+			name: "test2: unknown section is an error",
+			config: `
+non_pi_keys:
+  customers:
+    - id
+`,
+			problems: []string{
+				"error: yaml: unmarshal errors:\n  line 2: field non_pi_keys not found in type masker.MaskConfig",
+			},
+			errors: true,
+		},
+		{
+			name: "test3: bad regex of boolean keys is an error",
+			config: `
+regex_pattern_boolean_keys:
+  customers:
+    notes:
+      has_url: '(http'
+`,
+			problems: []string{
+				"error: regex_pattern_boolean_keys: table: customers, column: notes, regex: has_url, err: error parsing regexp: missing closing ): `(?i)(http`",
+			},
+			errors: true,
+		},
+		{
+			name: "test4: conditional keys must be a list or a condition",
+			config: `
+conditional_non_pii_keys:
+  customers:
+    type: 1
+`,
+			problems: []string{
+				"error: conditional_non_pii_keys: table: customers, column: type, expected list of patterns or a condition",
+			},
+			errors: true,
+		},
+		{
+			name: "test4.1: conditions must be valid",
+			config: `
+dependent_non_pii_keys:
+  customers:
+    name: "type = 'public' AND"
+`,
+			problems: []string{
+				`error: dependent_non_pii_keys: table: customers, column: name, condition: "type = 'public' AND", expected column, got: ""`,
+			},
+			errors: true,
+		},
+		{
+			name: "test5: conflicting sections",
+			config: `
+non_pii_keys:
+  customers:
+    - id
+    - email
+exclude_columns:
+  customers:
+    - email
+    - created_at
+sort_keys:
+  customers:
+    - created_at
+mask_functions:
+  customers:
+    id:
+      function: keep_length
+`,
+			problems: []string{
+				"error: non_pii_keys,exclude_columns: table: customers, column: email, column is unmasked and excluded",
+				"warning: non_pii_keys,mask_functions: table: customers, column: id, column is unmasked, mask function is never used",
+				"error: exclude_columns,sort_keys: table: customers, column: created_at, excluded column cannot be a sort key",
+			},
+			errors: true,
+		},
+		{
+			name: "test6: more than one dist key",
+			config: `
+dist_keys:
+  customers:
+    - id
+    - account_id
+`,
+			problems: []string{
+				"error: dist_keys: table: customers, redshift supports only one dist key per table",
+			},
+			errors: true,
+		},
+		{
+			name: "test7: tables not in kafka are warnings",
+			config: `
+non_pii_keys:
+  customers:
+    - id
+  orders_*:
+    - id
+  leads:
+    - id
+include_tables:
+  - customers
+  - /^payments_[0-9]+$/
+`,
+			tables: []string{"customers", "orders_2020"},
+			problems: []string{
+				"warning: non_pii_keys: table: leads, table not found in kafka topics",
+				"warning: include_tables: table: /^payments_[0-9]+$/, pattern matches no table in kafka topics",
+			},
+			errors: false,
+		},
+		{
+			name: "test8: null mask function on a sort key is an error",
+			config: `
+sort_keys:
+  customers:
+    - Email
+mask_functions:
+  customers:
+    email:
+      function: "null"
+`,
+			problems: []string{
+				"error: table: customers, column: email, function: null can not be used on a sort key or dist key",
+			},
+			errors: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			problems := Lint([]byte(tc.config), tc.tables)
+			var got []string
+			for _, problem := range problems {
+				got = append(got, problem.Error())
+			}
+			if !reflect.DeepEqual(got, tc.problems) {
+				t.Errorf("expected: %q, got: %q\n", tc.problems, got)
+			}
+			if HasLintErrors(problems) != tc.errors {
+				t.Errorf("expected errors: %v, got: %v\n",
+					tc.errors, HasLintErrors(problems))
+			}
+		})
+	}
+}
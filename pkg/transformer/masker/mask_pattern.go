@@ -0,0 +1,413 @@
+package masker
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Table and column keys in the mask configuration can be patterns:
+//
+//	exact: orders
+//	glob:  orders_*, matched using path.Match
+//	regex: /^orders_[0-9]{4}$/, the regex is enclosed in slashes
+//	all:   *, matches every table
+//
+// When many table keys match a table, the keys are used in the order of
+// precedence: exact, regex, glob and then all. Keys of the same kind are
+// ordered lexically.
+const allKey = "*"
+
+const (
+	exactKeyRank = iota
+	regexKeyRank
+	globKeyRank
+	allKeyRank
+)
+
+func isRegexKey(key string) bool {
+	return len(key) > 2 &&
+		strings.HasPrefix(key, "/") &&
+		strings.HasSuffix(key, "/")
+}
+
+func isGlobKey(key string) bool {
+	return !isRegexKey(key) && strings.ContainsAny(key, "*?[")
+}
+
+// isPatternKey tells if the key can match more than one name
+func isPatternKey(key string) bool {
+	return isRegexKey(key) || isGlobKey(key)
+}
+
+func keyRank(key string) int {
+	switch {
+	case key == allKey:
+		return allKeyRank
+	case isRegexKey(key):
+		return regexKeyRank
+	case isGlobKey(key):
+		return globKeyRank
+	default:
+		return exactKeyRank
+	}
+}
+
+// sortKeys sorts the keys in the order of precedence
+func sortKeys(keys []string) []string {
+	sort.SliceStable(keys, func(i, j int) bool {
+		ri, rj := keyRank(keys[i]), keyRank(keys[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// compileKey validates the key and returns the regex for regex keys
+func compileKey(key string) (*regexp.Regexp, error) {
+	switch {
+	case isRegexKey(key):
+		regex, err := regexp.Compile(key[1 : len(key)-1])
+		if err != nil {
+			return nil, fmt.Errorf("key: %s compile failed, err: %v", key, err)
+		}
+		return regex, nil
+	case isGlobKey(key):
+		_, err := path.Match(key, "")
+		if err != nil {
+			return nil, fmt.Errorf("key: %s is not a valid glob, err: %v", key, err)
+		}
+	}
+
+	return nil, nil
+}
+
+// lowerKey lowers the key, regex keys are kept as is as lowering
+// can change their meaning, for example \S and \s
+func lowerKey(key string) string {
+	if isRegexKey(key) {
+		return key
+	}
+
+	return strings.ToLower(key)
+}
+
+// MatchKey tells if the name matches the key of the mask configuration.
+// It compiles regex keys on every call, MaskConfig uses the keys compiled
+// at load instead.
+func MatchKey(key, name string) bool {
+	regex, err := compileKey(key)
+	if err != nil {
+		return false
+	}
+
+	return matchKey(key, regex, name)
+}
+
+func matchKey(key string, regex *regexp.Regexp, name string) bool {
+	switch {
+	case regex != nil:
+		return regex.MatchString(name)
+	case isGlobKey(key):
+		ok, _ := path.Match(key, name)
+		return ok
+	default:
+		return key == name
+	}
+}
+
+// compileKeys compiles the keys of the configuration and stores the table
+// keys of every section sorted by precedence, this is done at load so that
+// the lookups done while masking do not write to the config.
+func (m *MaskConfig) compileKeys() error {
+	m.keyRegexes = make(map[string]*regexp.Regexp)
+	m.tableKeys = make(map[string][]string)
+	m.columnKeys = make(map[string][]string)
+
+	addKey := func(key string) error {
+		regex, err := compileKey(key)
+		if err != nil {
+			return err
+		}
+		if regex != nil {
+			m.keyRegexes[key] = regex
+		}
+		return nil
+	}
+
+	addSection := func(section string, tables []string) error {
+		for _, table := range tables {
+			err := addKey(table)
+			if err != nil {
+				return fmt.Errorf("%s: %v", section, err)
+			}
+		}
+		m.tableKeys[section] = sortKeys(tables)
+		return nil
+	}
+
+	addColumns := func(section, table string, columns []string) error {
+		for _, column := range columns {
+			err := addKey(column)
+			if err != nil {
+				return fmt.Errorf("%s: table: %s, %v", section, table, err)
+			}
+		}
+		m.columnKeys[section+"/"+table] = sortKeys(columns)
+		return nil
+	}
+
+	for section, keys := range map[string]map[string][]string{
+		nonPiiKeysSection:     m.NonPiiKeys,
+		lengthKeysSection:     m.LengthKeys,
+		mobileKeysSection:     m.MobileKeys,
+		mappingPIIKeysSection: m.MappingPIIKeys,
+		sortKeysSection:       m.SortKeys,
+		distKeysSection:       m.DistKeys,
+		excludeColumnsSection: m.ExcludeColumns,
+	} {
+		tables := []string{}
+		for table, columns := range keys {
+			tables = append(tables, table)
+			err := addColumns(section, table, append([]string{}, columns...))
+			if err != nil {
+				return err
+			}
+		}
+		err := addSection(section, tables)
+		if err != nil {
+			return err
+		}
+	}
+
+	for section, keys := range map[string]map[string]interface{}{
+		conditionalNonPiiKeysSection:   m.ConditionalNonPiiKeys,
+		dependentNonPiiKeysSection:     m.DependentNonPiiKeys,
+		regexPatternBooleanKeysSection: m.RegexPatternBooleanKeys,
+	} {
+		tables := []string{}
+		for table, columnsRaw := range keys {
+			tables = append(tables, table)
+			columnsToCheck, ok := columnsRaw.(map[interface{}]interface{})
+			if !ok {
+				return fmt.Errorf(
+					"%s: table: %s, expected columns map", section, table)
+			}
+			columns := []string{}
+			loweredColumns := make(map[interface{}]interface{})
+			for columnRaw, value := range columnsToCheck {
+				column, ok := columnRaw.(string)
+				if !ok {
+					return fmt.Errorf(
+						"%s: table: %s, column: %v is not a string",
+						section, table, columnRaw)
+				}
+				column = lowerKey(column)
+				columns = append(columns, column)
+				loweredColumns[column] = value
+			}
+			keys[table] = loweredColumns
+			err := addColumns(section, table, columns)
+			if err != nil {
+				return err
+			}
+		}
+		err := addSection(section, tables)
+		if err != nil {
+			return err
+		}
+	}
+
+	tables := []string{}
+	for table, functions := range m.MaskFunctions {
+		tables = append(tables, table)
+		columns := []string{}
+		for column := range functions {
+			columns = append(columns, column)
+		}
+		err := addColumns(maskFunctionsSection, table, columns)
+		if err != nil {
+			return err
+		}
+	}
+	err := addSection(maskFunctionsSection, tables)
+	if err != nil {
+		return err
+	}
+
+	tables = []string{}
+	for table, generalizations := range m.GeneralizeKeys {
+		tables = append(tables, table)
+		columns := []string{}
+		for column := range generalizations {
+			columns = append(columns, column)
+		}
+		err := addColumns(generalizeKeysSection, table, columns)
+		if err != nil {
+			return err
+		}
+	}
+	err = addSection(generalizeKeysSection, tables)
+	if err != nil {
+		return err
+	}
+
+	tables = []string{}
+	for table, columns := range m.jsonPathColumns {
+		tables = append(tables, table)
+		err := addColumns(
+			jsonPathKeysSection, table, append([]string{}, columns...))
+		if err != nil {
+			return err
+		}
+	}
+	err = addSection(jsonPathKeysSection, tables)
+	if err != nil {
+		return err
+	}
+
+	tables = []string{}
+	for table := range m.RowFilters {
+		tables = append(tables, table)
+	}
+	err = addSection(rowFiltersSection, tables)
+	if err != nil {
+		return err
+	}
+
+	if m.IncludeTables != nil {
+		for _, table := range *m.IncludeTables {
+			err := addKey(table)
+			if err != nil {
+				return fmt.Errorf("include_tables: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// match tells if the name matches the key compiled at load
+func (m MaskConfig) match(key, name string) bool {
+	return matchKey(key, m.keyRegexes[key], name)
+}
+
+// matchingTables returns the table keys of the section matching the table
+// in the order of precedence.
+func (m MaskConfig) matchingTables(section, table string) []string {
+	var tables []string
+	for _, key := range m.tableKeys[section] {
+		if m.match(key, table) {
+			tables = append(tables, key)
+		}
+	}
+
+	return tables
+}
+
+// matchingColumn returns the first column key of the table key of the
+// section matching the column in the order of precedence.
+func (m MaskConfig) matchingColumn(
+	section, tableKey, cName string) (string, bool) {
+
+	for _, key := range m.columnKeys[section+"/"+tableKey] {
+		if m.match(key, cName) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// hasColumn tells if the column of the table is listed in the section by
+// any of the table keys matching the table.
+func (m MaskConfig) hasColumn(section, table, cName string) bool {
+	for _, tableKey := range m.matchingTables(section, table) {
+		_, ok := m.matchingColumn(section, tableKey, cName)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// columnConfigKeys returns the table key of the section with the highest
+// precedence which configures the column and the column key configuring it.
+func (m MaskConfig) columnConfigKeys(
+	section, table, cName string) (string, string, bool) {
+
+	for _, tableKey := range m.matchingTables(section, table) {
+		columnKey, ok := m.matchingColumn(section, tableKey, cName)
+		if ok {
+			return tableKey, columnKey, true
+		}
+	}
+
+	return "", "", false
+}
+
+// columnConfig returns the configuration of the column from the table key
+// of the section with the highest precedence which configures the column.
+func (m MaskConfig) columnConfig(
+	section string,
+	keys map[string]interface{},
+	table, cName string) (interface{}, bool) {
+
+	tableKey, columnKey, ok := m.columnConfigKeys(section, table, cName)
+	if !ok {
+		return nil, false
+	}
+
+	return keys[tableKey].(map[interface{}]interface{})[columnKey], true
+}
+
+// IncludeTable tells if the table is allowed to be sinked by the
+// include tables, all the tables are allowed when it is not specified.
+func (m MaskConfig) IncludeTable(table string) bool {
+	if m.IncludeTables == nil {
+		return true
+	}
+	for _, key := range *m.IncludeTables {
+		if m.match(key, table) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpandIncludeTables returns the include tables with the pattern keys
+// replaced by the tables matching them.
+func (m MaskConfig) ExpandIncludeTables(tables []string) []string {
+	if m.IncludeTables == nil {
+		return nil
+	}
+
+	expanded := []string{}
+	seen := make(map[string]bool)
+	add := func(table string) {
+		if !seen[table] {
+			seen[table] = true
+			expanded = append(expanded, table)
+		}
+	}
+	for _, key := range *m.IncludeTables {
+		if !isPatternKey(key) {
+			add(key)
+			continue
+		}
+		for _, table := range tables {
+			if m.match(key, table) {
+				add(table)
+			}
+		}
+	}
+
+	return expanded
+}
@@ -0,0 +1,265 @@
+package masker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSortKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := sortKeys([]string{
+		"*", "orders_*", "/^orders_[0-9]+$/", "orders", "a*", "customers",
+	})
+	expected := []string{
+		"customers", "orders", "/^orders_[0-9]+$/", "a*", "orders_*", "*",
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected: %v, got: %v\n", expected, keys)
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		match bool
+	}{
+		{
+			name:  "test1: exact key matches the same name",
+			key:   "orders",
+			value: "orders",
+			match: true,
+		},
+		{
+			name:  "test2: exact key does not match a prefix",
+			key:   "orders",
+			value: "orders_2020",
+			match: false,
+		},
+		{
+			name:  "test3: glob key matches",
+			key:   "orders_*",
+			value: "orders_2020",
+			match: true,
+		},
+		{
+			name:  "test4: all key matches everything",
+			key:   "*",
+			value: "customers",
+			match: true,
+		},
+		{
+			name:  "test5: regex key matches",
+			key:   "/^orders_[0-9]{4}$/",
+			value: "orders_2020",
+			match: true,
+		},
+		{
+			name:  "test6: regex key does not match",
+			key:   "/^orders_[0-9]{4}$/",
+			value: "orders_archive",
+			match: false,
+		},
+		{
+			name:  "test7: invalid regex key matches nothing",
+			key:   "/^orders_[/",
+			value: "orders_",
+			match: false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			match := MatchKey(tc.key, tc.value)
+			if match != tc.match {
+				t.Errorf("expected: %v, got: %v\n", tc.match, match)
+			}
+		})
+	}
+}
+
+func TestPatternKeys(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMaskConfig(
+		"/", filepath.Join(dir, "database_pattern.yaml"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unMaskTests := []struct {
+		name     string
+		table    string
+		cName    string
+		cValue   *string
+		unMasked bool
+	}{
+		{
+			name:     "test1: all tables key unmasks",
+			table:    "customers",
+			cName:    "id",
+			unMasked: true,
+		},
+		{
+			name:     "test2: glob column of all tables key unmasks",
+			table:    "customers",
+			cName:    "created_at",
+			unMasked: true,
+		},
+		{
+			name:     "test3: glob table key unmasks",
+			table:    "orders_2019",
+			cName:    "amount",
+			unMasked: true,
+		},
+		{
+			name:     "test4: columns of all the matching keys are used",
+			table:    "orders_2020",
+			cName:    "amount",
+			unMasked: true,
+		},
+		{
+			name:     "test5: exact table key unmasks",
+			table:    "orders_2020",
+			cName:    "currency",
+			unMasked: true,
+		},
+		{
+			name:     "test6: exact table key does not apply to others",
+			table:    "orders_2019",
+			cName:    "currency",
+			unMasked: false,
+		},
+		{
+			name:     "test7: regex table key unmasks",
+			table:    "payments_2020",
+			cName:    "status",
+			unMasked: true,
+		},
+		{
+			name:     "test8: regex table key does not match",
+			table:    "payments_archive",
+			cName:    "status",
+			unMasked: false,
+		},
+		{
+			name:     "test9: conditional key of all tables unmasks",
+			table:    "customers",
+			cName:    "type",
+			cValue:   stringPtr("public_profile"),
+			unMasked: true,
+		},
+		{
+			name:     "test10: exact conditional key has precedence",
+			table:    "orders_2020",
+			cName:    "type",
+			cValue:   stringPtr("public_profile"),
+			unMasked: false,
+		},
+		{
+			name:     "test11: exact conditional key unmasks",
+			table:    "orders_2020",
+			cName:    "type",
+			cValue:   stringPtr("internal"),
+			unMasked: true,
+		},
+	}
+
+	for _, tc := range unMaskTests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			unMasked := m.PerformUnMasking(tc.table, tc.cName, tc.cValue, nil)
+			if unMasked != tc.unMasked {
+				t.Errorf("expected: %v, got: %v\n", tc.unMasked, unMasked)
+			}
+		})
+	}
+
+	if !m.LengthKey("customers", "remarks") || m.LengthKey("customers", "notes_x") {
+		t.Errorf("regex column key of length keys did not match")
+	}
+
+	boolColumns := m.BoolColumns(
+		"orders_2020", "delivery_text", stringPtr("see http://x.com"))
+	expectedBoolColumns := map[string]*string{
+		"delivery_text_has_url": stringPtr("true"),
+	}
+	if !reflect.DeepEqual(boolColumns, expectedBoolColumns) {
+		t.Errorf("expected: %v, got: %v\n", expectedBoolColumns, boolColumns)
+	}
+
+	functionTests := []struct {
+		table    string
+		cName    string
+		function string
+	}{
+		{table: "customers", cName: "email", function: "hmac_sha256"},
+		{table: "orders_2020", cName: "email", function: "keep_email_domain"},
+		{table: "orders_2020", cName: "home_phone", function: "keep_last4"},
+		{table: "customers", cName: "home_phone", function: ""},
+	}
+	for _, tc := range functionTests {
+		function := m.MaskFunction(tc.table, tc.cName).Function
+		if function != tc.function {
+			t.Errorf("table: %s, cName: %s, expected: %v, got: %v\n",
+				tc.table, tc.cName, tc.function, function)
+		}
+	}
+
+	// row filters of the highest precedence key are used
+	if m.KeepRow("customers", map[string]*string{"deleted": stringPtr("1")}) {
+		t.Errorf("expected the row to be filtered by the all tables key")
+	}
+	if !m.KeepRow("orders_2020", map[string]*string{
+		"deleted": stringPtr("1"), "status": stringPtr("paid")}) {
+		t.Errorf("expected the row to be kept by the exact table key")
+	}
+}
+
+func TestExpandIncludeTables(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMaskConfig(
+		"/", filepath.Join(dir, "database_pattern.yaml"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []string{
+		"customers", "orders_2019", "orders_2020", "payments_2020", "leads",
+	}
+	included := []string{}
+	for _, table := range tables {
+		if m.IncludeTable(table) {
+			included = append(included, table)
+		}
+	}
+	expected := []string{
+		"customers", "orders_2019", "orders_2020", "payments_2020",
+	}
+	if !reflect.DeepEqual(included, expected) {
+		t.Errorf("expected: %v, got: %v\n", expected, included)
+	}
+
+	expanded := m.ExpandIncludeTables(included)
+	if !reflect.DeepEqual(expanded, expected) {
+		t.Errorf("expected: %v, got: %v\n", expected, expanded)
+	}
+}
@@ -19,6 +19,8 @@ type masker struct {
 	database string
 	table    string
 	topic    string
+	// tableKey is the key derived from the salt for the table
+	tableKey []byte
 
 	config MaskConfig
 }
@@ -30,6 +32,7 @@ func NewMsgMasker(salt string, topic string, config MaskConfig) transformer.Mess
 		salt:     salt,
 		database: database,
 		table:    table,
+		tableKey: tableKey(salt, database, table),
 		config:   config,
 	}
 }
@@ -58,6 +61,16 @@ func addMissingColumn(rawColumns map[string]*string, cols []redshift.ColInfo) {
 	}
 }
 
+// columnInfos returns the columns of the table by their lowered names
+func columnInfos(cols []redshift.ColInfo) map[string]redshift.ColInfo {
+	infos := make(map[string]redshift.ColInfo)
+	for _, col := range cols {
+		infos[strings.ToLower(col.Name)] = col
+	}
+
+	return infos
+}
+
 // Transform masks the message based on the masking rules specified in the
 // configuration file at: maskConfigDir + database.yaml
 // Default: mask everything, unless specified not to in the configuraton
@@ -81,6 +94,10 @@ func (m *masker) Transform(
 	extraMaskSchema := make(map[string]serializer.ExtraMaskInfo)
 	extraColumnValue := make(map[string]*string)
 	mappingPIIKeyTable := m.config.hasMappingPIIKey(m.table)
+	var tableColumns map[string]redshift.ColInfo
+	if len(m.config.GeneralizeKeys) > 0 {
+		tableColumns = columnInfos(table.Columns)
+	}
 
 	for cName, cVal := range rawColumns {
 		unmasked := m.config.PerformUnMasking(m.table, cName, cVal, rawColumns)
@@ -92,10 +109,14 @@ func (m *masker) Transform(
 		dependentNonPiiKey := m.config.DependentNonPiiKey(m.table, cName)
 		conditionalNonPiiKey := m.config.ConditionalNonPiiKey(m.table, cName)
 		boolColumns := m.config.BoolColumns(m.table, cName, cVal)
+		excludeColumn := m.config.ExcludeColumn(m.table, cName)
+		jsonPaths := m.config.columnJSONPaths(m.table, cName)
+		jsonPathKey := len(jsonPaths) > 0
+		generalization, generalizeKey := m.config.Generalization(m.table, cName)
 
 		// extraColumns store the mask info for extra columns
 		// extra columns are added for the following keys:
-		// LengthKey, MobileKey, MappingPIIKey and Boolean keys
+		// LengthKey, MobileKey, MappingPIIKey, Boolean and Generalize keys
 		if lengthKey {
 			var length int
 			if cVal != nil {
@@ -154,6 +175,18 @@ func (m *masker) Transform(
 				}
 				extraColumnValue[boolCol] = boolVal
 			}
+		}
+		if generalizeKey {
+			var generalized *string
+			if cVal != nil {
+				generalized = generalization.Generalize(*cVal, tableColumns[cName])
+			}
+			extraColumnName := generalization.ColumnName(cName)
+			extraMaskSchema[extraColumnName] = serializer.ExtraMaskInfo{
+				Masked:     false,
+				ColumnType: generalization.ColumnType(),
+			}
+			extraColumnValue[extraColumnName] = generalized
 		} // all extra columns handled
 
 		// special case for mapping PII keys
@@ -161,12 +194,24 @@ func (m *masker) Transform(
 			unmasked = true
 		}
 
+		// json columns are kept readable except the values at the paths
+		if jsonPathKey {
+			unmasked = true
+		}
+
 		if cVal == nil || strings.TrimSpace(*cVal) == "" {
 			columns[cName] = nil
+		} else if jsonPathKey {
+			columns[cName] = m.maskJSON(cName, *cVal, jsonPaths)
 		} else if unmasked {
 			columns[cName] = cVal
 		} else {
-			columns[cName] = Mask(*cVal, m.salt)
+			columns[cName] = m.mask(cName, *cVal)
+		}
+
+		// excluded columns do not reach s3, its extra columns still do
+		if excludeColumn {
+			delete(columns, cName)
 		}
 
 		// This has no meaning, and is not used, as StringMax is used
@@ -176,6 +221,11 @@ func (m *masker) Transform(
 			unmasked = false
 		}
 
+		var maskFunction string
+		if !unmasked {
+			maskFunction = m.config.MaskFunction(m.table, cName).Function
+		}
+
 		maskSchema[cName] = serializer.MaskInfo{
 			Masked: !unmasked,
 
@@ -188,6 +238,9 @@ func (m *masker) Transform(
 			ConditionalNonPIICol:   conditionalNonPiiKey,
 			DependentNonPIICol:     dependentNonPiiKey,
 			RegexPatternBooleanCol: boolColumnKey,
+			ExcludedCol:            excludeColumn,
+			JSONPathCol:            jsonPathKey,
+			MaskFunction:           maskFunction,
 		}
 	}
 
@@ -90,7 +90,10 @@ func testMasker(t *testing.T, salt, topic, cName string,
 				maskColumn.SortCol != maskInfo.SortCol ||
 				maskColumn.DistCol != maskInfo.DistCol ||
 				maskColumn.LengthCol != maskInfo.LengthCol ||
-				maskColumn.MobileCol != maskInfo.MobileCol {
+				maskColumn.MobileCol != maskInfo.MobileCol ||
+				maskColumn.ExcludedCol != maskInfo.ExcludedCol ||
+				maskColumn.JSONPathCol != maskInfo.JSONPathCol ||
+				maskColumn.MaskFunction != maskInfo.MaskFunction {
 				t.Errorf(
 					"column=%v, maskColumn=%+v does not match %+v\n",
 					column, maskColumn, maskInfo)
@@ -645,6 +648,340 @@ func TestMasker(t *testing.T) {
 			},
 			redshiftTable: redshift.Table{},
 		},
+		{
+			name:  "test26 excluded column is removed",
+			topic: "dbserver.database.notes",
+			cName: "body",
+			columns: map[string]*string{
+				"id":   stringPtr("1"),
+				"body": stringPtr("long note"),
+			},
+			resultVal: nil,
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"body": serializer.MaskInfo{
+					Masked:      true,
+					LengthCol:   true,
+					ExcludedCol: true,
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test27 extra columns of excluded column are kept",
+			topic: "dbserver.database.notes",
+			cName: "body_length",
+			columns: map[string]*string{
+				"id":   stringPtr("1"),
+				"body": stringPtr("long note"),
+			},
+			resultVal: stringPtr("9"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"id": serializer.MaskInfo{Masked: true},
+			},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{
+				"body_length": serializer.ExtraMaskInfo{
+					Masked:     false,
+					ColumnType: "integer",
+					DefaultVal: "0",
+				},
+			},
+			redshiftTable: redshift.Table{},
+		},
+		{
+			name:  "test28 keep_email_domain mask function",
+			topic: "dbserver.database.leads",
+			cName: "email",
+			columns: map[string]*string{
+				"email": stringPtr("lead@practo.com"),
+			},
+			resultVal: stringPtr("14b2db7f3dbfeddaea0a34948ab1b694ca8724450e973afc7fda0021663b372b@practo.com"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"email": serializer.MaskInfo{
+					Masked:       true,
+					MaskFunction: "keep_email_domain",
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test29 hmac_sha256_table mask function",
+			topic: "dbserver.database.leads",
+			cName: "mobile",
+			columns: map[string]*string{
+				"mobile": stringPtr("9876543210"),
+			},
+			resultVal: stringPtr("7742bc4080d5d1d4eafd4a3e60d43357ec9e9c7f681bdaea2f32f87cc4d44a50"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"mobile": serializer.MaskInfo{
+					Masked:       true,
+					MaskFunction: "hmac_sha256_table",
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test30 keep_last4 mask function",
+			topic: "dbserver.database.leads",
+			cName: "pan",
+			columns: map[string]*string{
+				"pan": stringPtr("ABCDE1234F"),
+			},
+			resultVal: stringPtr("******234F"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"pan": serializer.MaskInfo{
+					Masked:       true,
+					MaskFunction: "keep_last4",
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test31 keep_length mask function",
+			topic: "dbserver.database.leads",
+			cName: "name",
+			columns: map[string]*string{
+				"name": stringPtr("Batman"),
+			},
+			resultVal: stringPtr("******"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"name": serializer.MaskInfo{
+					Masked:       true,
+					MaskFunction: "keep_length",
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test32 constant mask function",
+			topic: "dbserver.database.leads",
+			cName: "notes",
+			columns: map[string]*string{
+				"notes": stringPtr("secret"),
+			},
+			resultVal: stringPtr("REDACTED"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"notes": serializer.MaskInfo{
+					Masked:       true,
+					MaskFunction: "constant",
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test33 null mask function",
+			topic: "dbserver.database.leads",
+			cName: "dob",
+			columns: map[string]*string{
+				"dob": stringPtr("1988-09-21"),
+			},
+			resultVal: nil,
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"dob": serializer.MaskInfo{
+					Masked:       true,
+					MaskFunction: "null",
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test34.1 conditional condition unmasks",
+			topic: "dbserver.database.doctors",
+			cName: "city",
+			columns: map[string]*string{
+				"city":    stringPtr("Bangalore"),
+				"country": nil,
+			},
+			resultVal: stringPtr("Bangalore"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"city": serializer.MaskInfo{Masked: true},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test34.2 dependent condition unmasks",
+			topic: "dbserver.database.doctors",
+			cName: "name",
+			columns: map[string]*string{
+				"name":       stringPtr("Strange"),
+				"verified":   stringPtr("1"),
+				"experience": stringPtr("4"),
+				"email":      stringPtr("strange@practo.com"),
+			},
+			resultVal: stringPtr("Strange"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"name": serializer.MaskInfo{Masked: true},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test34.3 dependent condition on null keeps masked",
+			topic: "dbserver.database.doctors",
+			cName: "name",
+			columns: map[string]*string{
+				"name":       stringPtr("Strange"),
+				"verified":   nil,
+				"experience": stringPtr("40"),
+			},
+			resultVal: stringPtr("a8c55c2aceda9a01d8942a33fe96001044f5da85"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"name": serializer.MaskInfo{Masked: true},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test34 json path keys mask only the paths",
+			topic: "dbserver.database.patients",
+			cName: "metadata",
+			columns: map[string]*string{
+				"metadata": stringPtr(`{"address":{"city":"Bangalore","phone":9876543210},"phones":["98765","null"],"documents":[{"type":"pan","number":"ABCDE1234F"},{"type":"passport"}],"age":32}`),
+			},
+			resultVal: stringPtr(`{"address":{"city":"Bangalore","phone":"08e9bdaf6e462787c19688ab25e40bd2f408814b"},"age":32,"documents":[{"number":"9188b6d360b5df52f8811cf57a0b010a189c88d4","type":"pan"},{"type":"passport"}],"phones":["8d620ad93f1914801e840e459620d0afd8fdb098","bbf996cdb63e6f237a70c8281daf80bcbb10aaed"]}`),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"metadata": serializer.MaskInfo{
+					Masked:      false,
+					JSONPathCol: true,
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test35 json path keys use the mask function",
+			topic: "dbserver.database.visits",
+			cName: "metadata",
+			columns: map[string]*string{
+				"metadata": stringPtr(`{"doctor":{"id":1,"name":"Dr. <Strange>"}}`),
+			},
+			resultVal: stringPtr(`{"doctor":{"id":1,"name":"REDACTED"}}`),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"metadata": serializer.MaskInfo{
+					Masked:      false,
+					JSONPathCol: true,
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test36 json path keys mask invalid json completely",
+			topic: "dbserver.database.visits",
+			cName: "metadata",
+			columns: map[string]*string{
+				"metadata": stringPtr(`not json`),
+			},
+			resultVal: stringPtr("REDACTED"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"metadata": serializer.MaskInfo{
+					Masked:      false,
+					JSONPathCol: true,
+				},
+			},
+			extraMaskSchema: make(map[string]serializer.ExtraMaskInfo),
+			redshiftTable:   redshift.Table{},
+		},
+		{
+			name:  "test37 generalize raw debezium date to year",
+			topic: "dbserver.database.customers",
+			cName: "dob_year",
+			columns: map[string]*string{
+				"dob": stringPtr("6838"),
+			},
+			resultVal: stringPtr("1988-01-01"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"dob": serializer.MaskInfo{Masked: true},
+			},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{
+				"dob_year": serializer.ExtraMaskInfo{
+					Masked:     false,
+					ColumnType: "date",
+				},
+			},
+			redshiftTable: redshift.Table{
+				Columns: []redshift.ColInfo{
+					redshift.ColInfo{
+						Name:        "dob",
+						LogicalType: "io.debezium.time.Date",
+					},
+				},
+			},
+		},
+		{
+			name:  "test38 generalize formatted timestamp to month",
+			topic: "dbserver.database.patients",
+			cName: "dob_month",
+			columns: map[string]*string{
+				"dob": stringPtr("1990-04-12 10:00:00"),
+			},
+			resultVal: stringPtr("1990-04-01"),
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{
+				"dob_month": serializer.ExtraMaskInfo{
+					Masked:     false,
+					ColumnType: "date",
+				},
+			},
+			redshiftTable: redshift.Table{},
+		},
+		{
+			name:  "test39 generalize number to bucket",
+			topic: "dbserver.database.patients",
+			cName: "age_bucket",
+			columns: map[string]*string{
+				"age": stringPtr("37"),
+			},
+			resultVal: stringPtr("30"),
+			resultMaskSchema: map[string]serializer.MaskInfo{
+				"age": serializer.MaskInfo{Masked: true},
+			},
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{
+				"age_bucket": serializer.ExtraMaskInfo{
+					Masked:     false,
+					ColumnType: "numeric(38,0)",
+				},
+			},
+			redshiftTable: redshift.Table{},
+		},
+		{
+			name:  "test40 generalize number by truncating",
+			topic: "dbserver.database.patients",
+			cName: "latitude_truncate",
+			columns: map[string]*string{
+				"latitude": stringPtr("12.97123"),
+			},
+			resultVal: stringPtr("12.97"),
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{
+				"latitude_truncate": serializer.ExtraMaskInfo{
+					Masked:     false,
+					ColumnType: "numeric(38,2)",
+				},
+			},
+			redshiftTable: redshift.Table{},
+		},
+		{
+			name:  "test41 generalize null is null",
+			topic: "dbserver.database.patients",
+			cName: "salary_round",
+			columns: map[string]*string{
+				"salary": nil,
+			},
+			resultVal: nil,
+			extraMaskSchema: map[string]serializer.ExtraMaskInfo{
+				"salary_round": serializer.ExtraMaskInfo{
+					Masked:     false,
+					ColumnType: "numeric(38,0)",
+				},
+			},
+			redshiftTable: redshift.Table{},
+		},
 	}
 
 	for _, tc := range tests {
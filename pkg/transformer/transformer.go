@@ -1,9 +1,13 @@
 package transformer
 
 import (
+	"fmt"
 	"github.com/practo/tipoca-stream/pkg/redshift"
 	"github.com/practo/tipoca-stream/pkg/serializer"
 	"strings"
+	"time"
+	// timezone database is embedded as the images do not ship it
+	_ "time/tzdata"
 )
 
 const (
@@ -16,6 +20,137 @@ const (
 	MappingPIIColumnPrefix = "hashed_"
 )
 
+// Mask functions specify how the masked columns are masked.
+// When not specified MaskSHA1 is used (legacy).
+const (
+	// MaskSHA1 is the hex of sha1(value+salt)
+	MaskSHA1 = "sha1"
+	// MaskHMACSHA256 is the hex of HMAC-SHA256 keyed with the salt
+	MaskHMACSHA256 = "hmac_sha256"
+	// MaskHMACSHA256Table is MaskHMACSHA256 keyed with a key derived from
+	// the salt for the table, equal values do not match across tables.
+	MaskHMACSHA256Table = "hmac_sha256_table"
+	// MaskKeepEmailDomain masks the local part of the email using
+	// MaskHMACSHA256 and keeps the domain.
+	MaskKeepEmailDomain = "keep_email_domain"
+	// MaskKeepLast4 replaces all but the last 4 characters with '*'
+	MaskKeepLast4 = "keep_last4"
+	// MaskKeepLength replaces all the characters with '*'
+	MaskKeepLength = "keep_length"
+	// MaskNull replaces the value with NULL
+	MaskNull = "null"
+	// MaskConstant replaces the value with a constant
+	MaskConstant = "constant"
+)
+
+// Generalize functions keep an approximate value of the column in an extra
+// typed column named <column>_<function>, so that it stays queryable.
+const (
+	// GeneralizeYear truncates the date to the year, 1990-04-12: 1990-01-01
+	GeneralizeYear = "year"
+	// GeneralizeMonth truncates the date to the month, 1990-04-12: 1990-04-01
+	GeneralizeMonth = "month"
+	// GeneralizeBucket floors the number to a multiple of the size,
+	// 37 with size 10: 30
+	GeneralizeBucket = "bucket"
+	// GeneralizeRound rounds the number to the nearest multiple of the size,
+	// 123456 with size 1000: 123000
+	GeneralizeRound = "round"
+	// GeneralizeTruncate truncates the number to the decimals,
+	// 12.97123 with decimals 2: 12.97
+	GeneralizeTruncate = "truncate"
+)
+
+// Time types specify how the MySQL TIME columns are stored in Redshift.
+// When not specified the raw Debezium value is kept in a varchar (legacy).
+const (
+	// TimeTypeVarchar keeps the time formatted as [-]HHH:MM:SS[.ffffff]
+	// in a varchar. It supports the complete MySQL TIME range.
+	TimeTypeVarchar = "varchar"
+	// TimeTypeTime uses Redshift TIME (or TIMETZ if TimestampWithTimezone
+	// is set). Supports values from 00:00:00 to 24:00:00 only.
+	TimeTypeTime = "time"
+	// TimeTypeSeconds stores the time as signed number of seconds with
+	// microsecond precision. It supports the complete MySQL TIME range.
+	TimeTypeSeconds = "seconds"
+)
+
+// TimezoneConfig specifies how the temporal columns are interpreted and
+// stored in Redshift.
+type TimezoneConfig struct {
+	// Source is the IANA timezone name in which the source database writes
+	// the temporal columns which do not carry a timezone, like MySQL
+	// DATETIME. Values of such columns are converted to UTC.
+	// Defaults to UTC.
+	Source string `yaml:"source,omitempty"`
+	// Databases overrides Source for the databases, keyed by database name.
+	Databases map[string]string `yaml:"databases,omitempty"`
+	// Tables overrides Databases for the tables, keyed by database.table
+	Tables map[string]string `yaml:"tables,omitempty"`
+	// TimestampWithTimezone maps the timestamp columns to TIMESTAMPTZ
+	// instead of TIMESTAMP.
+	TimestampWithTimezone bool `yaml:"timestampWithTimezone,omitempty"`
+	// TimeType is the Redshift type to store the TIME columns in.
+	// One of: varchar, time, seconds. Defaults to the legacy behaviour.
+	TimeType string `yaml:"timeType,omitempty"`
+	// TimeColumns overrides TimeType for the columns which need it,
+	// keyed by database.table.column. Columns having values outside the
+	// Redshift TIME range can use varchar or seconds this way.
+	TimeColumns map[string]string `yaml:"timeColumns,omitempty"`
+}
+
+// Validate validates the time types, timezones are validated by Location
+func (t TimezoneConfig) Validate() error {
+	timeTypes := []string{t.TimeType}
+	for _, timeType := range t.TimeColumns {
+		timeTypes = append(timeTypes, timeType)
+	}
+	for _, timeType := range timeTypes {
+		switch timeType {
+		case "", TimeTypeVarchar, TimeTypeTime, TimeTypeSeconds:
+		default:
+			return fmt.Errorf("Unsupported timeType: %s", timeType)
+		}
+	}
+
+	return nil
+}
+
+// ColumnTimeType returns the time type of the TIME column.
+// Column level configuration takes precedence over TimeType.
+func (t TimezoneConfig) ColumnTimeType(database, table, column string) string {
+	key := strings.ToLower(database + "." + table + "." + column)
+	if timeType, ok := t.TimeColumns[key]; ok {
+		return timeType
+	}
+
+	return t.TimeType
+}
+
+// Location returns the source timezone of the table. Table level
+// configuration takes precedence over the database level configuration.
+func (t TimezoneConfig) Location(database, table string) (*time.Location, error) {
+	name := t.Source
+	if tz, ok := t.Databases[strings.ToLower(database)]; ok {
+		name = tz
+	}
+	if tz, ok := t.Tables[strings.ToLower(database+"."+table)]; ok {
+		name = tz
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Error loading timezone: %s for %s.%s, err: %v",
+			name, database, table, err)
+	}
+
+	return location, nil
+}
+
 type MessageTransformer interface {
 	Transform(message *serializer.Message, table redshift.Table) error
 }
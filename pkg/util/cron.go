@@ -0,0 +1,153 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed standard cron schedule with the 5 fields:
+// minute hour day-of-month month day-of-week. The fields support
+// *, values, ranges (1-5), lists (1,15) and steps (*/15, 0-30/10).
+type CronSchedule struct {
+	minute     map[int]bool
+	hour       map[int]bool
+	dayOfMonth map[int]bool
+	month      map[int]bool
+	dayOfWeek  map[int]bool
+
+	// anyDayOfMonth and anyDayOfWeek are used to match the days
+	// like cron, the day matches either of them when both are restricted.
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day-of-month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day-of-week", min: 0, max: 6},
+}
+
+// ParseCronSchedule parses the standard 5 field cron schedule
+func ParseCronSchedule(schedule string) (*CronSchedule, error) {
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf(
+			"cron schedule: %q should have %d fields, got: %d",
+			schedule, len(cronFields), len(fields))
+	}
+
+	values := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		// 7 is also sunday
+		max := cronFields[i].max
+		if cronFields[i].name == "day-of-week" {
+			max = 7
+		}
+		v, err := parseCronField(field, cronFields[i].min, max)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cron schedule: %q, invalid %s: %v",
+				schedule, cronFields[i].name, err)
+		}
+		values[i] = v
+	}
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return &CronSchedule{
+		minute:        values[0],
+		hour:          values[1],
+		dayOfMonth:    values[2],
+		month:         values[3],
+		dayOfWeek:     values[4],
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}, nil
+}
+
+// parseCronField parses the comma separated values of the field
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i != -1 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step: %q", part)
+			}
+			part = part[:i]
+		}
+
+		start, end := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range: %q", part)
+			}
+			end, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range: %q", part)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value: %q", part)
+			}
+			start, end = value, value
+		}
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf(
+				"%q is out of the range %d-%d", part, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+// Matches tells if the schedule runs at the minute of the time
+func (c *CronSchedule) Matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+
+	dayOfMonth := c.dayOfMonth[t.Day()]
+	dayOfWeek := c.dayOfWeek[int(t.Weekday())]
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+
+	return dayOfMonth || dayOfWeek
+}
+
+// Within tells if the time is within the duration after any of the runs
+// of the schedule, i.e. the window starting at a run is open at the time.
+func (c *CronSchedule) Within(t time.Time, duration time.Duration) bool {
+	start := t.Truncate(time.Minute)
+	for run := start; t.Sub(run) < duration; run = run.Add(-time.Minute) {
+		if c.Matches(run) {
+			return true
+		}
+	}
+
+	return false
+}
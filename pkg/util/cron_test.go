@@ -0,0 +1,111 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule(t *testing.T) {
+	t.Parallel()
+
+	// 2021-06-07 is a monday
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name     string
+		schedule string
+		time     time.Time
+		duration time.Duration
+		within   bool
+	}{
+		{
+			name:     "weekday night window open",
+			schedule: "0 22 * * 1-5",
+			time:     at("2021-06-08 03:59"),
+			duration: 6 * time.Hour,
+			within:   true,
+		},
+		{
+			name:     "weekday night window closed",
+			schedule: "0 22 * * 1-5",
+			time:     at("2021-06-08 04:00"),
+			duration: 6 * time.Hour,
+			within:   false,
+		},
+		{
+			name:     "weekend window not open on weekday",
+			schedule: "0 0 * * 0,6",
+			time:     at("2021-06-07 10:00"),
+			duration: 24 * time.Hour,
+			within:   false,
+		},
+		{
+			name:     "sunday as 7",
+			schedule: "0 0 * * 7",
+			time:     at("2021-06-06 23:59"),
+			duration: 24 * time.Hour,
+			within:   true,
+		},
+		{
+			name:     "step",
+			schedule: "*/15 * * * *",
+			time:     at("2021-06-07 10:31"),
+			duration: 5 * time.Minute,
+			within:   true,
+		},
+		{
+			name:     "step outside",
+			schedule: "*/15 * * * *",
+			time:     at("2021-06-07 10:36"),
+			duration: 5 * time.Minute,
+			within:   false,
+		},
+		{
+			name:     "day of month or day of week",
+			schedule: "0 0 1 * 1",
+			time:     at("2021-06-07 00:30"),
+			duration: time.Hour,
+			within:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			schedule, err := ParseCronSchedule(tc.schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			within := schedule.Within(tc.time, tc.duration)
+			if within != tc.within {
+				t.Errorf("expected within: %v, got: %v", tc.within, within)
+			}
+		})
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, schedule := range []string{
+		"",
+		"0 22 * *",
+		"60 * * * *",
+		"0 22 * * 1-8",
+		"0 22 5-1 * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		_, err := ParseCronSchedule(schedule)
+		if err == nil {
+			t.Errorf("expected error for schedule: %q", schedule)
+		}
+	}
+}